@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Modo -auto: um único processo simula muitos votantes. Cada voto usa um
+// ClientID próprio (<nome>-<n>), registrado e votando pelo mesmo socket, com
+// a opção sorteada conforme a distribuição configurada. A semente fixa
+// torna a sequência de opções reproduzível.
+
+// Peso de uma opção na distribuição do modo -auto
+type weight struct {
+	option string
+	weight int
+}
+
+// parseDistribution lê "A:50,B:30,C:20"; os pesos não precisam somar 100
+func parseDistribution(spec string) ([]weight, error) {
+	var dist []weight
+	for _, part := range strings.Split(spec, ",") {
+		op, w, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || op == "" {
+			return nil, fmt.Errorf("distribuição inválida %q: esperado OPÇÃO:PESO", part)
+		}
+		n, err := strconv.Atoi(w)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("peso inválido em %q", part)
+		}
+		dist = append(dist, weight{op, n})
+	}
+	if len(dist) == 0 {
+		return nil, errors.New("distribuição vazia")
+	}
+	return dist, nil
+}
+
+// pick sorteia uma opção proporcionalmente aos pesos
+func pick(r *rand.Rand, dist []weight) string {
+	total := 0
+	for _, w := range dist {
+		total += w.weight
+	}
+	n := r.Intn(total)
+	for _, w := range dist {
+		if n < w.weight {
+			return w.option
+		}
+		n -= w.weight
+	}
+	return dist[len(dist)-1].option
+}
+
+// Estado compartilhado entre o envio e a leitura das respostas
+type autoVoter struct {
+	conn  net.Conn
+	token string
+
+	m         sync.Mutex
+	pending   map[int]chan client.Message // SeqNum do REGISTER → resposta
+	confirmed int
+	errs      map[string]int // mensagem de erro → ocorrências
+}
+
+// listen trata as respostas do servidor até a conexão ser fechada
+func (a *autoVoter) listen() {
+	buf := make([]byte, client.MaxMessageSize)
+	for {
+		n, err := a.conn.Read(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		var msg client.Message
+		if json.Unmarshal(buf[:n], &msg) != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "CHALLENGE":
+			// Cada ID recebe o próprio desafio
+			go func(msg client.Message) {
+				nonce := client.SolveChallenge(msg.Challenge, msg.Difficulty)
+				sendMessage(a.conn, client.Message{Type: "REGISTER", ClientID: msg.ClientID, SeqNum: msg.SeqNum,
+					Token: a.token, Challenge: msg.Challenge, Nonce: nonce, Version: client.ProtocolVersion})
+			}(msg)
+		case "ACK", "ERROR":
+			a.reply(msg)
+		}
+	}
+}
+
+// reply entrega a resposta ao REGISTER correspondente ou contabiliza o voto
+func (a *autoVoter) reply(msg client.Message) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if ch, ok := a.pending[msg.SeqNum]; ok && msg.SeqNum != 0 {
+		delete(a.pending, msg.SeqNum)
+		ch <- msg
+		return
+	}
+	switch {
+	case msg.Type == "ACK" && len(msg.Acked) > 0:
+		a.confirmed += len(msg.Acked) // um ou vários votos (ACKs agrupados)
+	case msg.Type == "ACK" && msg.Message == "Voto registrado":
+		a.confirmed++
+	case msg.Type == "ERROR":
+		a.errs[msg.Message]++
+	}
+}
+
+// vote registra o ID (com retransmissão) e envia o voto
+func (a *autoVoter) vote(id string, seq int, option string) error {
+	ch := make(chan client.Message, 1)
+	a.m.Lock()
+	a.pending[seq] = ch
+	a.m.Unlock()
+
+	for attempt := 1; attempt <= client.RegisterAttempts; attempt++ {
+		sendMessage(a.conn, client.Message{Type: "REGISTER", ClientID: id, SeqNum: seq, Token: a.token,
+			Version: client.ProtocolVersion})
+		select {
+		case ack := <-ch:
+			if ack.Type == "ERROR" {
+				return errors.New(ack.Message)
+			}
+			// Token do ACK de registro, exigido com client_secret no servidor
+			sendMessage(a.conn, client.Message{Type: "VOTE", ClientID: id, VoteOption: option, Auth: ack.Auth})
+			return nil
+		case <-time.After(client.RegisterTimeout):
+		}
+	}
+
+	a.m.Lock()
+	delete(a.pending, seq)
+	a.m.Unlock()
+	return fmt.Errorf("sem resposta ao registro de %s", id)
+}
+
+// runAuto envia `count` votos a `rate` por segundo e mostra a distribuição obtida
+func runAuto(conn net.Conn, cfg clientConfig) error {
+	dist, err := parseDistribution(cfg.Auto)
+	if err != nil {
+		return err
+	}
+	if cfg.Rate <= 0 || cfg.Count <= 0 {
+		return errors.New("-rate e -count devem ser positivos")
+	}
+
+	a := &autoVoter{conn: conn, token: cfg.Token, pending: make(map[int]chan client.Message), errs: make(map[string]int)}
+	go a.listen()
+
+	r := rand.New(rand.NewSource(cfg.Seed))
+	sent := make(map[string]int)
+	var regFailed int
+	var wg sync.WaitGroup
+
+	fmt.Printf("Modo automático: %d votos a %.1f/s (semente %d)\n", cfg.Count, cfg.Rate, cfg.Seed)
+	start := time.Now()
+	tick := time.NewTicker(time.Duration(float64(time.Second) / cfg.Rate))
+	defer tick.Stop()
+
+	for i := 1; i <= cfg.Count; i++ {
+		// O sorteio fica no laço principal para a sequência não depender do agendamento
+		option := pick(r, dist)
+		sent[option]++
+
+		wg.Add(1)
+		go func(id string, seq int, option string) {
+			defer wg.Done()
+			if err := a.vote(id, seq, option); err != nil {
+				a.m.Lock()
+				regFailed++
+				a.errs["registro: "+err.Error()]++
+				a.m.Unlock()
+			}
+		}(fmt.Sprintf("%s-%d", cfg.Name, i), i, option)
+
+		if i < cfg.Count {
+			<-tick.C
+		}
+	}
+	wg.Wait()
+
+	// Dá tempo para os últimos ACKs de voto chegarem
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		a.m.Lock()
+		done := a.confirmed+regFailed >= cfg.Count
+		a.m.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+	printAutoReport(dist, sent, cfg.Count, a.confirmed, regFailed, a.errs, time.Since(start))
+	return nil
+}
+
+func sendMessage(c net.Conn, msg client.Message) {
+	data, _ := json.Marshal(msg)
+	_, err := c.Write(data)
+	if err != nil {
+		fmt.Println("Erro ao enviar mensagem:", err)
+	}
+}
+
+func printAutoReport(dist []weight, sent map[string]int, count, confirmed, regFailed int, errs map[string]int, elapsed time.Duration) {
+	total := 0
+	for _, w := range dist {
+		total += w.weight
+	}
+
+	fmt.Println("\n===== MODO AUTOMÁTICO =====")
+	fmt.Printf("Votos          : %d em %s\n", count, elapsed.Round(time.Millisecond))
+	fmt.Println("Confirmados    :", confirmed)
+	fmt.Println("Falha registro :", regFailed)
+	for msg, n := range errs {
+		fmt.Printf("Erro (%s): %d\n", msg, n)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Opção\tEsperado %\tObtido %\tVotos\t")
+	for _, d := range dist {
+		fmt.Fprintf(w, "%s\t%.1f\t%.1f\t%d\t\n", d.option,
+			float64(d.weight)/float64(total)*100,
+			float64(sent[d.option])/float64(count)*100,
+			sent[d.option])
+	}
+	w.Flush()
+	fmt.Println("===========================")
+}
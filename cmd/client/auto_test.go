@@ -0,0 +1,109 @@
+package main
+
+import (
+	"maps"
+	"math"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/juander/udp-vote/internal/server"
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+func TestParseDistribution(t *testing.T) {
+	dist, err := parseDistribution("A:50, B:30,C:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []weight{{"A", 50}, {"B", 30}, {"C", 20}}
+	if len(dist) != len(want) {
+		t.Fatalf("distribuição = %v", dist)
+	}
+	for i := range want {
+		if dist[i] != want[i] {
+			t.Fatalf("distribuição = %v, esperava %v", dist, want)
+		}
+	}
+
+	for _, bad := range []string{"", "A", "A:", ":5", "A:0", "A:-1", "A:x", "A:50,B"} {
+		if _, err := parseDistribution(bad); err == nil {
+			t.Errorf("%q aceito", bad)
+		}
+	}
+}
+
+// Em muitos sorteios a proporção obtida fica perto da configurada, e a
+// mesma semente repete a sequência
+func TestPickDistribution(t *testing.T) {
+	dist := []weight{{"A", 50}, {"B", 30}, {"C", 20}}
+	const n = 100000
+	counts := make(map[string]int)
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < n; i++ {
+		counts[pick(r, dist)]++
+	}
+	for _, w := range dist {
+		got, want := float64(counts[w.option])/n, float64(w.weight)/100
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("%s: %.3f dos votos, esperava %.2f ± 0.01", w.option, got, want)
+		}
+	}
+
+	a, b := rand.New(rand.NewSource(7)), rand.New(rand.NewSource(7))
+	for i := 0; i < 1000; i++ {
+		if pick(a, dist) != pick(b, dist) {
+			t.Fatalf("sorteio %d difere com a mesma semente", i)
+		}
+	}
+}
+
+// Contra um servidor real, cada voto sorteado é contado: o placar é
+// exatamente a sequência que a semente produz
+func TestRunAutoTally(t *testing.T) {
+	srv, err := server.NewUDPServer([]string{"A", "B", "C"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Start("127.0.0.1:0") }()
+	select {
+	case <-srv.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	srv.StartVoting(3600)
+
+	conn, err := net.Dial("udp", srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cfg := defaultConfig()
+	cfg.Name, cfg.Auto, cfg.Rate, cfg.Count, cfg.Seed = "auto", "A:50,B:30,C:20", 2000, 60, 3
+	if err := runAuto(conn, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	dist, _ := parseDistribution(cfg.Auto)
+	want := map[string]int64{"A": 0, "B": 0, "C": 0}
+	r := rand.New(rand.NewSource(cfg.Seed))
+	for i := 0; i < cfg.Count; i++ {
+		want[pick(r, dist)]++
+	}
+	if got := srv.Results(); !maps.Equal(got, want) {
+		t.Fatalf("placar = %v, esperava %v", got, want)
+	}
+}
+
+// O modo -auto conta cada ID de um ACK agrupado como um voto confirmado
+func TestAutoVoterCombinedAck(t *testing.T) {
+	a := &autoVoter{pending: make(map[int]chan client.Message), errs: make(map[string]int)}
+	a.reply(client.Message{Type: "ACK", Message: "Votos registrados (3)", Acked: []string{"auto-1", "auto-2", "auto-3"}})
+	a.reply(client.Message{Type: "ACK", Message: "Voto registrado", Acked: []string{"auto-4"}})
+	a.reply(client.Message{Type: "ERROR", Message: "Votação encerrada"})
+	if a.confirmed != 4 || a.errs["Votação encerrada"] != 1 {
+		t.Fatalf("confirmados = %d, erros = %v", a.confirmed, a.errs)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuração do cliente. Precedência (da menor para a maior):
+// valores padrão → arquivo de configuração → variáveis de ambiente → flags.
+type clientConfig struct {
+	Server string // endereço do servidor (host:porta)
+	Name   string // ClientID usado no registro
+	Token  string // token de observador (recebe parciais ocultos)
+	Key    string // chave dos placares assinados (vazio = não confere)
+
+	ReadTimeout time.Duration // prazo de cada leitura do socket (0 = sem prazo)
+	VoteTimeout time.Duration // espera pelo ACK de cada envio do VOTE
+	VoteRetries int           // reenvios do VOTE sem ACK (negativo = nenhum)
+
+	MinServerVersion int   // versão de protocolo mínima do servidor (0 = qualquer uma)
+	Weight           int64 // peso do voto pedido no registro (0 = sem peso)
+
+	MetricsAddr string // endereço do GET /metrics do cliente (vazio = desligado)
+	Quiet       bool   // não exibe parciais, só o resultado final e as respostas
+
+	// Modo automático (-auto): votos sintéticos com distribuição fixa
+	Auto  string  // distribuição, ex.: "A:50,B:30,C:20" (vazio = interativo)
+	Rate  float64 // votos por segundo
+	Count int     // total de votos (um ClientID por voto)
+	Seed  int64   // semente do sorteio
+}
+
+// Variáveis de ambiente reconhecidas. Toda chave do arquivo também pode vir
+// do ambiente como UDPVOTE_<CHAVE> (ex.: UDPVOTE_VOTE_RETRIES).
+const (
+	envServer  = "UDPVOTE_SERVER"
+	envName    = "UDPVOTE_NAME"
+	envToken   = "UDPVOTE_TOKEN"
+	envKey     = "UDPVOTE_KEY"
+	envMetrics = "UDPVOTE_METRICS_ADDR"
+	envQuiet   = "UDPVOTE_QUIET"
+	envConfig  = "UDPVOTE_CONFIG"
+)
+
+// Chaves aceitas por set, na ordem em que o ambiente é lido
+var configKeys = []string{
+	"server", "name", "token", "key",
+	"read_timeout", "vote_timeout", "vote_retries",
+	"min_server_version", "weight",
+	"metrics_addr", "quiet",
+}
+
+func defaultConfig() clientConfig {
+	return clientConfig{Server: "localhost:9000", ReadTimeout: defaultReadTimeout, Rate: 10, Count: 100, Seed: 1}
+}
+
+// set atribui uma chave vinda do arquivo de configuração ou do ambiente
+func (c *clientConfig) set(key, value string) error {
+	switch key {
+	case "server":
+		c.Server = value
+	case "name":
+		c.Name = value
+	case "token":
+		c.Token = value
+	case "key":
+		c.Key = value
+	case "read_timeout":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("read_timeout inválido %q", value)
+		}
+		c.ReadTimeout = d
+	case "vote_timeout":
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("vote_timeout inválido %q", value)
+		}
+		c.VoteTimeout = d
+	case "vote_retries":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("vote_retries inválido %q", value)
+		}
+		c.VoteRetries = n
+		if n == 0 {
+			c.VoteRetries = -1 // 0 no arquivo = sem reenvio
+		}
+	case "min_server_version":
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 0 {
+			return fmt.Errorf("min_server_version inválido %q", value)
+		}
+		c.MinServerVersion = v
+	case "weight":
+		w, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || w <= 0 {
+			return fmt.Errorf("weight inválido %q", value)
+		}
+		c.Weight = w
+	case "metrics_addr":
+		if _, _, err := net.SplitHostPort(value); err != nil {
+			return fmt.Errorf("metrics_addr inválido %q", value)
+		}
+		c.MetricsAddr = value
+	case "quiet":
+		q, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("quiet inválido %q (use true ou false)", value)
+		}
+		c.Quiet = q
+	default:
+		return fmt.Errorf("chave desconhecida %q", key)
+	}
+	return nil
+}
+
+// loadConfigFile lê um arquivo no formato chave=valor.
+// Linhas vazias e linhas iniciadas com # são ignoradas.
+func loadConfigFile(path string, cfg *clientConfig) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: esperado chave=valor", path, line)
+		}
+		if err := cfg.set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+	}
+	return sc.Err()
+}
+
+// envFor devolve a variável de ambiente de uma chave do arquivo
+func envFor(key string) string {
+	return "UDPVOTE_" + strings.ToUpper(key)
+}
+
+// applyEnv sobrescreve a configuração com as variáveis de ambiente definidas,
+// validadas como as chaves do arquivo
+func applyEnv(cfg *clientConfig) error {
+	for _, key := range configKeys {
+		name := envFor(key)
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		if err := cfg.set(key, v); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (c clientConfig) validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("nome de usuário não pode ser vazio")
+	}
+	if _, _, err := net.SplitHostPort(c.Server); err != nil {
+		return fmt.Errorf("endereço do servidor inválido %q: %v", c.Server, err)
+	}
+	return nil
+}
+
+// parseConfig monta a configuração final a partir dos argumentos da linha de comando.
+// O nome também pode ser passado como argumento posicional (uso antigo).
+func parseConfig(args []string) (clientConfig, error) {
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	server := fs.String("server", "", "endereço do servidor (host:porta) [$"+envServer+"]")
+	name := fs.String("name", "", "nome do cliente [$"+envName+"]")
+	token := fs.String("token", "", "token de observador [$"+envToken+"]")
+	key := fs.String("key", "", "chave dos placares assinados pelo servidor [$"+envKey+"]")
+	readTimeout := fs.Duration("read-timeout", -1, "prazo de cada leitura do socket, ex.: 30s (0 = sem prazo; padrão 10s)")
+	voteTimeout := fs.Duration("vote-timeout", 0, "espera pelo ACK de cada envio do voto (padrão 500ms)")
+	voteRetries := fs.Int("vote-retries", -1, "reenvios do voto sem ACK (padrão 3; 0 = nenhum)")
+	minServer := fs.Int("min-server-version", -1, "recusa servidores com versão de protocolo abaixo desta (padrão 0 = qualquer uma)")
+	weight := fs.Int64("weight", 0, "peso do voto, em votações com peso (padrão: sem peso)")
+	metrics := fs.String("metrics", "", "endereço do GET /metrics do cliente, ex.: :9101 [$"+envMetrics+"]")
+	quiet := fs.Bool("quiet", false, "não exibe parciais, só o resultado final e as respostas [$"+envQuiet+"]")
+	auto := fs.String("auto", "", "modo automático com a distribuição dada (ex.: A:50,B:30,C:20)")
+	rate := fs.Float64("rate", 0, "modo automático: votos por segundo (padrão 10)")
+	count := fs.Int("count", 0, "modo automático: total de votos (padrão 100)")
+	seed := fs.Int64("seed", 0, "modo automático: semente do sorteio (padrão 1)")
+	configPath := fs.String("config", os.Getenv(envConfig), "arquivo de configuração chave=valor [$"+envConfig+"]")
+	if err := fs.Parse(args); err != nil {
+		return clientConfig{}, err
+	}
+
+	cfg := defaultConfig()
+	if *configPath != "" {
+		if err := loadConfigFile(*configPath, &cfg); err != nil {
+			return clientConfig{}, err
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return clientConfig{}, err
+	}
+	given := make(map[string]bool) // flags passadas (para -quiet=false vencer o ambiente)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	if *server != "" {
+		cfg.Server = *server
+	}
+	if *name != "" {
+		cfg.Name = *name
+	}
+	if *token != "" {
+		cfg.Token = *token
+	}
+	if *key != "" {
+		cfg.Key = *key
+	}
+	if *readTimeout >= 0 {
+		cfg.ReadTimeout = *readTimeout
+	}
+	if *voteTimeout > 0 {
+		cfg.VoteTimeout = *voteTimeout
+	}
+	switch {
+	case *voteRetries > 0:
+		cfg.VoteRetries = *voteRetries
+	case *voteRetries == 0:
+		cfg.VoteRetries = -1 // sem reenvio
+	}
+	if *minServer >= 0 {
+		cfg.MinServerVersion = *minServer
+	}
+	if *weight != 0 {
+		cfg.Weight = *weight
+	}
+	if *metrics != "" {
+		if err := cfg.set("metrics_addr", *metrics); err != nil {
+			return clientConfig{}, err
+		}
+	}
+	if given["quiet"] {
+		cfg.Quiet = *quiet
+	}
+	cfg.Auto = *auto
+	if *rate != 0 {
+		cfg.Rate = *rate
+	}
+	if *count != 0 {
+		cfg.Count = *count
+	}
+	if *seed != 0 {
+		cfg.Seed = *seed
+	}
+	if fs.NArg() > 0 {
+		cfg.Name = fs.Arg(0)
+	}
+
+	return cfg, cfg.validate()
+}
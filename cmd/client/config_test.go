@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// O cliente configurado só pelo ambiente fala com o endereço configurado
+func TestConfigFromEnvConnects(t *testing.T) {
+	srv, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	t.Setenv(envServer, srv.LocalAddr().String())
+	t.Setenv(envName, "alice")
+	t.Setenv(envQuiet, "true")
+	t.Setenv(envMetrics, "127.0.0.1:0")
+	t.Setenv("UDPVOTE_VOTE_RETRIES", "5")
+	t.Setenv("UDPVOTE_READ_TIMEOUT", "2s")
+
+	cfg, err := parseConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server != srv.LocalAddr().String() || cfg.Name != "alice" || !cfg.Quiet ||
+		cfg.MetricsAddr != "127.0.0.1:0" || cfg.VoteRetries != 5 || cfg.ReadTimeout != 2*time.Second {
+		t.Fatalf("configuração do ambiente = %+v", cfg)
+	}
+
+	c, err := client.Dial(cfg.Server, cfg.Name, client.Options{ReadTimeout: cfg.ReadTimeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Send(client.Message{Type: "PING", SeqNum: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 2048)
+	n, from, err := srv.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("nada chegou ao endereço configurado: %v", err)
+	}
+	var msg client.Message
+	if err := json.Unmarshal(buf[:n], &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "PING" || msg.ClientID != "alice" {
+		t.Fatalf("recebido %+v, esperava PING de alice", msg)
+	}
+	if from.String() != c.LocalAddr().String() {
+		t.Fatalf("datagrama de %s, esperava do cliente %s", from, c.LocalAddr())
+	}
+}
+
+// Precedência: flags > ambiente > arquivo > padrão
+func TestConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cliente.conf")
+	conf := "server=10.0.0.1:9000\nname=arquivo\nquiet=true\nmetrics_addr=:9101\nvote_retries=2\n"
+	if err := os.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envConfig, path)
+	t.Setenv(envName, "ambiente")
+	t.Setenv(envMetrics, ":9102")
+
+	cfg, err := parseConfig([]string{"-quiet=false", "-vote-retries", "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := clientConfig{Server: "10.0.0.1:9000", Name: "ambiente", MetricsAddr: ":9102", Quiet: false, VoteRetries: 4}
+	if cfg.Server != want.Server || cfg.Name != want.Name || cfg.MetricsAddr != want.MetricsAddr ||
+		cfg.Quiet != want.Quiet || cfg.VoteRetries != want.VoteRetries {
+		t.Fatalf("configuração = %+v, esperava %+v", cfg, want)
+	}
+
+	cfg, err = parseConfig([]string{"-metrics", ":9103", "flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MetricsAddr != ":9103" || cfg.Name != "flag" || !cfg.Quiet {
+		t.Fatalf("configuração = %+v", cfg)
+	}
+}
+
+// -server aceita host e IPv6 entre colchetes
+func TestConfigServerAddress(t *testing.T) {
+	for _, addr := range []string{"[::1]:9000", "192.168.0.10:9000", "votacao.local:9000"} {
+		cfg, err := parseConfig([]string{"-server", addr, "alice"})
+		if err != nil || cfg.Server != addr {
+			t.Fatalf("-server %s: %+v, %v", addr, cfg, err)
+		}
+	}
+}
+
+// Valores malformados no ambiente, no arquivo ou nas flags são recusados
+// com a origem na mensagem
+func TestConfigMalformed(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		file string
+		args []string
+		want string
+	}{
+		{"quiet no ambiente", map[string]string{envQuiet: "talvez"}, "", nil, envQuiet},
+		{"retries no ambiente", map[string]string{"UDPVOTE_VOTE_RETRIES": "-1"}, "", nil, "UDPVOTE_VOTE_RETRIES"},
+		{"metrics no ambiente", map[string]string{envMetrics: "9101"}, "", nil, envMetrics},
+		{"quiet no arquivo", nil, "quiet=sim\n", nil, "cliente.conf:1"},
+		{"chave desconhecida", nil, "name=a\nporta=1\n", nil, "cliente.conf:2"},
+		{"metrics na flag", nil, "", []string{"-metrics", "localhost"}, "metrics_addr"},
+		{"IPv6 sem colchetes", nil, "", []string{"-server", "::1:9000"}, "endereço do servidor inválido"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envName, "alice")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			if tc.file != "" {
+				path := filepath.Join(t.TempDir(), "cliente.conf")
+				if err := os.WriteFile(path, []byte(tc.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv(envConfig, path)
+			}
+			_, err := parseConfig(tc.args)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("erro = %v, esperava mencionar %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestClientMetrics(t *testing.T) {
+	stats := &Stats{}
+	stats.addVote()
+	stats.confirm()
+	stats.addBroadcast()
+	stats.seqCheck(1)
+	stats.seqCheck(4) // perde 2 e 3
+
+	addr, err := startMetrics("127.0.0.1:0", stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(raw)
+	for _, line := range []string{
+		"udpvote_client_votes_sent_total 1",
+		"udpvote_client_votes_confirmed_total 1",
+		"udpvote_client_broadcasts_lost_total 2",
+		"udpvote_client_last_seq 4",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("métricas sem %q:\n%s", line, body)
+		}
+	}
+}
+
+// -read-timeout: padrão de 10s, 0 desliga o prazo
+func TestConfigReadTimeout(t *testing.T) {
+	cases := []struct {
+		args []string
+		want time.Duration
+	}{
+		{[]string{"alice"}, defaultReadTimeout},
+		{[]string{"-read-timeout", "0", "alice"}, 0},
+		{[]string{"-read-timeout", "250ms", "alice"}, 250 * time.Millisecond},
+	}
+	for _, tc := range cases {
+		cfg, err := parseConfig(tc.args)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+		if cfg.ReadTimeout != tc.want {
+			t.Errorf("%v: ReadTimeout = %s, esperava %s", tc.args, cfg.ReadTimeout, tc.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// printDiag reúne o estado de conexão do cliente e mede o RTT na hora,
+// para investigar por que os broadcasts não estão chegando.
+//
+// Não há contagem de reconexões: o socket UDP não tem conexão que caia, e
+// o cliente nunca refaz Dial nem o registro. Em vez disso o diagnóstico
+// mostra em que tentativa o REGISTER foi confirmado e quantas leituras o SO
+// recusou (ICMP port unreachable), que é como um servidor fora do ar aparece.
+func printDiag(w io.Writer, c *client.Client, stats *Stats, pinger *Pinger, readTimeout time.Duration) {
+	send(c, client.Message{Type: "PING", SeqNum: pinger.next()})
+	rtt, ok := pinger.wait(time.Second)
+
+	fmt.Fprintln(w, "\n===== DIAGNÓSTICO =====")
+	fmt.Fprintln(w, "Local         :", c.LocalAddr())
+	fmt.Fprintln(w, "Servidor      :", c.RemoteAddr())
+
+	if last := stats.lastBroadcast(); last.IsZero() {
+		fmt.Fprintln(w, "Últ. broadcast: nenhum recebido")
+	} else {
+		fmt.Fprintf(w, "Últ. broadcast: há %s\n", time.Since(last).Round(time.Millisecond))
+	}
+
+	if readTimeout > 0 {
+		fmt.Fprintf(w, "Read deadline : %s (renovado a cada leitura)\n", readTimeout)
+	} else {
+		fmt.Fprintln(w, "Read deadline : nenhum (leitura bloqueante)")
+	}
+
+	if ok {
+		fmt.Fprintln(w, "RTT (PING)    :", rtt.Round(time.Microsecond))
+	} else {
+		fmt.Fprintln(w, "RTT (PING)    : sem resposta em 1s")
+	}
+
+	switch n := c.RegisterTries(); {
+	case c.Registered():
+		fmt.Fprintf(w, "Registro      : confirmado na tentativa %d\n", n)
+	case n > 0:
+		fmt.Fprintf(w, "Registro      : não confirmado (%d tentativas)\n", n)
+	default:
+		fmt.Fprintln(w, "Registro      : não enviado")
+	}
+	fmt.Fprintln(w, "Reconexões    : não se aplica (UDP sem conexão; o cliente não refaz Dial nem o registro)")
+	fmt.Fprintln(w, "Recusas do SO :", c.Refused(), "(ICMP port unreachable: servidor fora do ar)")
+	fmt.Fprint(w, "=======================\n\n")
+}
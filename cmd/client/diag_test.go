@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/internal/server"
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+func TestDiagOutput(t *testing.T) {
+	srv, err := server.NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Start("127.0.0.1:0") }()
+	select {
+	case <-srv.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	stats := &Stats{}
+	pinger := &Pinger{}
+	got := make(chan struct{}, 8)
+	c, err := client.Dial(srv.Addr().String(), "alice", client.Options{OnMessage: func(m client.Message) {
+		if m.Type == "PONG" {
+			pinger.pong(m.SeqNum)
+		}
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Subscribe(func(r client.Results) {
+		if r.Kind == "BROADCAST" {
+			stats.addBroadcast()
+			got <- struct{}{}
+		}
+	})
+	if err := c.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Antes de qualquer broadcast
+	var out bytes.Buffer
+	printDiag(&out, c, stats, pinger, defaultReadTimeout)
+	if !strings.Contains(out.String(), "Últ. broadcast: nenhum recebido") {
+		t.Fatalf("DIAG sem broadcast:\n%s", out.String())
+	}
+
+	srv.StartVoting(60)
+	select {
+	case <-got:
+	case <-time.After(2 * time.Second):
+		t.Fatal("broadcast de abertura não chegou")
+	}
+
+	out.Reset()
+	printDiag(&out, c, stats, pinger, defaultReadTimeout)
+	diag := out.String()
+	for _, want := range []string{
+		"Servidor      : " + srv.Addr().String(),
+		"Local         : " + c.LocalAddr().String(),
+		"Últ. broadcast: há ",
+		"Read deadline : 10s",
+		"Registro      : confirmado na tentativa 1",
+		"Reconexões    : não se aplica",
+		"Recusas do SO : 0",
+	} {
+		if !strings.Contains(diag, want) {
+			t.Fatalf("DIAG sem %q:\n%s", want, diag)
+		}
+	}
+	if strings.Contains(diag, "RTT (PING)    : sem resposta") {
+		t.Fatalf("PING do DIAG sem resposta:\n%s", diag)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Quantos broadcasts o cliente guarda para o EXPORT (os mais antigos saem)
+const historyLimit = 10000
+
+// Um broadcast como o cliente o recebeu
+type historyEntry struct {
+	seq        int
+	receivedAt time.Time
+	source     string // broadcast | resync
+	final      bool
+	counts     map[string]int64
+}
+
+// Histórico dos broadcasts recebidos, para análise offline
+type History struct {
+	m       sync.Mutex
+	entries []historyEntry
+}
+
+func (h *History) add(r client.Results, source string) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.entries = append(h.entries, historyEntry{
+		seq:        r.SeqNum,
+		receivedAt: time.Now(),
+		source:     source,
+		final:      r.Final != nil,
+		counts:     r.VoteCounts,
+	})
+	if extra := len(h.entries) - historyLimit; extra > 0 {
+		h.entries = h.entries[extra:]
+	}
+}
+
+// writeCSV grava o histórico em ordem de chegada, com uma coluna por opção:
+// seq,received_at,source,final,<opção>...
+func (h *History) writeCSV(path string) (int, error) {
+	h.m.Lock()
+	entries := append([]historyEntry(nil), h.entries...)
+	h.m.Unlock()
+
+	seen := make(map[string]bool)
+	var options []string
+	for _, e := range entries {
+		for op := range e.counts {
+			if !seen[op] {
+				seen[op] = true
+				options = append(options, op)
+			}
+		}
+	}
+	sort.Strings(options)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(f)
+
+	w.Write(append([]string{"seq", "received_at", "source", "final"}, options...))
+	for _, e := range entries {
+		row := []string{
+			strconv.Itoa(e.seq),
+			e.receivedAt.Format(time.RFC3339Nano),
+			e.source,
+			strconv.FormatBool(e.final),
+		}
+		for _, op := range options {
+			row = append(row, strconv.FormatInt(e.counts[op], 10))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		f.Close()
+		return 0, err
+	}
+	return len(entries), f.Close()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// O CSV traz os broadcasts na ordem de chegada, com uma coluna por opção
+// vista em qualquer um deles (0 onde a opção não apareceu)
+func TestHistoryExportCSV(t *testing.T) {
+	h := &History{}
+	start := time.Now()
+	h.add(client.Results{SeqNum: 1, VoteCounts: map[string]int64{"A": 1, "B": 0}}, "broadcast")
+	h.add(client.Results{SeqNum: 3, VoteCounts: map[string]int64{"A": 1, "B": 2}}, "broadcast")
+	h.add(client.Results{SeqNum: 2, VoteCounts: map[string]int64{"A": 1, "B": 1}}, "resync")
+	h.add(client.Results{SeqNum: 4, VoteCounts: map[string]int64{"A": 2, "B": 2, "C": 1},
+		Final: &client.FinalResult{Winner: "A"}}, "broadcast")
+
+	path := filepath.Join(t.TempDir(), "historico.csv")
+	n, err := h.writeCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("writeCSV = %d linhas, esperava 4", n)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"seq", "received_at", "source", "final", "A", "B", "C"},
+		{"1", "", "broadcast", "false", "1", "0", "0"},
+		{"3", "", "broadcast", "false", "1", "2", "0"},
+		{"2", "", "resync", "false", "1", "1", "0"},
+		{"4", "", "broadcast", "true", "2", "2", "1"},
+	}
+	for i, row := range rows[1:] {
+		at, err := time.Parse(time.RFC3339Nano, row[1])
+		if err != nil || at.Before(start.Truncate(time.Second)) || at.After(time.Now()) {
+			t.Fatalf("linha %d: received_at %q inválido", i+1, row[1])
+		}
+		row[1] = ""
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("CSV =\n%v\nesperava\n%v", rows, want)
+	}
+}
+
+// Só os últimos historyLimit broadcasts ficam guardados
+func TestHistoryLimit(t *testing.T) {
+	h := &History{}
+	for i := 1; i <= historyLimit+5; i++ {
+		h.add(client.Results{SeqNum: i}, "broadcast")
+	}
+	if len(h.entries) != historyLimit || h.entries[0].seq != 6 {
+		t.Fatalf("histórico com %d entradas a partir de #%d", len(h.entries), h.entries[0].seq)
+	}
+}
+
+func TestHistoryExportError(t *testing.T) {
+	h := &History{}
+	h.add(client.Results{SeqNum: 1}, "broadcast")
+	path := filepath.Join(t.TempDir(), "não-existe", "historico.csv")
+	if n, err := h.writeCSV(path); err == nil || n != 0 {
+		t.Fatalf("writeCSV em diretório inexistente = %d, %v", n, err)
+	}
+}
@@ -2,25 +2,24 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+	"github.com/juander/udp-vote/pkg/resultfmt"
 )
 
-// Formato JSON trocado com o servidor
-type Message struct {
-	Type       string         `json:"type"`
-	ClientID   string         `json:"client_id"`
-	VoteOption string         `json:"vote,omitempty"`
-	Message    string         `json:"message,omitempty"`
-	VoteCounts map[string]int `json:"vote_counts,omitempty"`
-	SeqNum     int            `json:"seq_num,omitempty"`
-	Options    []string       `json:"options,omitempty"`
-}
+// Prazo padrão de cada leitura do socket (renovado a cada iteração; -read-timeout)
+const defaultReadTimeout = 10 * time.Second
+
+// Buracos maiores que isso não são rastreados um a um (o servidor
+// responde ao RESYNC com um SNAPSHOT)
+const maxTrackedGap = 64
 
 // Estatísticas locais do cliente (para medir UDP)
 type Stats struct {
@@ -30,98 +29,303 @@ type Stats struct {
 	confirmed  int
 	broadcasts int
 	lost       int
+	recovered  int // broadcasts perdidos recuperados via RESYNC
+	resyncs    int // recuperações automáticas pedidas (RESYNC ou GET_RESULTS)
+	heartbeats int // keepalives do servidor (fora da contagem de perdas)
+	forged     int // placares descartados por assinatura inválida ou reenvio
+	retries    int // reenvios de VOTE sem resposta
 	lastSeq    int
+
+	missing map[int]bool // SeqNums perdidos ainda não recuperados
+
+	lastBroadcastAt time.Time
+
+	// Placar mais recente recebido (BROADCAST, RESYNC ou SNAPSHOT)
+	standings    map[string]int64
+	standingsSeq int
+
+	// Opção registrada pelo servidor no último voto confirmado
+	recorded string
+}
+
+func (s *Stats) addVote()   { s.m.Lock(); s.sent++; s.m.Unlock() }
+func (s *Stats) confirm()   { s.m.Lock(); s.confirmed++; s.m.Unlock() }
+func (s *Stats) addRetry()  { s.m.Lock(); s.retries++; s.m.Unlock() }
+func (s *Stats) addResync() { s.m.Lock(); s.resyncs++; s.m.Unlock() }
+func (s *Stats) addBroadcast() {
+	s.m.Lock()
+	s.broadcasts++
+	s.lastBroadcastAt = time.Now()
+	s.m.Unlock()
+}
+func (s *Stats) addHeartbeat()            { s.m.Lock(); s.heartbeats++; s.m.Unlock() }
+func (s *Stats) addForged()               { s.m.Lock(); s.forged++; s.m.Unlock() }
+func (s *Stats) lastBroadcast() time.Time { s.m.Lock(); defer s.m.Unlock(); return s.lastBroadcastAt }
+
+// setRecorded guarda a opção que o servidor ecoou no ACK do voto; é ela,
+// e não a digitada, que vale
+func (s *Stats) setRecorded(option string) {
+	if option == "" {
+		return // servidor sem recorded_option
+	}
+	s.m.Lock()
+	s.recorded = option
+	s.m.Unlock()
 }
 
-func (s *Stats) addVote()      { s.m.Lock(); s.sent++; s.m.Unlock() }
-func (s *Stats) confirm()      { s.m.Lock(); s.confirmed++; s.m.Unlock() }
-func (s *Stats) addBroadcast() { s.m.Lock(); s.broadcasts++; s.m.Unlock() }
-func (s *Stats) seqCheck(n int) {
+// seqCheck contabiliza perdas pelo SeqNum. Havendo buraco, devolve o
+// último SeqNum visto antes dele (para o RESYNC); senão devolve 0.
+func (s *Stats) seqCheck(n int) int {
 	s.m.Lock()
+	defer s.m.Unlock()
+
+	gapFrom := 0
 	if s.lastSeq > 0 && n > s.lastSeq+1 {
 		s.lost += n - s.lastSeq - 1
+		gapFrom = s.lastSeq
+		if n-s.lastSeq-1 <= maxTrackedGap {
+			if s.missing == nil {
+				s.missing = make(map[int]bool)
+			}
+			for seq := s.lastSeq + 1; seq < n; seq++ {
+				s.missing[seq] = true
+			}
+		}
 	}
 	s.lastSeq = n
-	s.m.Unlock()
+	return gapFrom
 }
+
+// recover registra um broadcast reenviado; devolve false se já era conhecido
+func (s *Stats) recover(n int) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.missing[n] {
+		delete(s.missing, n)
+		s.recovered++
+		return true
+	}
+	if n > s.lastSeq {
+		s.lastSeq = n
+		return true
+	}
+	return false
+}
+
+// resynced aplica um SNAPSHOT: o placar está em dia até o SeqNum n
+func (s *Stats) resynced(n int) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.missing = nil
+	if n > s.lastSeq {
+		s.lastSeq = n
+	}
+}
+
+// setStandings guarda o placar do SeqNum n, se for mais novo que o atual
+func (s *Stats) setStandings(n int, counts map[string]int64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if counts == nil || n < s.standingsSeq {
+		return
+	}
+	s.standings, s.standingsSeq = counts, n
+}
+
 func (s *Stats) Print() {
 	s.m.Lock()
 	defer s.m.Unlock()
 	fmt.Println("\n===== UDP STATS =====")
 	fmt.Println("Votos enviados:", s.sent)
 	fmt.Println("Confirmados   :", s.confirmed)
+	if s.recorded != "" {
+		fmt.Println("Voto atual   :", s.recorded)
+	}
 	fmt.Println("Não confirm. :", s.sent-s.confirmed)
+	fmt.Println("Reenvios     :", s.retries)
 	fmt.Println("Broadcasts   :", s.broadcasts)
 	fmt.Println("Pacotes perd.:", s.lost)
+	fmt.Println("Recuperados  :", s.recovered)
+	fmt.Println("Resyncs auto.:", s.resyncs)
+	fmt.Println("Heartbeats   :", s.heartbeats)
+	if s.forged > 0 {
+		fmt.Println("Forjados     :", s.forged)
+	}
 	total := s.broadcasts + s.lost
 	if total > 0 {
 		fmt.Printf("Perda estimada: %.2f%%\n", float64(s.lost)/float64(total)*100)
 	}
-	fmt.Println("=====================\n")
+	if s.standings != nil {
+		fmt.Printf("\nPlacar (#%d):\n%s", s.standingsSeq, resultfmt.Table(s.standings))
+	}
+	fmt.Print("=====================\n\n")
+}
+
+// Controle do PING para medir o RTT até o servidor
+type Pinger struct {
+	m sync.Mutex
+
+	seq     int
+	sentAt  time.Time
+	lastRTT time.Duration
+	notify  chan time.Duration // avisa quem espera pelo PONG do PING atual
+}
+
+// next registra o envio de um novo PING e devolve seu número
+func (p *Pinger) next() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.seq++
+	p.sentAt = time.Now()
+	p.notify = make(chan time.Duration, 1)
+	return p.seq
+}
+
+// pong calcula o RTT se a resposta for do PING mais recente
+func (p *Pinger) pong(seq int) (time.Duration, bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if seq != p.seq {
+		return 0, false // resposta atrasada de um PING anterior
+	}
+	p.lastRTT = time.Since(p.sentAt)
+	select {
+	case p.notify <- p.lastRTT:
+	default:
+	}
+	return p.lastRTT, true
+}
+
+// wait espera o PONG do PING mais recente por até timeout
+func (p *Pinger) wait(timeout time.Duration) (time.Duration, bool) {
+	p.m.Lock()
+	ch := p.notify
+	p.m.Unlock()
+
+	select {
+	case rtt := <-ch:
+		return rtt, true
+	case <-time.After(timeout):
+		return 0, false
+	}
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Uso: go run client.go <nome>")
+	cfg, err := parseConfig(os.Args[1:])
+	if err != nil {
+		fmt.Println("Erro de configuração:", err)
+		fmt.Println("Uso: go run ./cmd/client [-server host:porta] [-config arquivo] <nome>")
 		return
 	}
-	name := os.Args[1]
-	if strings.TrimSpace(name) == "" {
-		fmt.Println("Nome de usuário não pode ser vazio")
+
+	if cfg.Auto != "" {
+		conn, err := net.Dial("udp", cfg.Server)
+		if err != nil {
+			fmt.Println("Erro ao conectar ao servidor:", err)
+			return
+		}
+		defer conn.Close()
+		if err := runAuto(conn, cfg); err != nil {
+			fmt.Println("Erro no modo automático:", err)
+		}
 		return
 	}
+
 	stats := &Stats{}
+	pinger := &Pinger{}
+	history := &History{}
+	resyncer := &Resyncer{}
 
-	conn, err := net.Dial("udp", "localhost:9000")
+	if cfg.MetricsAddr != "" {
+		addr, err := startMetrics(cfg.MetricsAddr, stats)
+		if err != nil {
+			fmt.Println("Erro ao abrir endpoint de métricas:", err)
+			return
+		}
+		fmt.Printf("Métricas em http://%s/metrics\n", addr)
+	}
+
+	opts := client.Options{
+		Token:       cfg.Token,
+		ReadTimeout: cfg.ReadTimeout,
+		VoteTimeout: cfg.VoteTimeout,
+		VoteRetries: cfg.VoteRetries,
+
+		MinServerVersion: cfg.MinServerVersion,
+		Weight:           cfg.Weight,
+		OnMessage: func(msg client.Message) {
+			handleMessage(msg, cfg.Name, stats, pinger)
+		},
+		OnVoteRetry: func(option string, attempt int) {
+			stats.addRetry()
+			fmt.Printf("\n[AVISO] Voto em %s sem resposta; reenviando (%d)\n>> ", option, attempt)
+		},
+		OnDiscard: func(msg client.Message, err error) {
+			if errors.Is(err, client.ErrUntrusted) {
+				stats.addForged()
+				fmt.Printf("\n[AVISO] %s #%d descartado: %v\n>> ", msg.Type, msg.SeqNum, err)
+				return
+			}
+			fmt.Printf("\n[AVISO] Descartado: %v\n>> ", err)
+		},
+		OnError: func(err error) {
+			fmt.Printf("\n[ERRO] Leitura interrompida: %v\n", err)
+		},
+	}
+	if cfg.Key != "" {
+		opts.Key = []byte(cfg.Key)
+	}
+
+	c, err := client.Dial(cfg.Server, cfg.Name, opts)
 	if err != nil {
 		fmt.Println("Erro ao conectar ao servidor:", err)
 		return
 	}
-	defer conn.Close()
-
-	registrado := false
-	ackCh := make(chan struct{})
+	defer c.Close()
 
-	go func() {
-		buf := make([]byte, 4096)
-		for {
-			conn.SetReadDeadline(time.Now().Add(10 * time.Second))
-			n, err := conn.Read(buf)
-			if err != nil {
-				continue
+	c.Subscribe(func(r client.Results) {
+		switch r.Kind {
+		case "BROADCAST":
+			stats.addBroadcast()
+			if last := stats.seqCheck(r.SeqNum); last > 0 {
+				// Pede ao servidor o que se perdeu no caminho
+				resyncer.gap(c, stats, last, r.SeqNum)
 			}
-			var msg Message
-			if json.Unmarshal(buf[:n], &msg) != nil {
-				continue
+			history.add(r, "broadcast")
+			stats.setStandings(r.SeqNum, r.VoteCounts)
+			if !cfg.Quiet || r.Final != nil {
+				printBroadcast(r, "")
 			}
-			switch msg.Type {
-			case "ACK":
-				if len(msg.Options) > 0 {
-					fmt.Printf("\nOpções de voto disponíveis: %v\n", msg.Options)
-				}
-				if msg.Message == "Voto registrado" {
-					stats.confirm()
-				}
-				fmt.Printf("\n[OK] %s\n>> ", msg.Message)
-				if !registrado {
-					registrado = true
-					ackCh <- struct{}{}
+		case "RESYNC":
+			// Broadcast reenviado a pedido; não mexe na detecção de perdas
+			if stats.recover(r.SeqNum) {
+				history.add(r, "resync")
+				stats.setStandings(r.SeqNum, r.VoteCounts)
+				if !cfg.Quiet || r.Final != nil {
+					printBroadcast(r, " (recuperado)")
 				}
-			case "ERROR":
-				fmt.Printf("\n[ERRO] %s\n>> ", msg.Message)
-			case "BROADCAST":
-				stats.addBroadcast()
-				stats.seqCheck(msg.SeqNum)
-				fmt.Printf("\n📡 Parcial #%d %v\n>> ", msg.SeqNum, msg.VoteCounts)
+			}
+		case "SNAPSHOT":
+			stats.resynced(r.SeqNum)
+			stats.setStandings(r.SeqNum, r.VoteCounts)
+			if r.VoteCounts != nil {
+				fmt.Printf("\n🔄 Placar sincronizado #%d %s\n>> ", r.SeqNum, resultfmt.Breakdown(r.VoteCounts))
+			} else {
+				fmt.Printf("\n🔄 Placar #%d: parciais ocultos até o encerramento\n>> ", r.SeqNum)
 			}
 		}
-	}()
+	})
 
-	send(conn, "REGISTER", name, "")
-	fmt.Println("Conectado. Comandos: VOTE <X> | STATS | QUIT")
+	fmt.Println("Conectado. Comandos: VOTE <X> | MUTE | UNMUTE | DELEGATE <ID> | PING | TIME | DIAG | STATS | RESULTS | SRVSTATS | EXPORT <arquivo> | END <segredo> | QUIT")
 
 	// Espera ACK de registro antes de permitir votar
-	<-ackCh
+	if err := c.Register(); err != nil {
+		fmt.Println("\nFalha no registro:", err)
+		return
+	}
 
 	input := bufio.NewScanner(os.Stdin)
 	for {
@@ -131,27 +335,175 @@ func main() {
 		switch {
 		case cmd == "STATS":
 			stats.Print()
+		case cmd == "MUTE" || cmd == "UNMUTE":
+			send(c, client.Message{Type: cmd})
+		case cmd == "PING":
+			send(c, client.Message{Type: "PING", SeqNum: pinger.next()})
+		case cmd == "TIME":
+			send(c, client.Message{Type: "TIME_LEFT"})
+		case cmd == "SRVSTATS":
+			send(c, client.Message{Type: "SERVER_STATS"})
+		case cmd == "RESULTS":
+			// Placar atual direto do servidor (chega como SNAPSHOT)
+			send(c, client.Message{Type: "GET_RESULTS"})
+		case cmd == "DIAG":
+			printDiag(os.Stdout, c, stats, pinger, cfg.ReadTimeout)
 		case cmd == "QUIT":
+			// Avisa o servidor para parar de enviar broadcasts a este endereço
+			if err := c.Unregister(); err != nil {
+				fmt.Println("Saída sem confirmação do servidor:", err)
+			}
 			stats.Print()
 			return
 		case strings.HasPrefix(cmd, "VOTE "):
-			if !registrado {
+			if !c.Registered() {
 				fmt.Println("Aguarde registro ser confirmado antes de votar.")
 				continue
 			}
 			option := strings.TrimPrefix(cmd, "VOTE ")
 			stats.addVote()
-			send(conn, "VOTE", name, option)
+			// A resposta é exibida pelo handleMessage; aqui só se contabiliza
+			go func() {
+				_, err := c.Vote(option)
+				switch {
+				case err == nil:
+					stats.confirm()
+					stats.setRecorded(c.RecordedVote())
+				case errors.Is(err, client.ErrNoReply):
+					fmt.Printf("\n[AVISO] Voto em %s sem confirmação do servidor\n>> ", option)
+				}
+			}()
+		case strings.HasPrefix(cmd, "EXPORT "):
+			path := strings.TrimSpace(strings.TrimPrefix(cmd, "EXPORT "))
+			n, err := history.writeCSV(path)
+			if err != nil {
+				fmt.Println("Erro ao exportar histórico:", err)
+				continue
+			}
+			fmt.Printf("%d broadcasts exportados para %s\n", n, path)
+		case strings.HasPrefix(cmd, "DELEGATE "):
+			if !c.Registered() {
+				fmt.Println("Aguarde registro ser confirmado antes de delegar.")
+				continue
+			}
+			send(c, client.Message{Type: "DELEGATE", Delegate: strings.TrimPrefix(cmd, "DELEGATE ")})
+		case strings.HasPrefix(cmd, "END "):
+			// Operador: encerra a votação antes do prazo (admin_secret no servidor)
+			if err := c.EndVoting(strings.TrimPrefix(cmd, "END ")); err != nil {
+				fmt.Println("Erro ao enviar mensagem:", err)
+			}
 		default:
-			fmt.Println("Comandos: VOTE <A/B/...>, STATS, QUIT")
+			fmt.Println("Comandos: VOTE <A/B/...>, DELEGATE <ID>, MUTE, UNMUTE, PING, TIME, DIAG, STATS, RESULTS, SRVSTATS, EXPORT <arquivo>, END <segredo>, QUIT")
 		}
 	}
 }
 
-func send(c net.Conn, t, id, opt string) {
-	data, _ := json.Marshal(Message{Type: t, ClientID: id, VoteOption: opt})
-	_, err := c.Write(data)
-	if err != nil {
+// handleMessage exibe as respostas do servidor que não são placares
+// (estes chegam pelo Subscribe)
+func handleMessage(msg client.Message, name string, stats *Stats, pinger *Pinger) {
+	switch msg.Type {
+	case "ACK":
+		if len(msg.Options) > 0 {
+			fmt.Printf("\nOpções de voto disponíveis: %v\n", msg.Options)
+		}
+		if msg.RecordedOption != "" {
+			// A opção como o servidor a registrou
+			fmt.Printf("\n[OK] %s: %s\n>> ", msg.Message, msg.RecordedOption)
+			return
+		}
+		fmt.Printf("\n[OK] %s\n>> ", msg.Message)
+	case "ERROR":
+		fmt.Printf("\n[ERRO] %s\n>> ", msg.Message)
+	case "HEARTBEAT":
+		// Só mantém o mapeamento NAT; não é broadcast nem conta perda
+		stats.addHeartbeat()
+	case "OPTIONS":
+		// Organizador trocou as opções antes da abertura
+		fmt.Printf("\nOpções de voto atualizadas: %v\n>> ", msg.Options)
+	case "RUNOFF":
+		// Empate: nova rodada só com as opções empatadas
+		fmt.Printf("\n=== SEGUNDO TURNO #%d ===\nEmpate! Vote de novo entre: %v\n>> ", msg.Round, msg.Options)
+	case "PAUSE", "RESUME":
+		// Organizador pausou ou retomou a votação
+		fmt.Printf("\n[%s] %s\n>> ", msg.Type, msg.Message)
+	case "JOIN":
+		// Outro cliente se registrou (announce_joins no servidor)
+		fmt.Printf("\n[JOIN] %s entrou: %d participantes\n>> ", msg.ClientID, msg.ClientCount)
+	case "NEW_ROUND":
+		// Organizador preparou outra votação com opções novas
+		fmt.Printf("\n=== NOVA VOTAÇÃO #%d ===\nOpções de voto: %v\n>> ", msg.Round, msg.Options)
+	case "TIME_LEFT":
+		// Resposta ao TIME: estado e tempo restante da votação
+		fmt.Printf("\n[TEMPO] %s\n>> ", msg.Message)
+	case "SERVER_STATS":
+		printServerStats(msg.Stats, name)
+	case "PONG":
+		rtt, ok := pinger.pong(msg.SeqNum)
+		if !ok {
+			return
+		}
+		fmt.Printf("\nPONG #%d rtt=%s", msg.SeqNum, rtt.Round(time.Microsecond))
+		if msg.Uptime > 0 {
+			fmt.Printf(" uptime=%s goroutines=%d clientes=%d",
+				time.Duration(msg.Uptime*float64(time.Second)).Round(time.Second),
+				msg.Goroutines, msg.ClientCount)
+		}
+		fmt.Print("\n>> ")
+	}
+}
+
+// printServerStats exibe a visão do servidor sobre a entrega de broadcasts
+func printServerStats(st *client.ServerStats, name string) {
+	if st == nil {
+		return
+	}
+	fmt.Println("\n===== SERVER STATS =====")
+	fmt.Println("Broadcasts enviados:", st.BroadcastsSent)
+	fmt.Println("Perdas relatadas   :", st.BroadcastsMissed)
+	fmt.Printf("Entrega estimada   : %.2f%%\n", st.DeliveryRatio*100)
+	for _, c := range st.Clients {
+		if c.ClientID == name {
+			fmt.Printf("Este cliente       : %d de %d perdidos (%.2f%%)\n", c.Missed, c.Sent, c.LossRatio*100)
+		}
+	}
+	fmt.Print("========================\n>> ")
+}
+
+// printBroadcast exibe um placar parcial ou o resultado final
+func printBroadcast(r client.Results, note string) {
+	if r.Final != nil {
+		fmt.Printf("\n🏁 Resultado final #%d%s\n%s", r.SeqNum, note, resultfmt.Bars(r.VoteCounts, 20))
+		if r.Final.Winner != "" {
+			fmt.Println("Vencedor:", r.Final.Winner)
+			if r.Final.RunnerUp != "" {
+				fmt.Printf("Vantagem: %d votos (%.1f%%) sobre %s\n",
+					r.Final.Margin, r.Final.MarginPct, r.Final.RunnerUp)
+			}
+		}
+		if len(r.Final.Tied) > 0 {
+			fmt.Println("Empate entre:", strings.Join(r.Final.Tied, ", "))
+		}
+		switch r.Final.Decision {
+		case "approved":
+			fmt.Println("Decisão: APROVADA")
+		case "rejected":
+			fmt.Println("Decisão: REJEITADA")
+		}
+		if r.Final.NonBinding {
+			fmt.Printf("Quórum não atingido (mínimo de %d votos): resultado não vinculante\n", r.Final.Quorum)
+		}
+		if r.Final.ChainHash != "" {
+			fmt.Printf("Selo da apuração: %s\n", r.Final.ChainHash)
+		}
+		fmt.Print(">> ")
+		return
+	}
+	fmt.Printf("\n📡 Parcial #%d%s %s\n>> ", r.SeqNum, note, resultfmt.Breakdown(r.VoteCounts))
+}
+
+// send envia um comando ao servidor, avisando se a escrita falhar
+func send(c *client.Client, msg client.Message) {
+	if err := c.Send(msg); err != nil {
 		fmt.Println("Erro ao enviar mensagem:", err)
 	}
 }
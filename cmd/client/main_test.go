@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// HEARTBEAT entre dois broadcasts seguidos só conta como keepalive: nem
+// broadcast nem perda
+func TestHeartbeatNotCountedAsLoss(t *testing.T) {
+	stats := &Stats{}
+	stats.addBroadcast()
+	stats.seqCheck(1)
+	for i := 0; i < 3; i++ {
+		handleMessage(client.Message{Type: "HEARTBEAT"}, "ana", stats, &Pinger{})
+	}
+	stats.addBroadcast()
+	if gap := stats.seqCheck(2); gap != 0 {
+		t.Fatalf("seqCheck viu buraco depois de %d", gap)
+	}
+	if stats.heartbeats != 3 || stats.broadcasts != 2 || stats.lost != 0 || stats.lastSeq != 2 {
+		t.Fatalf("heartbeats=%d broadcasts=%d perdidos=%d último=#%d",
+			stats.heartbeats, stats.broadcasts, stats.lost, stats.lastSeq)
+	}
+}
+
+// captureStdout devolve o que fn escreveu na saída padrão
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// O resultado final anuncia o vencedor, o empate ou nenhum dos dois
+func TestPrintFinalWinner(t *testing.T) {
+	cases := []struct {
+		name  string
+		final client.FinalResult
+		want  string
+		not   []string
+	}{
+		{"vencedor", client.FinalResult{Winner: "A", RunnerUp: "B", Margin: 2, MarginPct: 50},
+			"Vencedor: A\nVantagem: 2 votos (50.0%) sobre B\n", []string{"Empate"}},
+		{"empate", client.FinalResult{Tied: []string{"A", "B"}}, "Empate entre: A, B\n", []string{"Vencedor"}},
+		{"sem votos", client.FinalResult{}, "", []string{"Vencedor", "Empate"}},
+	}
+	for _, tc := range cases {
+		final := tc.final
+		out := captureStdout(t, func() {
+			printBroadcast(client.Results{SeqNum: 3, VoteCounts: map[string]int64{"A": 3, "B": 1}, Final: &final}, "")
+		})
+		if !strings.Contains(out, tc.want) {
+			t.Errorf("%s: saída sem %q:\n%s", tc.name, tc.want, out)
+		}
+		for _, s := range tc.not {
+			if strings.Contains(out, s) {
+				t.Errorf("%s: saída com %q:\n%s", tc.name, s, out)
+			}
+		}
+	}
+}
+
+// STATS mostra o placar mais recente recebido, ordenado e com total; um
+// placar de SeqNum antigo não substitui o atual
+func TestStatsPrintStandings(t *testing.T) {
+	stats := &Stats{}
+	if out := captureStdout(t, stats.Print); strings.Contains(out, "Placar") {
+		t.Fatalf("placar sem nenhum broadcast:\n%s", out)
+	}
+
+	stats.setStandings(5, map[string]int64{"B": 1, "C": 4, "A": 4})
+	stats.setStandings(4, map[string]int64{"A": 9})
+	stats.setStandings(6, nil)
+	out := captureStdout(t, stats.Print)
+	want := "\nPlacar (#5):\n" +
+		"  Opção  Votos     %\n" +
+		"      A      4  44.4\n" +
+		"      C      4  44.4\n" +
+		"      B      1  11.1\n" +
+		"  Total      9      \n"
+	if !strings.Contains(out, want) {
+		t.Fatalf("STATS sem o placar esperado:\n%s\nesperava\n%s", out, want)
+	}
+}
+
+// JOIN anunciado pelo servidor mostra o número de participantes
+func TestPrintJoin(t *testing.T) {
+	out := captureStdout(t, func() {
+		handleMessage(client.Message{Type: "JOIN", ClientID: "bia", ClientCount: 2}, "ana", &Stats{}, &Pinger{})
+	})
+	if !strings.Contains(out, "[JOIN] bia entrou: 2 participantes") {
+		t.Fatalf("saída do JOIN:\n%s", out)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+)
+
+// ----------------------------------------------------------
+// Contadores do cliente para o Prometheus (-metrics)
+// ----------------------------------------------------------
+//
+// Os mesmos números do STATS, expostos em GET /metrics para acompanhar a
+// perda de broadcasts de vários clientes (em contêineres, sem terminal).
+
+// WriteText grava as estatísticas no formato de texto do Prometheus
+func (s *Stats) WriteText(w io.Writer) error {
+	s.m.Lock()
+	metrics := []struct {
+		name, kind string
+		value      int
+	}{
+		{"udpvote_client_votes_sent_total", "counter", s.sent},
+		{"udpvote_client_votes_confirmed_total", "counter", s.confirmed},
+		{"udpvote_client_vote_retries_total", "counter", s.retries},
+		{"udpvote_client_broadcasts_total", "counter", s.broadcasts},
+		{"udpvote_client_broadcasts_lost_total", "counter", s.lost},
+		{"udpvote_client_broadcasts_recovered_total", "counter", s.recovered},
+		{"udpvote_client_resyncs_total", "counter", s.resyncs},
+		{"udpvote_client_heartbeats_total", "counter", s.heartbeats},
+		{"udpvote_client_forged_total", "counter", s.forged},
+		{"udpvote_client_last_seq", "gauge", s.lastSeq},
+	}
+	s.m.Unlock()
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n%s %d\n", m.name, m.kind, m.name, m.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startMetrics abre o endpoint GET /metrics com as estatísticas do cliente
+func startMetrics(addr string, stats *Stats) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		stats.WriteText(w)
+	})
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Println("Métricas do cliente encerradas:", err)
+		}
+	}()
+	return ln.Addr(), nil
+}
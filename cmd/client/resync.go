@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Intervalo mínimo entre duas recuperações automáticas
+const resyncDebounce = time.Second
+
+// Recuperação automática de broadcasts perdidos. O primeiro buraco pede
+// ao servidor os broadcasts que faltam (RESYNC); buracos seguidos dentro
+// do intervalo viram um único GET_RESULTS no fim dele, que traz o placar
+// oficial de uma vez em vez de um pedido por buraco.
+type Resyncer struct {
+	m sync.Mutex
+
+	last    time.Time // última recuperação enviada
+	pending bool      // GET_RESULTS já agendado para o fim do intervalo
+}
+
+// gap trata o buraco entre o SeqNum `last` e `upTo`
+func (r *Resyncer) gap(c *client.Client, stats *Stats, last, upTo int) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	wait := resyncDebounce - time.Since(r.last)
+	if wait <= 0 {
+		r.last = time.Now()
+		stats.addResync()
+		send(c, client.Message{Type: "RESYNC", SeqNum: last, UpTo: upTo})
+		return
+	}
+	if r.pending {
+		return
+	}
+	r.pending = true
+	time.AfterFunc(wait, func() {
+		r.m.Lock()
+		r.pending = false
+		r.last = time.Now()
+		r.m.Unlock()
+		stats.addResync()
+		send(c, client.Message{Type: "GET_RESULTS"})
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/juander/udp-vote/internal/server"
+)
+
+// loadConfig monta e valida a configuração do servidor a partir da linha de
+// comando: valores padrão → arquivo (-config) → flags passadas
+// explicitamente. Erros nas flags voltam como error (flag.ErrHelp para -h)
+func loadConfig(args []string) (server.Config, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configPath := fs.String("config", "", "arquivo de configuração JSON")
+	addr := fs.String("addr", "", "endereço UDP (ex.: :9000 ou [::1]:9000)")
+	host := fs.String("host", "", "interface do socket UDP, mantendo a porta de -addr (ex.: 192.168.0.10 ou ::1)")
+	stream := fs.String("stream", "", "endereço do stream TCP (ex.: :9001)")
+	httpAddr := fs.String("http", "", "endereço HTTP com /events (ex.: :8080)")
+	metricsAddr := fs.String("metrics", "", "endereço HTTP só com /metrics (ex.: :9100)")
+	options := fs.String("options", "", "opções de voto separadas por vírgula")
+	duration := fs.Int("duration", 0, "duração da votação em segundos")
+	delay := fs.Int("delay", 0, "espera antes de abrir a votação, em segundos")
+	reusePort := fs.Int("reuseport", 0, "sockets na mesma porta com SO_REUSEPORT (Linux)")
+	if err := fs.Parse(args); err != nil {
+		return server.Config{}, err
+	}
+
+	cfg := server.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = server.LoadConfig(*configPath); err != nil {
+			return cfg, err
+		}
+	}
+
+	// Só sobrescreve o arquivo com as flags passadas explicitamente
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Addr = *addr
+		case "stream":
+			cfg.StreamAddr = *stream
+		case "http":
+			cfg.HTTPAddr = *httpAddr
+		case "metrics":
+			cfg.MetricsAddr = *metricsAddr
+		case "options":
+			cfg.Options = strings.Split(*options, ",")
+		case "duration":
+			cfg.Duration = *duration
+		case "delay":
+			cfg.StartDelay = *delay
+		case "reuseport":
+			cfg.ReusePort = *reusePort
+		}
+	})
+
+	// -host troca só a interface; JoinHostPort põe os colchetes do IPv6
+	if *host != "" {
+		_, port, err := net.SplitHostPort(cfg.Addr)
+		if err != nil {
+			return cfg, fmt.Errorf("addr inválido %q: %v", cfg.Addr, err)
+		}
+		cfg.Addr = net.JoinHostPort(*host, port)
+	}
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig grava o JSON num arquivo temporário e devolve o caminho
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Padrões → arquivo → flags explícitas; o que não foi passado fica do arquivo
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, `{"addr": ":7000", "options": ["X", "Y"], "duration_s": 60, "start_delay_s": 2}`)
+
+	cfg, err := loadConfig([]string{"-config", path, "-duration", "90", "-options", "P,Q,R"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":7000" || cfg.StartDelay != 2 {
+		t.Fatalf("valores do arquivo perdidos: addr=%q delay=%d", cfg.Addr, cfg.StartDelay)
+	}
+	if cfg.Duration != 90 || !reflect.DeepEqual(cfg.Options, []string{"P", "Q", "R"}) {
+		t.Fatalf("flags não sobrescreveram o arquivo: duration=%d options=%v", cfg.Duration, cfg.Options)
+	}
+
+	// Flag com o valor zero passada explicitamente também vale
+	cfg, err = loadConfig([]string{"-config", path, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.StartDelay != 0 || cfg.Duration != 60 {
+		t.Fatalf("delay=%d duration=%d, esperava 0 e 60", cfg.StartDelay, cfg.Duration)
+	}
+
+	// Sem -config ficam os padrões
+	cfg, err = loadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":9000" || cfg.Duration != 300 {
+		t.Fatalf("padrões: addr=%q duration=%d", cfg.Addr, cfg.Duration)
+	}
+}
+
+func TestLoadConfigHost(t *testing.T) {
+	cfg, err := loadConfig([]string{"-addr", ":7000", "-host", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != "[::1]:7000" {
+		t.Fatalf("addr = %q, esperava [::1]:7000", cfg.Addr)
+	}
+}
+
+// Entradas inválidas voltam como erro em vez de encerrar o processo
+func TestLoadConfigBadInput(t *testing.T) {
+	bad := writeConfig(t, `{"addr": ":7000",`)
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"flag desconhecida", []string{"-nada"}, "flag provided but not defined"},
+		{"inteiro inválido", []string{"-duration", "dez"}, "invalid value"},
+		{"duração negativa", []string{"-duration", "-5"}, "duration_s deve ser positivo"},
+		{"uma única opção", []string{"-options", "A"}, "pelo menos 2 opções"},
+		{"addr sem porta", []string{"-addr", "localhost"}, "addr inválido"},
+		{"host com addr inválido", []string{"-addr", "localhost", "-host", "::1"}, "addr inválido"},
+		{"arquivo inexistente", []string{"-config", filepath.Join(t.TempDir(), "nada.json")}, "no such file"},
+		{"JSON quebrado", []string{"-config", bad}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loadConfig(tc.args)
+			if err == nil {
+				t.Fatalf("%v aceito", tc.args)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("erro = %q, esperava conter %q", err, tc.want)
+			}
+		})
+	}
+
+	if _, err := loadConfig([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("-h: erro = %v, esperava flag.ErrHelp", err)
+	}
+}
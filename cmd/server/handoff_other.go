@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "github.com/juander/udp-vote/internal/server"
+
+// watchHandoff: sem SIGUSR2 fora de sistemas Unix
+func watchHandoff(srv *server.UDPServer) {}
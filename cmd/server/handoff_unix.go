@@ -0,0 +1,32 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/juander/udp-vote/internal/server"
+)
+
+// watchHandoff troca este processo pelo binário atual (mesmos argumentos)
+// ao receber SIGUSR2, sem fechar o socket nem perder o estado da votação
+func watchHandoff(srv *server.UDPServer) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR2)
+	go func() {
+		for range ch {
+			proc, err := srv.Handoff(os.Args)
+			if err != nil {
+				log.Printf("[WARN] %v", err)
+				fmt.Println("Falha no handoff:", err)
+				continue
+			}
+			fmt.Printf("Servidor entregue ao processo %d\n", proc.Pid)
+			os.Exit(0)
+		}
+	}()
+}
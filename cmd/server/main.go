@@ -1,40 +1,184 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/juander/udp-vote/internal/server"
+	"github.com/juander/udp-vote/pkg/resultfmt"
 )
 
 func main() {
-	// Logs em arquivo
-	logFile, err := os.OpenFile("logs/server_udp.log",
-		os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+	// Configuração: valores padrão → arquivo (-config) → flags
+	cfg, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Fatal("Erro ao abrir arquivo de log:", err)
+		fmt.Println("Erro na configuração:", err)
+		os.Exit(2)
+	}
+
+	// Logs em arquivo
+	if cfg.LogFile != "" {
+		logFile, err := os.OpenFile(cfg.LogFile,
+			os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+		if err != nil {
+			log.Fatal("Erro ao abrir arquivo de log:", err)
+		}
+		defer logFile.Close()
+		log.SetOutput(logFile)
 	}
-	defer logFile.Close()
-	log.SetOutput(logFile)
 
 	fmt.Println("=== SERVIDOR UDP DE VOTAÇÃO ===")
-	fmt.Println("Logs salvos em: logs/server_udp.log\n")
+	if cfg.LogFile != "" {
+		fmt.Printf("Logs salvos em: %s\n\n", cfg.LogFile)
+	}
 
-	// Opções da votação
-	opcoes := []string{"A", "B", "C"}
+	// Votação gravada em state_file por uma execução anterior
+	resumed := false
+	if cfg.StateFile != "" {
+		_, err := os.Stat(cfg.StateFile)
+		resumed = err == nil
+	}
 
 	// Cria servidor sempre assíncrono
-	srv := server.NewUDPServer(opcoes)
+	srv, err := server.NewUDPServerFromConfig(cfg)
+	if err != nil {
+		fmt.Println("Erro na configuração:", err)
+		os.Exit(2)
+	}
 
-	// Inicia votação automaticamente após 5 segundos
+	if resumed {
+		fmt.Printf("Votação retomada de %s (%s)\n", cfg.StateFile, srv.State())
+	}
+
+	// Iniciado por Handoff: continua a votação do processo anterior
+	handoff, err := server.InheritedHandoff()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if handoff != nil {
+		if err := srv.RestoreHandoff(*handoff); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Votação retomada do processo anterior (%s)\n", handoff.State)
+	}
+
+	// Log de auditoria com cadeia de hashes dos votos aceitos
+	// (a cadeia recomeça a cada execução, então o arquivo é recriado;
+	// depois de um handoff ou com o estado recarregado ela continua no
+	// mesmo arquivo)
+	if cfg.AuditLog != "" {
+		flags := os.O_CREATE | os.O_TRUNC | os.O_WRONLY
+		if handoff != nil || resumed {
+			flags = os.O_CREATE | os.O_APPEND | os.O_WRONLY
+		}
+		auditFile, err := os.OpenFile(cfg.AuditLog, flags, 0666)
+		if err != nil {
+			log.Fatal("Erro ao abrir log de auditoria:", err)
+		}
+		defer auditFile.Close()
+		srv.SetAuditLog(auditFile)
+	}
+
+	// Cópia de cada broadcast enviado, para ferramentas externas (tail -f)
+	if cfg.BroadcastLog != "" {
+		broadcastFile, err := os.OpenFile(cfg.BroadcastLog,
+			os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Erro ao abrir log de broadcasts:", err)
+		}
+		defer broadcastFile.Close()
+		srv.SetBroadcastLog(broadcastFile)
+	}
+
+	// Stream TCP confiável para placares oficiais
+	if cfg.StreamAddr != "" {
+		if err := listen(srv.StartStream, cfg.StreamAddr, handoff != nil); err != nil {
+			log.Fatal("Erro ao abrir stream TCP:", err)
+		}
+	}
+
+	// Eventos SSE para painéis no navegador
+	if cfg.HTTPAddr != "" {
+		if err := listen(srv.StartHTTP, cfg.HTTPAddr, handoff != nil); err != nil {
+			log.Fatal("Erro ao abrir servidor HTTP:", err)
+		}
+	}
+
+	// Contadores para o Prometheus
+	if cfg.MetricsAddr != "" {
+		if err := listen(srv.StartMetrics, cfg.MetricsAddr, handoff != nil); err != nil {
+			log.Fatal("Erro ao abrir endpoint de métricas:", err)
+		}
+	}
+
+	switch {
+	case srv.State() != server.VotingNotStarted:
+		// Votação já aberta (ou encerrada) pelo processo anterior
+	case cfg.OpenAt != nil:
+		// Abertura e encerramento em horários definidos
+		if err := srv.ScheduleVoting(*cfg.OpenAt, *cfg.CloseAt); err != nil {
+			log.Fatal("Erro ao agendar votação:", err)
+		}
+		fmt.Printf("Votação agendada: %s até %s\n",
+			cfg.OpenAt.Format(time.RFC3339), cfg.CloseAt.Format(time.RFC3339))
+	default:
+		// Inicia votação automaticamente após o atraso configurado,
+		// contado a partir da abertura do socket
+		go func() {
+			<-srv.Ready()
+			time.Sleep(time.Duration(cfg.StartDelay) * time.Second)
+			fmt.Printf("Iniciando votação (%ds)...\n", cfg.Duration)
+			srv.StartVoting(cfg.Duration)
+		}()
+	}
+
+	// SIGUSR2 passa o socket e o estado a um novo processo
+	watchHandoff(srv)
+
+	// SIGINT/SIGTERM encerram o servidor sem perder o placar
+	watchShutdown(srv)
+
+	// Escuta na porta UDP (retorna depois do Stop)
+	if err := srv.Start(cfg.Addr); err != nil {
+		log.Fatal("Erro ao abrir socket UDP:", err)
+	}
+
+	// Resumo no log antes de os defers fecharem os arquivos
+	r := srv.GetResults()
+	log.Printf("[FINAL] Estado %s, %d votos: %s", r.State, r.TotalVotes, resultfmt.Breakdown(r.VoteCounts))
+	fmt.Printf("Servidor encerrado (%s). Votos: %s\n", r.State, resultfmt.Breakdown(r.VoteCounts))
+}
+
+// watchShutdown chama o Stop no primeiro SIGINT/SIGTERM; um segundo sinal
+// volta ao comportamento padrão e mata o processo na hora
+func watchShutdown(srv *server.UDPServer) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		time.Sleep(5 * time.Second)
-		fmt.Println("Iniciando votação (300s)...")
-		srv.StartVoting(300)
+		sig := <-ch
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		log.Printf("Sinal %s recebido: encerrando", sig)
+		fmt.Println("\nEncerrando...")
+		srv.Stop()
 	}()
+}
 
-	// Escuta na porta UDP
-	srv.Start(":9000")
+// listen abre um servidor TCP opcional. Depois de um handoff, o processo
+// anterior ainda segura a porta por alguns instantes até sair.
+func listen(start func(string) error, addr string, handoff bool) error {
+	err := start(addr)
+	for i := 0; err != nil && handoff && i < 20; i++ {
+		time.Sleep(100 * time.Millisecond)
+		err = start(addr)
+	}
+	return err
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/juander/udp-vote/internal/server"
+)
+
+// Verificador independente: recalcula a apuração a partir do log de
+// auditoria e confere com o resultado exportado pelo servidor.
+func main() {
+	resultsPath := flag.String("results", "logs/results.json", "resultado exportado pelo servidor")
+	auditPath := flag.String("audit", "logs/audit.jsonl", "log de auditoria (JSON por linha)")
+	flag.Parse()
+
+	fmt.Println("=== VERIFICADOR DE APURAÇÃO ===")
+
+	results, err := server.ReadResults(*resultsPath)
+	if err != nil {
+		fmt.Println("Erro ao ler resultado:", err)
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*auditPath)
+	if err != nil {
+		fmt.Println("Erro ao abrir log de auditoria:", err)
+		os.Exit(2)
+	}
+	records, err := server.ReadAuditLog(f)
+	f.Close()
+	if err != nil {
+		fmt.Println("Erro ao ler log de auditoria:", err)
+		os.Exit(2)
+	}
+
+	problems := verify(results, records)
+	if len(problems) == 0 {
+		fmt.Printf("OK: %d votos conferidos, selo %s\n", len(records), results.ChainHash)
+		return
+	}
+
+	fmt.Println("DIVERGÊNCIAS ENCONTRADAS:")
+	for _, p := range problems {
+		fmt.Println(" -", p)
+	}
+	os.Exit(1)
+}
+
+// verify compara o resultado exportado com o log e devolve as divergências
+func verify(results server.Results, records []server.AuditRecord) []string {
+	var problems []string
+
+	hash, err := server.VerifyAuditChain(records)
+	if err != nil {
+		// Com a cadeia quebrada, a contagem do log não é confiável
+		return append(problems, "cadeia de auditoria inválida: "+err.Error())
+	}
+	if hash != results.ChainHash {
+		problems = append(problems, fmt.Sprintf("selo diverge: resultado %q, log %q", results.ChainHash, hash))
+	}
+
+	// A cadeia cobre todas as rodadas; a contagem é só a da rodada decidida
+	// (nos segundos turnos, a última)
+	var round []server.AuditRecord
+	for _, rec := range records {
+		if rec.Round == results.Round {
+			round = append(round, rec)
+		}
+	}
+	replayed := server.ReplayAudit(round)
+
+	// Une as opções dos dois lados para pegar votos sem correspondência
+	options := make(map[string]bool)
+	for op := range results.VoteCounts {
+		options[op] = true
+	}
+	for op := range replayed {
+		options[op] = true
+	}
+	sorted := make([]string, 0, len(options))
+	for op := range options {
+		sorted = append(sorted, op)
+	}
+	sort.Strings(sorted)
+
+	total := int64(0)
+	for _, op := range sorted {
+		total += replayed[op]
+		if results.VoteCounts[op] != replayed[op] {
+			problems = append(problems, fmt.Sprintf("opção %s: resultado %d, log %d",
+				op, results.VoteCounts[op], replayed[op]))
+		}
+	}
+	if results.TotalVotes != total {
+		problems = append(problems, fmt.Sprintf("total: resultado %d, log %d", results.TotalVotes, total))
+	}
+
+	return problems
+}
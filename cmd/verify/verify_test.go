@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/juander/udp-vote/internal/server"
+)
+
+// loadPair lê o par resultado + auditoria gravado pelo servidor em testdata
+// (4 votos: A=2, B=1, C=1)
+func loadPair(t *testing.T) (server.Results, []server.AuditRecord) {
+	t.Helper()
+	results, err := server.ReadResults("testdata/results.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/audit.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := server.ReadAuditLog(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results, records
+}
+
+func TestVerifyConsistentPair(t *testing.T) {
+	results, records := loadPair(t)
+	if problems := verify(results, records); len(problems) != 0 {
+		t.Fatalf("divergências num par consistente: %v", problems)
+	}
+}
+
+func TestVerifyDetectsDiscrepancy(t *testing.T) {
+	cases := []struct {
+		name   string
+		inject func(r *server.Results, recs *[]server.AuditRecord)
+		want   []string
+	}{
+		{"contagem alterada", func(r *server.Results, _ *[]server.AuditRecord) {
+			r.VoteCounts["B"]++
+			r.TotalVotes++
+		}, []string{"opção B: resultado 2, log 1", "total: resultado 5, log 4"}},
+		{"total alterado", func(r *server.Results, _ *[]server.AuditRecord) {
+			r.TotalVotes = 3
+		}, []string{"total: resultado 3, log 4"}},
+		{"opção ausente do resultado", func(r *server.Results, _ *[]server.AuditRecord) {
+			delete(r.VoteCounts, "C")
+			r.TotalVotes = 3
+		}, []string{"opção C: resultado 0, log 1", "total: resultado 3, log 4"}},
+		{"selo trocado", func(r *server.Results, _ *[]server.AuditRecord) {
+			r.ChainHash = "00"
+		}, []string{"selo diverge"}},
+		{"voto a mais no log", func(r *server.Results, recs *[]server.AuditRecord) {
+			*recs = (*recs)[:3] // o resultado conta um voto que o log não tem
+		}, []string{"selo diverge", "opção C: resultado 1, log 0"}},
+		{"registro adulterado", func(_ *server.Results, recs *[]server.AuditRecord) {
+			(*recs)[1].Option = "A"
+		}, []string{"cadeia de auditoria inválida: registro 2"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, records := loadPair(t)
+			tc.inject(&results, &records)
+			problems := strings.Join(verify(results, records), "\n")
+			for _, w := range tc.want {
+				if !strings.Contains(problems, w) {
+					t.Fatalf("divergências = %q, esperava conter %q", problems, w)
+				}
+			}
+		})
+	}
+}
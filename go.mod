@@ -1,3 +1,8 @@
 module github.com/juander/udp-vote
 
 go 1.21
+
+require (
+	golang.org/x/net v0.35.0
+	golang.org/x/sys v0.30.0
+)
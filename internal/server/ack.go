@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// ----------------------------------------------------------
+// ACKs de voto agrupados por endereço
+// ----------------------------------------------------------
+//
+// Um processo que vota por muitos IDs no mesmo socket (modo -auto, teste de
+// carga) recebe um ACK por voto. Com SetAckCoalesce, os ACKs de um mesmo
+// endereço são retidos pela janela configurada e saem em um único datagrama
+// que lista os ClientIDs confirmados (campo acked). O padrão continua sendo
+// um ACK imediato por voto. O ACK de um único voto ecoa o RequestID do VOTE
+// e traz a opção registrada (recorded_option); no agrupado, o campo acked
+// identifica os votos confirmados.
+//
+// Os últimos RequestIDs confirmados de cada cliente ficam guardados: uma
+// cópia retransmitida de um voto já contado (mesmo RequestID na mesma
+// rodada) recebe de novo o ACK original, sem contar nada. Sem isso, com
+// SetAllowRevote, a cópia atrasada de um voto anterior desfaria a troca.
+
+// Quantos RequestIDs confirmados são guardados por cliente
+const ackedRequestsPerClient = 16
+
+// Voto confirmado, identificado pelo RequestID do cliente
+type ackedRequest struct {
+	id      string
+	round   int
+	option  string // opção registrada
+	changed bool   // o ACK foi "Voto alterado"
+}
+
+// Votos confirmados aguardando o envio agrupado
+type pendingAck struct {
+	addr *net.UDPAddr
+	ids  []string
+	reqs []ackedRequest // cada voto, na ordem de ids
+}
+
+// SetAckCoalesce agrupa os ACKs de voto de cada endereço por `window`
+// (0 = um ACK imediato por voto)
+func (s *UDPServer) SetAckCoalesce(window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ackCoalesce = window
+}
+
+// ackVoteLocked confirma o voto de `id` em `option`, logo ou na próxima
+// janela
+func (s *UDPServer) ackVoteLocked(id, reqID, option string, addr *net.UDPAddr, changed bool) {
+	r := ackedRequest{id: reqID, round: s.round, option: option, changed: changed}
+	if reqID != "" {
+		s.clients[id].rememberRequest(r)
+	}
+	if s.ackCoalesce <= 0 {
+		s.send(addr, voteAck(id, r))
+		return
+	}
+
+	key := addr.String()
+	p, ok := s.pendingAcks[key]
+	if !ok {
+		p = &pendingAck{addr: addr}
+		s.pendingAcks[key] = p
+		time.AfterFunc(s.ackCoalesce, func() { s.flushAcks(key) })
+	}
+	p.ids = append(p.ids, id)
+	p.reqs = append(p.reqs, r)
+}
+
+// reackVoteLocked repete o ACK se `reqID` é de um voto de `id` já
+// confirmado nesta rodada
+func (s *UDPServer) reackVoteLocked(id, reqID string, addr *net.UDPAddr) bool {
+	if reqID == "" {
+		return false
+	}
+	for _, r := range s.clients[id].ackedRequests {
+		if r.id == reqID && r.round == s.round {
+			s.send(addr, voteAck(id, r))
+			return true
+		}
+	}
+	return false
+}
+
+// rememberRequest guarda o voto confirmado, descartando o mais antigo
+// acima de ackedRequestsPerClient
+func (c *clientInfo) rememberRequest(r ackedRequest) {
+	if len(c.ackedRequests) >= ackedRequestsPerClient {
+		c.ackedRequests = c.ackedRequests[1:]
+	}
+	c.ackedRequests = append(c.ackedRequests, r)
+}
+
+// voteAck é o ACK de um único voto
+func voteAck(id string, r ackedRequest) Message {
+	msg := Message{Type: "ACK", Message: "Voto registrado", Acked: []string{id}, RequestID: r.id, RecordedOption: r.option}
+	if r.changed {
+		msg.Message = "Voto alterado"
+	}
+	return msg
+}
+
+// flushAcks envia em um único ACK os votos confirmados de um endereço
+func (s *UDPServer) flushAcks(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pendingAcks[key]
+	if !ok {
+		return
+	}
+	delete(s.pendingAcks, key)
+
+	msg := Message{Type: "ACK", Message: "Voto registrado", Acked: p.ids}
+	if len(p.ids) > 1 {
+		msg.Message = fmt.Sprintf("Votos registrados (%d)", len(p.ids))
+	} else {
+		msg.RequestID, msg.RecordedOption = p.reqs[0].id, p.reqs[0].option
+	}
+	s.send(p.addr, msg)
+}
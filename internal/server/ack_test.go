@@ -0,0 +1,172 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+// O mesmo VOTE (mesmo RequestID) três vezes conta uma vez, com ou sem troca
+// de voto
+func TestDedupSameRequestThreeTimes(t *testing.T) {
+	for _, revote := range []bool{false, true} {
+		s, f := votingServer(t, revote, "ana")
+		a := testAddr(1)
+		for i := 0; i < 3; i++ {
+			if got := voteReq(s, f, "ana", a, "A", "ana-7"); got.Type != "ACK" || got.RequestID != "ana-7" {
+				t.Fatalf("revote=%v: envio %d = %+v", revote, i+1, got)
+			}
+		}
+		wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+		if st := s.Stats(); st.VotesReceived != 3 || st.VotesAccepted != 1 {
+			t.Fatalf("revote=%v: recebidos %d, aceitos %d; esperava 3 e 1", revote, st.VotesReceived, st.VotesAccepted)
+		}
+	}
+}
+
+// Cada cliente guarda só os últimos ackedRequestsPerClient pedidos: um
+// reenvio mais antigo que isso é tratado como voto novo
+func TestDedupSetBounded(t *testing.T) {
+	s, f := votingServer(t, true, "ana")
+	a := testAddr(1)
+	options := []string{"A", "B"}
+	// ana-0 (A), ana-1 (B), ..., ana-17 (B): os dois primeiros saem
+	for i := 0; i < ackedRequestsPerClient+2; i++ {
+		voteReq(s, f, "ana", a, options[i%2], fmt.Sprintf("ana-%d", i))
+	}
+	s.mu.Lock()
+	kept := len(s.clients["ana"].ackedRequests)
+	s.mu.Unlock()
+	if kept != ackedRequestsPerClient {
+		t.Fatalf("%d pedidos guardados, limite %d", kept, ackedRequestsPerClient)
+	}
+
+	// ana-2 (A) ainda está guardado: só repete o ACK, sem trocar de volta
+	if got := voteReq(s, f, "ana", a, "A", "ana-2"); got.Type != "ACK" || got.RecordedOption != "A" {
+		t.Fatalf("reenvio de ana-2 = %+v", got)
+	}
+	wantTally(t, s, map[string]int64{"A": 0, "B": 1})
+
+	// ana-0 (A) saiu do conjunto: vale como troca de voto nova
+	if got := voteReq(s, f, "ana", a, "A", "ana-0"); got.Type != "ACK" || got.Message != "Voto alterado" {
+		t.Fatalf("reenvio de ana-0 = %+v, esperava uma troca nova", got)
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+}
+
+// O RequestID do VOTE volta sem alteração em toda resposta ao voto, aceito
+// ou recusado
+func TestRequestIDEchoed(t *testing.T) {
+	const reqID = "ana-42/é ✓"
+	cases := []struct {
+		name     string
+		setup    func(s *UDPServer)
+		id       string
+		addr     int
+		option   string
+		wantType string
+		wantMsg  string
+	}{
+		{"aceito", func(s *UDPServer) { s.StartVoting(60) }, "ana", 1, "A", "ACK", "Voto registrado"},
+		{"trocado", func(s *UDPServer) {
+			s.SetAllowRevote(true)
+			s.StartVoting(60)
+			deliver(s, testAddr(1), Message{Type: "VOTE", ClientID: "ana", VoteOption: "B"})
+		}, "ana", 1, "A", "ACK", "Voto alterado"},
+		{"não iniciada", func(s *UDPServer) {}, "ana", 1, "A", "ERROR", "Votação não iniciada"},
+		{"pausada", func(s *UDPServer) { s.StartVoting(60); s.PauseVoting() }, "ana", 1, "A", "ERROR", "Votação pausada"},
+		{"encerrada", func(s *UDPServer) { s.StartVoting(60); s.EndVotingNow() }, "ana", 1, "A", "ERROR", "Votação encerrada"},
+		{"duplicado", func(s *UDPServer) {
+			s.StartVoting(60)
+			deliver(s, testAddr(1), Message{Type: "VOTE", ClientID: "ana", VoteOption: "A"})
+		}, "ana", 1, "A", "ERROR", "Voto duplicado"},
+		{"opção inválida", func(s *UDPServer) { s.StartVoting(60) }, "ana", 1, "Z", "ERROR", "Opção inválida"},
+		{"sem registro", func(s *UDPServer) { s.StartVoting(60) }, "bia", 2, "A", "ERROR", "Registre-se primeiro"},
+		{"outro endereço", func(s *UDPServer) { s.StartVoting(60) }, "ana", 2, "A", "ERROR", "Endereço não corresponde"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			register(t, s, f, "ana", testAddr(1))
+			tc.setup(s)
+
+			got := voteReq(s, f, tc.id, testAddr(tc.addr), tc.option, reqID)
+			if got.Type != tc.wantType || got.Message != tc.wantMsg {
+				t.Fatalf("resposta = %+v, esperava %s %q", got, tc.wantType, tc.wantMsg)
+			}
+			if got.RequestID != reqID {
+				t.Fatalf("RequestID = %q, esperava %q", got.RequestID, reqID)
+			}
+		})
+	}
+}
+
+// ACK agrupado: com um único voto na janela, ecoa o RequestID; com vários,
+// o campo acked identifica os votos e o RequestID fica vazio
+func TestRequestIDEchoedCoalesced(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetAckCoalesce(10 * time.Millisecond)
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+	s.StartVoting(60)
+
+	voteReq(s, f, "ana", a, "A", "ana-1")
+	got := f.waitFor(t, a, func(m Message) bool { return m.Type == "ACK" && len(m.Acked) > 0 })
+	if got.RequestID != "ana-1" || got.RecordedOption != "A" {
+		t.Fatalf("ACK agrupado de um voto = %+v", got)
+	}
+
+	// Dois IDs no mesmo socket
+	b := testAddr(2)
+	register(t, s, f, "bia", b)
+	register(t, s, f, "caio", b)
+	deliver(s, b, Message{Type: "VOTE", ClientID: "bia", VoteOption: "A", RequestID: "bia-1"})
+	deliver(s, b, Message{Type: "VOTE", ClientID: "caio", VoteOption: "B", RequestID: "caio-1"})
+	got = f.waitFor(t, b, func(m Message) bool { return m.Type == "ACK" && len(m.Acked) > 0 })
+	if len(got.Acked) != 2 || got.RequestID != "" {
+		t.Fatalf("ACK agrupado de dois votos = %+v", got)
+	}
+}
+
+// Votos rápidos de dez IDs no mesmo socket dentro da janela: um único ACK
+// confirma todos
+func TestAckCoalesceRapidVotes(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetAckCoalesce(50 * time.Millisecond)
+	a := testAddr(1)
+	var ids []string
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("auto-%d", i)
+		register(t, s, f, id, a)
+		ids = append(ids, id)
+	}
+	s.StartVoting(60)
+	for _, id := range ids {
+		deliver(s, a, Message{Type: "VOTE", ClientID: id, VoteOption: "A"})
+	}
+	if acks := voteAcks(f, a); len(acks) != 0 {
+		t.Fatalf("ACK enviado antes do fim da janela: %+v", acks[0])
+	}
+
+	got := f.waitFor(t, a, func(m Message) bool { return m.Type == "ACK" && len(m.Acked) > 0 })
+	if !slices.Equal(got.Acked, ids) || got.Message != "Votos registrados (10)" {
+		t.Fatalf("ACK agrupado = %+v", got)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if acks := voteAcks(f, a); len(acks) != 1 {
+		t.Fatalf("%d ACKs de voto, esperava um só", len(acks))
+	}
+}
+
+// voteAcks devolve os ACKs de voto (com acked) enviados a addr
+func voteAcks(f *fakeConn, addr *net.UDPAddr) []Message {
+	var acks []Message
+	for _, m := range f.ofType(addr, "ACK") {
+		if len(m.Acked) > 0 {
+			acks = append(acks, m)
+		}
+	}
+	return acks
+}
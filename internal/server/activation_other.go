@@ -0,0 +1,10 @@
+//go:build !unix
+
+package server
+
+import "net"
+
+// inheritedConn: a ativação por socket do systemd só existe em sistemas Unix
+func inheritedConn() (*net.UDPConn, error) {
+	return nil, nil
+}
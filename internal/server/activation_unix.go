@@ -0,0 +1,49 @@
+//go:build unix
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// Primeiro descritor passado pelo systemd (SD_LISTEN_FDS_START)
+const listenFdsStart = 3
+
+// inheritedConn devolve o socket UDP herdado pela ativação por socket do
+// systemd (protocolo LISTEN_FDS), ou nil quando o processo não foi ativado
+// assim. As variáveis são removidas para não passarem a processos filhos.
+func inheritedConn() (*net.UDPConn, error) {
+	fds := os.Getenv("LISTEN_FDS")
+	if fds == "" {
+		return nil, nil
+	}
+	// LISTEN_PID diz a qual processo os descritores se destinam
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("LISTEN_FDS inválido: %q", fds)
+	}
+
+	f := os.NewFile(listenFdsStart, "systemd-socket")
+	defer f.Close() // FilePacketConn duplica o descritor
+	pc, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, fmt.Errorf("socket herdado: %v", err)
+	}
+	conn, ok := pc.(*net.UDPConn)
+	if !ok {
+		pc.Close()
+		return nil, errors.New("socket herdado não é UDP")
+	}
+	return conn, nil
+}
@@ -0,0 +1,85 @@
+//go:build unix
+
+package server
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Processo filho do TestSocketActivation: sobe o servidor com o socket
+// herdado no fd 3 e fica servindo até ser morto
+func TestSocketActivationChild(t *testing.T) {
+	if os.Getenv("UDPVOTE_ACTIVATION_CHILD") != "1" {
+		t.Skip("só roda como filho do TestSocketActivation")
+	}
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Endereço impossível: só funciona se o socket herdado for usado
+	if err := s.Start("endereço-inválido"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Simula a ativação do systemd: o socket é aberto aqui e passado ao filho
+// como fd 3, com LISTEN_FDS=1; o filho atende nele sem abrir outro
+func TestSocketActivation(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	file, err := conn.File()
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSocketActivationChild$")
+	cmd.Env = append(os.Environ(), "UDPVOTE_ACTIVATION_CHILD=1", "LISTEN_FDS=1")
+	cmd.ExtraFiles = []*os.File{file} // vira o fd 3 do filho
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	c, err := client.Dial(addr, "ana", client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Register(); err != nil {
+		t.Fatalf("servidor não atendeu no socket herdado: %v", err)
+	}
+}
+
+// LISTEN_PID de outro processo: os descritores não são deste, e o Start
+// abre o próprio socket
+func TestSocketActivationOtherPID(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	conn, err := inheritedConn()
+	if conn != nil || err != nil {
+		t.Fatalf("inheritedConn = %v, %v; esperava nenhum socket herdado", conn, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "1" {
+		t.Fatal("variáveis de outro processo removidas do ambiente")
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "zero")
+	if _, err := inheritedConn(); err == nil {
+		t.Fatal("LISTEN_FDS inválido aceito")
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Encerramento antecipado pelo operador (ADMIN_END)
+// ----------------------------------------------------------
+//
+// Sem intervenção, a votação só termina no prazo. EndVotingNow encerra na
+// hora uma votação ativa ou pausada, com a mesma apuração e o mesmo
+// broadcast final do prazo, e cancela o timer pendente. Com
+// SetAdminSecret, um operador pode pedir o mesmo remotamente, sem
+// registro, em duas etapas:
+//
+//  1. ADMIN_END sem challenge: o servidor responde ADMIN_CHALLENGE com um
+//     desafio e o número da rodada atual.
+//  2. ADMIN_END com esse challenge e, em auth, o HMAC-SHA256 de
+//     "ADMIN_END|<rodada>|<desafio>" com o segredo (client.AdminToken).
+//
+// O desafio vale por adminChallengeTTL, só para o endereço que o pediu e
+// uma única vez, e o token cobre a rodada: um ADMIN_END capturado não
+// encerra de novo nem a votação seguinte. Como na prova de trabalho, o
+// desafio é derivado do horário e do endereço com o segredo, sem estado
+// por desafio emitido; só os já usados ficam guardados até vencer. Sem
+// segredo configurado, o ADMIN_END é recusado.
+
+// Validade de um desafio do ADMIN_END
+const adminChallengeTTL = 30 * time.Second
+
+// SetAdminSecret define o segredo compartilhado com o operador para as
+// mensagens ADMIN_*. Segredo vazio desliga.
+func (s *UDPServer) SetAdminSecret(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adminSecret = []byte(secret)
+	if secret == "" {
+		s.adminSecret = nil
+	}
+}
+
+// EndVotingNow encerra a votação ativa ou pausada antes do prazo
+func (s *UDPServer) EndVotingNow() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endVotingNowLocked()
+}
+
+func (s *UDPServer) endVotingNowLocked() error {
+	switch s.votingState {
+	case VotingActive:
+	case VotingPaused:
+		// Encerrar não retoma: o prazo congelado deixa de valer
+		s.votingState = VotingActive
+		s.pausedAt = time.Time{}
+	default:
+		return errors.New("só uma votação aberta pode ser encerrada")
+	}
+
+	log.Printf("[ADMIN] Votação encerrada antes do prazo")
+	s.endVotingLocked()
+	return nil
+}
+
+// adminChallengeLocked emite o desafio de addr no instante `at` (em
+// nanossegundos): "<at>.<HMAC do instante e do endereço>"
+func (s *UDPServer) adminChallengeLocked(at int64, addr *net.UDPAddr) string {
+	issued := strconv.FormatInt(at, 10)
+	mac := hmac.New(sha256.New, s.adminSecret)
+	mac.Write([]byte("ADMIN_CHALLENGE|" + issued + "|" + addr.String()))
+	return issued + "." + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// adminChallengeValidLocked confere se o desafio foi emitido para addr,
+// ainda vale e não foi usado
+func (s *UDPServer) adminChallengeValidLocked(challenge string, addr *net.UDPAddr, now time.Time) bool {
+	issued, _, ok := strings.Cut(challenge, ".")
+	if !ok {
+		return false
+	}
+	at, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil || !hmac.Equal([]byte(challenge), []byte(s.adminChallengeLocked(at, addr))) {
+		return false
+	}
+	age := now.Sub(time.Unix(0, at))
+	if age < 0 || age > adminChallengeTTL {
+		return false
+	}
+	_, used := s.adminUsed[challenge]
+	return !used
+}
+
+// useAdminChallengeLocked marca o desafio como usado, esquecendo os que já
+// venceram (esses o adminChallengeValidLocked recusa pela idade)
+func (s *UDPServer) useAdminChallengeLocked(challenge string, now time.Time) {
+	for c, at := range s.adminUsed {
+		if now.Sub(at) > adminChallengeTTL {
+			delete(s.adminUsed, c)
+		}
+	}
+	if s.adminUsed == nil {
+		s.adminUsed = make(map[string]time.Time)
+	}
+	s.adminUsed[challenge] = now
+}
+
+// adminTokenLocked deriva o token do operador para o tipo `t` na rodada
+// atual com o desafio dado
+func (s *UDPServer) adminTokenLocked(t, challenge string) string {
+	mac := hmac.New(sha256.New, s.adminSecret)
+	mac.Write([]byte(t + "|" + strconv.Itoa(s.round) + "|" + challenge))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// adminEnd responde ao ADMIN_END: com o desafio sem challenge, com o
+// encerramento se o token confere
+func (s *UDPServer) adminEnd(msg Message, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.adminSecret == nil {
+		s.send(addr, Message{Type: "ERROR", Message: "Operação não permitida"})
+		return
+	}
+	now := s.now()
+	if msg.Challenge == "" {
+		s.send(addr, Message{
+			Type:      "ADMIN_CHALLENGE",
+			Challenge: s.adminChallengeLocked(now.UnixNano(), addr),
+			Round:     s.round,
+		})
+		return
+	}
+	if !s.adminChallengeValidLocked(msg.Challenge, addr, now) {
+		log.Printf("[ADMIN] %s recusado de %s: desafio inválido, vencido ou já usado", msg.Type, addr)
+		s.send(addr, Message{Type: "ERROR", Message: "Desafio inválido"})
+		return
+	}
+	if !hmac.Equal([]byte(msg.Auth), []byte(s.adminTokenLocked(msg.Type, msg.Challenge))) {
+		log.Printf("[ADMIN] %s recusado de %s: token inválido", msg.Type, addr)
+		s.send(addr, Message{Type: "ERROR", Message: "Autenticação inválida"})
+		return
+	}
+	s.useAdminChallengeLocked(msg.Challenge, now)
+	if err := s.endVotingNowLocked(); err != nil {
+		s.send(addr, Message{Type: "ERROR", Message: err.Error()})
+		return
+	}
+	s.send(addr, Message{Type: "ACK", Message: "Votação encerrada"})
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// finalBroadcasts conta os broadcasts de encerramento enviados a addr
+func finalBroadcasts(f *fakeConn, addr *net.UDPAddr) int {
+	n := 0
+	for _, m := range f.ofType(addr, "BROADCAST") {
+		if m.Final != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// adminEndSigned pede o desafio de addr e devolve o ADMIN_END assinado
+// com secret, pronto para entregar
+func adminEndSigned(t *testing.T, s *UDPServer, f *fakeConn, addr *net.UDPAddr, secret string) Message {
+	t.Helper()
+	deliver(s, addr, Message{Type: "ADMIN_END"})
+	ch := f.last(addr)
+	if ch.Type != "ADMIN_CHALLENGE" || ch.Challenge == "" {
+		t.Fatalf("ADMIN_END sem desafio: esperava ADMIN_CHALLENGE, veio %+v", ch)
+	}
+	return Message{
+		Type:      "ADMIN_END",
+		Challenge: ch.Challenge,
+		Auth:      client.AdminToken(secret, "ADMIN_END", ch.Round, ch.Challenge),
+	}
+}
+
+func TestEndVotingNowEndsEarly(t *testing.T) {
+	s, f := newFakeServer(t)
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+	s.StartVoting(3600)
+	vote(s, f, "ana", a, "A")
+
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	if s.State() != VotingEnded {
+		t.Fatalf("estado = %s, esperava %s", s.State(), VotingEnded)
+	}
+	if err := s.EndVotingNow(); err == nil {
+		t.Fatal("segundo EndVotingNow aceito")
+	}
+	stopFake(s, f)
+	if n := finalBroadcasts(f, a); n != 1 {
+		t.Fatalf("%d broadcasts finais, esperava 1", n)
+	}
+	if got := s.Results()["A"]; got != 1 {
+		t.Fatalf("votos em A = %d, esperava 1", got)
+	}
+}
+
+func TestEndVotingNowWhilePaused(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.StartVoting(3600)
+	if err := s.PauseVoting(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	if s.State() != VotingEnded {
+		t.Fatalf("estado = %s, esperava %s", s.State(), VotingEnded)
+	}
+}
+
+func TestEndVotingNowNotOpen(t *testing.T) {
+	s, _ := newFakeServer(t)
+	if err := s.EndVotingNow(); err == nil {
+		t.Fatal("EndVotingNow aceito antes da abertura")
+	}
+	if s.State() != VotingNotStarted {
+		t.Fatalf("estado = %s, esperava %s", s.State(), VotingNotStarted)
+	}
+}
+
+func TestAdminEndHandshake(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetAdminSecret("segredo")
+	voter, op := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", voter)
+	s.StartVoting(3600)
+
+	deliver(s, op, adminEndSigned(t, s, f, op, "segredo"))
+	if got := f.last(op); got.Type != "ACK" || got.Message != "Votação encerrada" {
+		t.Fatalf("ADMIN_END assinado = %+v", got)
+	}
+	if s.State() != VotingEnded {
+		t.Fatalf("estado = %s, esperava %s", s.State(), VotingEnded)
+	}
+	stopFake(s, f)
+	if n := finalBroadcasts(f, voter); n != 1 {
+		t.Fatalf("%d broadcasts finais, esperava 1", n)
+	}
+}
+
+func TestAdminEndRejected(t *testing.T) {
+	op, other := testAddr(2), testAddr(3)
+	cases := []struct {
+		name string
+		run  func(t *testing.T, s *UDPServer, f *fakeConn, clock *time.Time) Message
+		want string
+	}{
+		{"sem segredo no servidor", func(t *testing.T, s *UDPServer, f *fakeConn, _ *time.Time) Message {
+			s.SetAdminSecret("")
+			deliver(s, op, Message{Type: "ADMIN_END"})
+			return f.last(op)
+		}, "Operação não permitida"},
+		{"segredo errado", func(t *testing.T, s *UDPServer, f *fakeConn, _ *time.Time) Message {
+			deliver(s, op, adminEndSigned(t, s, f, op, "outro"))
+			return f.last(op)
+		}, "Autenticação inválida"},
+		{"token do modelo antigo", func(t *testing.T, s *UDPServer, f *fakeConn, _ *time.Time) Message {
+			msg := adminEndSigned(t, s, f, op, "segredo")
+			msg.Auth = client.AdminToken("segredo", "ADMIN_END", 0, "")
+			deliver(s, op, msg)
+			return f.last(op)
+		}, "Autenticação inválida"},
+		{"desafio forjado", func(t *testing.T, s *UDPServer, f *fakeConn, _ *time.Time) Message {
+			challenge := "1.0123456789abcdef0123456789abcdef"
+			deliver(s, op, Message{
+				Type:      "ADMIN_END",
+				Challenge: challenge,
+				Auth:      client.AdminToken("segredo", "ADMIN_END", 0, challenge),
+			})
+			return f.last(op)
+		}, "Desafio inválido"},
+		{"desafio de outro endereço", func(t *testing.T, s *UDPServer, f *fakeConn, _ *time.Time) Message {
+			deliver(s, other, adminEndSigned(t, s, f, op, "segredo"))
+			return f.last(other)
+		}, "Desafio inválido"},
+		{"desafio vencido", func(t *testing.T, s *UDPServer, f *fakeConn, clock *time.Time) Message {
+			msg := adminEndSigned(t, s, f, op, "segredo")
+			*clock = clock.Add(adminChallengeTTL + time.Second)
+			deliver(s, op, msg)
+			return f.last(op)
+		}, "Desafio inválido"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			s.SetClock(func() time.Time { return clock })
+			s.SetAdminSecret("segredo")
+			s.StartVoting(3600)
+
+			if got := tc.run(t, s, f, &clock); got.Type != "ERROR" || got.Message != tc.want {
+				t.Fatalf("resposta = %+v, esperava ERROR %q", got, tc.want)
+			}
+			if s.State() != VotingActive {
+				t.Fatalf("estado = %s, esperava %s", s.State(), VotingActive)
+			}
+		})
+	}
+}
+
+// Um ADMIN_END capturado não encerra de novo: nem repetido na mesma rodada
+// nem na votação seguinte, dentro da validade do desafio
+func TestAdminEndReplayRejected(t *testing.T) {
+	s, f := newFakeServer(t)
+	clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return clock })
+	s.SetAdminSecret("segredo")
+	op := testAddr(2)
+	s.StartVoting(3600)
+
+	captured := adminEndSigned(t, s, f, op, "segredo")
+	deliver(s, op, captured)
+	if got := f.last(op); got.Type != "ACK" {
+		t.Fatalf("ADMIN_END assinado = %+v", got)
+	}
+
+	// Mesma rodada, já encerrada: o desafio foi usado
+	deliver(s, op, captured)
+	if got := f.last(op); got.Type != "ERROR" || got.Message != "Desafio inválido" {
+		t.Fatalf("reenvio na mesma rodada = %+v", got)
+	}
+
+	// Nova votação: o reenvio não a encerra
+	if err := s.ResetVoting([]string{"X", "Y"}); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(3600)
+	clock = clock.Add(time.Second)
+	deliver(s, op, captured)
+	if got := f.last(op); got.Type != "ERROR" {
+		t.Fatalf("reenvio na votação seguinte = %+v", got)
+	}
+
+	// Token feito para a rodada anterior não vale na atual
+	deliver(s, op, Message{Type: "ADMIN_END"})
+	ch := f.last(op)
+	stale := Message{
+		Type:      "ADMIN_END",
+		Challenge: ch.Challenge,
+		Auth:      client.AdminToken("segredo", "ADMIN_END", ch.Round-1, ch.Challenge),
+	}
+	deliver(s, op, stale)
+	if got := f.last(op); got.Type != "ERROR" || got.Message != "Autenticação inválida" {
+		t.Fatalf("token de outra rodada = %+v", got)
+	}
+	if s.State() != VotingActive {
+		t.Fatalf("estado = %s, esperava %s", s.State(), VotingActive)
+	}
+
+	// O operador legítimo continua conseguindo encerrar
+	deliver(s, op, adminEndSigned(t, s, f, op, "segredo"))
+	if got := f.last(op); got.Type != "ACK" || s.State() != VotingEnded {
+		t.Fatalf("novo ADMIN_END = %+v (estado %s)", got, s.State())
+	}
+}
+
+// O cliente responde ao desafio sozinho (EndVoting), pela rede de verdade
+func TestClientEndVoting(t *testing.T) {
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetAdminSecret("segredo")
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start("127.0.0.1:0") }()
+	select {
+	case <-s.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	s.StartVoting(3600)
+
+	replies := make(chan client.Message, 8)
+	c, err := client.Dial(s.Addr().String(), "op", client.Options{OnMessage: func(m client.Message) {
+		if m.Type == "ACK" || m.Type == "ERROR" {
+			replies <- m
+		}
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.EndVoting("segredo"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-replies:
+		if m.Type != "ACK" || m.Message != "Votação encerrada" {
+			t.Fatalf("resposta = %+v", m)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("sem resposta ao EndVoting")
+	}
+	if s.State() != VotingEnded {
+		t.Fatalf("estado = %s, esperava %s", s.State(), VotingEnded)
+	}
+}
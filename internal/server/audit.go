@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Log de auditoria encadeado por hash
+// ----------------------------------------------------------
+
+// AuditRecord é uma linha do log de auditoria (um JSON por linha).
+// Cada registro guarda o hash do anterior, formando uma cadeia:
+// alterar qualquer registro invalida todos os hashes seguintes.
+type AuditRecord struct {
+	Seq      int       `json:"seq"`              // posição na cadeia (1, 2, 3...)
+	Time     time.Time `json:"time"`             // momento em que o voto foi aceito
+	ClientID string    `json:"client_id"`        // quem votou
+	Option   string    `json:"vote"`             // opção registrada
+	Round    int       `json:"round,omitempty"`  // segundo turno em que o voto foi dado (0 = votação original)
+	Weight   int64     `json:"weight,omitempty"` // peso do voto (ausente = 1)
+	PrevHash string    `json:"prev_hash"`        // hash do registro anterior ("" no primeiro)
+	Hash     string    `json:"hash"`             // sha256 deste registro
+}
+
+// computeHash calcula o hash do registro a partir dos seus campos e do hash anterior
+func (r AuditRecord) computeHash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%d|%s|%s|%s",
+		r.PrevHash, r.Seq, r.ClientID, r.Option, r.Time.UTC().Format(time.RFC3339Nano))
+	// A rodada só entra no hash quando existe, para logs antigos continuarem válidos
+	if r.Round > 0 {
+		fmt.Fprintf(h, "|%d", r.Round)
+	}
+	// Idem para o peso, gravado só quando diferente de 1
+	if r.Weight > 0 {
+		fmt.Fprintf(h, "|w%d", r.Weight)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// weight devolve o peso do voto registrado
+func (r AuditRecord) weight() int64 {
+	if r.Weight > 0 {
+		return r.Weight
+	}
+	return 1
+}
+
+// SetAuditLog define onde os registros de auditoria são gravados.
+// A cadeia de hashes é mantida mesmo sem destino configurado.
+func (s *UDPServer) SetAuditLog(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditLog = w
+}
+
+// appendAuditLocked encadeia um voto aceito; deve ser chamado com o mutex travado
+func (s *UDPServer) appendAuditLocked(id, option string, weight int64) {
+	s.auditSeq++
+	rec := AuditRecord{
+		Seq:      s.auditSeq,
+		Time:     s.now(),
+		ClientID: id,
+		Option:   option,
+		Round:    s.round,
+		PrevHash: s.chainHash,
+	}
+	if weight != 1 {
+		rec.Weight = weight
+	}
+	rec.Hash = rec.computeHash()
+	s.chainHash = rec.Hash
+
+	if s.auditLog == nil {
+		return
+	}
+	line, _ := json.Marshal(rec)
+	if _, err := s.auditLog.Write(append(line, '\n')); err != nil {
+		log.Println("[AUDIT] Erro ao gravar registro:", err)
+	}
+}
+
+// ReadAuditLog lê um log de auditoria no formato JSON por linha
+func ReadAuditLog(r io.Reader) ([]AuditRecord, error) {
+	var records []AuditRecord
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("linha %d: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, sc.Err()
+}
+
+// VerifyAuditChain recalcula a cadeia e devolve o hash final.
+// Retorna erro no primeiro registro adulterado, fora de ordem ou desencadeado.
+func VerifyAuditChain(records []AuditRecord) (string, error) {
+	prev := ""
+	for i, rec := range records {
+		if rec.Seq != i+1 {
+			return "", fmt.Errorf("registro %d: sequência esperada %d", rec.Seq, i+1)
+		}
+		if rec.PrevHash != prev {
+			return "", fmt.Errorf("registro %d: hash anterior não confere", rec.Seq)
+		}
+		if rec.computeHash() != rec.Hash {
+			return "", fmt.Errorf("registro %d: hash não confere (registro adulterado)", rec.Seq)
+		}
+		prev = rec.Hash
+	}
+	return prev, nil
+}
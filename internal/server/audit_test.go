@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// auditedPoll roda uma votação com troca de voto e log de auditoria e
+// devolve os registros e o selo do FINAL
+func auditedPoll(t *testing.T) ([]AuditRecord, string) {
+	t.Helper()
+	var audit bytes.Buffer
+	s, f := votingServer(t, true, "ana", "bia", "caio")
+	s.SetAuditLog(&audit)
+	vote(s, f, "ana", testAddr(1), "A")
+	vote(s, f, "bia", testAddr(2), "B")
+	vote(s, f, "caio", testAddr(3), "A")
+	vote(s, f, "bia", testAddr(2), "A") // troca: vale o último registro
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	final := f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+
+	records, err := ReadAuditLog(&audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records, final.Final.ChainHash
+}
+
+// O auditor recalcula a cadeia a partir do log, chega ao selo do FINAL e
+// refaz o placar
+func TestAuditChainMatchesSeal(t *testing.T) {
+	records, seal := auditedPoll(t)
+	if len(records) != 4 {
+		t.Fatalf("%d registros, esperava 4", len(records))
+	}
+	if seal == "" {
+		t.Fatal("FINAL sem selo")
+	}
+	hash, err := VerifyAuditChain(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != seal {
+		t.Fatalf("cadeia recalculada = %s, selo = %s", hash, seal)
+	}
+	if got := ReplayAudit(records); got["A"] != 3 || got["B"] != 0 {
+		t.Fatalf("apuração do log = %v, esperava A=3", got)
+	}
+}
+
+// Qualquer alteração em um único registro quebra a cadeia, mesmo quando o
+// adulterador recalcula o hash do registro alterado
+func TestAuditChainDetectsTampering(t *testing.T) {
+	cases := []struct {
+		name    string
+		tamper  func(r []AuditRecord) []AuditRecord
+		wantErr string
+	}{
+		{"opção trocada", func(r []AuditRecord) []AuditRecord {
+			r[1].Option = "A"
+			return r
+		}, "registro 2: hash não confere"},
+		{"opção trocada com hash refeito", func(r []AuditRecord) []AuditRecord {
+			r[1].Option = "A"
+			r[1].Hash = r[1].computeHash()
+			return r
+		}, "registro 3: hash anterior não confere"},
+		{"votante trocado", func(r []AuditRecord) []AuditRecord {
+			r[2].ClientID = "bia"
+			return r
+		}, "registro 3: hash não confere"},
+		{"peso inflado", func(r []AuditRecord) []AuditRecord {
+			r[0].Weight = 100
+			return r
+		}, "registro 1: hash não confere"},
+		{"registro removido", func(r []AuditRecord) []AuditRecord {
+			return append(r[:1], r[2:]...)
+		}, "registro 3: sequência esperada 2"},
+		{"registros invertidos", func(r []AuditRecord) []AuditRecord {
+			r[1], r[2] = r[2], r[1]
+			return r
+		}, "registro 3: sequência esperada 2"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, seal := auditedPoll(t)
+			records = tc.tamper(records)
+			hash, err := VerifyAuditChain(records)
+			if err == nil {
+				t.Fatalf("adulteração não detectada (hash %s, selo %s)", hash, seal)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("erro = %q, esperava %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	// O último registro refeito por inteiro fecha a cadeia, mas não o selo
+	records, seal := auditedPoll(t)
+	last := &records[len(records)-1]
+	last.Option = "B"
+	last.Hash = last.computeHash()
+	hash, err := VerifyAuditChain(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == seal {
+		t.Fatal("último registro adulterado confere com o selo")
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+)
+
+// ----------------------------------------------------------
+// Autenticação por cliente (segredo do servidor)
+// ----------------------------------------------------------
+//
+// Sem autenticação, qualquer um que conheça um ClientID pode votar em nome
+// dele. Com SetClientSecret, o ACK de registro leva um token (campo auth)
+// derivado do segredo e do ClientID por HMAC, e VOTE, DELEGATE,
+// UNREGISTER, MUTE e UNMUTE só são aceitos com esse token; os demais
+// recebem ERROR "Autenticação inválida". O token não precisa ser guardado:
+// o servidor o recalcula a cada pacote, inclusive depois de um handoff.
+
+// Tipos de mensagem que exigem o token com SetClientSecret
+var authRequired = map[string]bool{
+	"VOTE": true, "DELEGATE": true, "UNREGISTER": true, "MUTE": true, "UNMUTE": true,
+}
+
+// SetClientSecret liga a autenticação por cliente com o segredo dado.
+// Segredo vazio desliga.
+func (s *UDPServer) SetClientSecret(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientSecret = []byte(secret)
+	if secret == "" {
+		s.clientSecret = nil
+	}
+}
+
+// AuthFailures devolve quantos pacotes foram recusados por token inválido
+func (s *UDPServer) AuthFailures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authFailures
+}
+
+// authTokenLocked deriva o token do ClientID ("" sem segredo)
+func (s *UDPServer) authTokenLocked(id string) string {
+	if s.clientSecret == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.clientSecret)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// authorized confere o token dos pacotes que agem em nome do cliente,
+// respondendo ERROR aos recusados. Votos espelhados vêm do primário, já
+// autenticados (mirroredVoteLocked).
+func (s *UDPServer) authorized(msg Message, addr *net.UDPAddr) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clientSecret == nil || s.mirroredVoteLocked(msg, addr) || !authRequired[msg.Type] {
+		return true
+	}
+	if hmac.Equal([]byte(msg.Auth), []byte(s.authTokenLocked(msg.ClientID))) {
+		return true
+	}
+	s.authFailures++
+	if msg.Type == "VOTE" {
+		s.votesReceived++
+		s.metrics.Inc(MetricVotesReceived)
+		s.rejectVoteLocked(addr, msg.RequestID, "Autenticação inválida")
+		return false
+	}
+	s.send(addr, Message{Type: "ERROR", Message: "Autenticação inválida"})
+	return false
+}
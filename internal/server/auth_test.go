@@ -0,0 +1,139 @@
+package server
+
+import (
+	"net"
+	"testing"
+)
+
+func TestClientTokenVote(t *testing.T) {
+	cases := []struct {
+		name    string
+		auth    func(own, other Message) string // token do VOTE a partir dos ACKs de registro
+		wantAck bool
+	}{
+		{"token válido", func(own, _ Message) string { return own.Auth }, true},
+		{"sem token", func(_, _ Message) string { return "" }, false},
+		{"token errado", func(_, _ Message) string { return "00ff" }, false},
+		{"token de outro ID", func(_, other Message) string { return other.Auth }, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			s.SetClientSecret("segredo")
+			a, b := testAddr(1), testAddr(2)
+			ack := register(t, s, f, "ana", a)
+			if ack.Auth == "" {
+				t.Fatal("ACK de registro sem token")
+			}
+			other := register(t, s, f, "bia", b)
+			s.StartVoting(60)
+
+			deliver(s, a, Message{Type: "VOTE", ClientID: "ana", VoteOption: "A", Auth: tc.auth(ack, other), RequestID: "r1"})
+			got := f.last(a)
+			if tc.wantAck {
+				if got.Type != "ACK" || s.Results()["A"] != 1 {
+					t.Fatalf("voto com token válido = %+v (placar %v)", got, s.Results())
+				}
+				return
+			}
+			if got.Type != "ERROR" || got.Message != "Autenticação inválida" || got.RequestID != "r1" {
+				t.Fatalf("voto recusado = %+v, esperava ERROR Autenticação inválida com o RequestID", got)
+			}
+			if s.Results()["A"] != 0 || s.AuthFailures() != 1 {
+				t.Fatalf("placar = %v, falhas = %d", s.Results(), s.AuthFailures())
+			}
+		})
+	}
+}
+
+// UNREGISTER sem token não derruba o registro de outro cliente
+func TestClientTokenUnregister(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetClientSecret("segredo")
+	a := testAddr(1)
+	ack := register(t, s, f, "ana", a)
+
+	deliver(s, a, Message{Type: "UNREGISTER", ClientID: "ana"})
+	if got := f.last(a); got.Type != "ERROR" || got.Message != "Autenticação inválida" {
+		t.Fatalf("UNREGISTER sem token = %+v", got)
+	}
+	if clientCount(s) != 1 {
+		t.Fatal("UNREGISTER sem token removeu o cliente")
+	}
+	deliver(s, a, Message{Type: "UNREGISTER", ClientID: "ana", Auth: ack.Auth})
+	if got := f.last(a); got.Type != "ACK" || clientCount(s) != 0 {
+		t.Fatalf("UNREGISTER com token = %+v (%d clientes)", got, clientCount(s))
+	}
+}
+
+// Sem segredo, nada muda: nem token no ACK nem exigência no VOTE
+func TestClientTokenDisabled(t *testing.T) {
+	s, f := newFakeServer(t)
+	a := testAddr(1)
+	if ack := register(t, s, f, "ana", a); ack.Auth != "" {
+		t.Fatalf("ACK com token sem segredo configurado: %+v", ack)
+	}
+	s.StartVoting(60)
+	if got := vote(s, f, "ana", a, "A"); got.Type != "ACK" {
+		t.Fatalf("voto sem segredo = %+v", got)
+	}
+}
+
+// A marca Mirror não pula o token nem a fixação de origem fora do VOTE
+// vindo do primário: um pacote forjado com mirror=true, mesmo do endereço
+// da vítima ou do próprio primário, é descartado
+func TestSpoofedMirrorRejected(t *testing.T) {
+	primary := testAddr(9)
+	cases := []struct {
+		name string
+		from *net.UDPAddr
+		msg  Message
+	}{
+		{"UNREGISTER do endereço da vítima", testAddr(1), Message{Type: "UNREGISTER", ClientID: "ana", Mirror: true}},
+		{"UNREGISTER do primário", primary, Message{Type: "UNREGISTER", ClientID: "ana", Mirror: true}},
+		{"DELEGATE do endereço da vítima", testAddr(1), Message{Type: "DELEGATE", ClientID: "ana", Delegate: "bia", Mirror: true}},
+		{"DELEGATE do primário", primary, Message{Type: "DELEGATE", ClientID: "ana", Delegate: "bia", Mirror: true}},
+		{"MUTE do endereço da vítima", testAddr(1), Message{Type: "MUTE", ClientID: "ana", Mirror: true}},
+		{"VOTE de outra origem", testAddr(1), Message{Type: "VOTE", ClientID: "ana", VoteOption: "B", Mirror: true}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			s.SetClientSecret("segredo")
+			s.SetDelegatedVoting(true)
+			if err := s.SetMirrorSource(primary.String()); err != nil {
+				t.Fatal(err)
+			}
+			register(t, s, f, "ana", testAddr(1))
+			register(t, s, f, "bia", testAddr(2))
+			s.StartVoting(60)
+
+			deliver(s, tc.from, tc.msg)
+			s.mu.Lock()
+			_, registered := s.clients["ana"]
+			delegated := s.delegations["ana"]
+			muted := s.muted["ana"]
+			s.mu.Unlock()
+			if !registered || delegated != "" || muted {
+				t.Fatalf("pacote forjado aplicado: registrada=%v delegação=%q silenciada=%v", registered, delegated, muted)
+			}
+			wantTally(t, s, map[string]int64{"A": 0, "B": 0})
+		})
+	}
+}
+
+// O voto espelhado legítimo continua entrando sem token
+func TestMirroredVoteFromPrimary(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.SetClientSecret("segredo")
+	primary := testAddr(9)
+	if err := s.SetMirrorSource(primary.String()); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(60)
+	deliver(s, primary, Message{Type: "VOTE", ClientID: "ana", VoteOption: "A", Mirror: true})
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+	if _, received := s.MirroredVotes(); received != 1 {
+		t.Fatalf("%d votos espelhados recebidos, esperava 1", received)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build linux
+
+package server
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// batchSupported indica que a leitura em lote (recvmmsg) está disponível
+const batchSupported = true
+
+// readLoopBatch lê até n datagramas por syscall e despacha cada um
+// exatamente como o readLoop
+func (s *UDPServer) readLoopBatch(conn *net.UDPConn, reads *atomic.Int64, n int) {
+	// ipv4 e ipv6 compartilham o mesmo tipo Message; a família só muda
+	// como o socket é embrulhado
+	var r interface {
+		ReadBatch(ms []ipv4.Message, flags int) (int, error)
+	}
+	if a, ok := conn.LocalAddr().(*net.UDPAddr); ok && a.IP.To4() != nil {
+		r = ipv4.NewPacketConn(conn)
+	} else {
+		r = ipv6.NewPacketConn(conn)
+	}
+
+	ms := make([]ipv4.Message, n)
+	for i := range ms {
+		ms[i].Buffers = [][]byte{make([]byte, readBufferSize)}
+	}
+
+	for {
+		s.readGate.RLock()
+		count, err := r.ReadBatch(ms, 0)
+		if err != nil {
+			s.readGate.RUnlock()
+			if s.stopping() || s.readsStopped() || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		reads.Add(int64(count))
+
+		for _, m := range ms[:count] {
+			addr, ok := m.Addr.(*net.UDPAddr)
+			if !ok {
+				continue
+			}
+
+			// Cria uma cópia, pois os buffers são reutilizados na próxima leitura
+			data := make([]byte, m.N)
+			copy(data, m.Buffers[0][:m.N])
+			s.dispatchPacket(data, addr)
+		}
+		s.readGate.RUnlock()
+	}
+}
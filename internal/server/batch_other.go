@@ -0,0 +1,16 @@
+//go:build !linux
+
+package server
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// batchSupported indica que a leitura em lote não está disponível nesta plataforma
+const batchSupported = false
+
+// readLoopBatch usa a leitura simples fora do Linux
+func (s *UDPServer) readLoopBatch(conn *net.UDPConn, reads *atomic.Int64, n int) {
+	s.readLoop(conn, reads)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+// ----------------------------------------------------------
+// Orçamento de memória dos buffers retidos
+// ----------------------------------------------------------
+//
+// O histórico de broadcasts (reenvio sob RESYNC) e os snapshots oficiais
+// ficam em memória durante toda a execução. Cada buffer tem o próprio limite
+// em número de entradas, mas o tamanho de cada entrada cresce com as opções.
+// SetMemoryBudget limita a soma dos dois em bytes (tamanho do JSON de cada
+// entrada). Ao passar do orçamento, saem primeiro os broadcasts mais antigos
+// do histórico (um RESYNC fora dele recebe SNAPSHOT) e depois os snapshots
+// mais antigos; a entrada mais recente de cada buffer sempre fica.
+
+// SetMemoryBudget limita a memória do histórico de broadcasts e dos
+// snapshots a `bytes` (0 = sem limite, só os limites de entradas)
+func (s *UDPServer) SetMemoryBudget(bytes int) error {
+	if bytes < 0 {
+		return errors.New("orçamento de memória não pode ser negativo")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memoryBudget = bytes
+	s.enforceBudgetLocked()
+	return nil
+}
+
+// BufferBytes devolve o tamanho atual do histórico de broadcasts e dos
+// snapshots retidos
+func (s *UDPServer) BufferBytes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.historyBytes + s.snapshotBytes
+}
+
+// jsonSize estima a memória de uma entrada pelo tamanho do seu JSON
+func jsonSize(v any) int {
+	data, _ := json.Marshal(v)
+	return len(data)
+}
+
+// trimHistoryLocked descarta os n broadcasts mais antigos do histórico
+func (s *UDPServer) trimHistoryLocked(n int) {
+	for _, msg := range s.history[:n] {
+		s.historyBytes -= jsonSize(msg)
+	}
+	s.history = s.history[n:]
+}
+
+// trimSnapshotsLocked descarta os n snapshots mais antigos
+func (s *UDPServer) trimSnapshotsLocked(n int) {
+	for _, r := range s.snapshots[:n] {
+		s.snapshotBytes -= jsonSize(r)
+	}
+	s.snapshots = s.snapshots[n:]
+}
+
+// enforceBudgetLocked descarta entradas antigas até caber no orçamento
+// e atualiza o medidor de memória. Se só restarem as entradas mais
+// recentes, o uso fica acima do orçamento até elas serem substituídas.
+func (s *UDPServer) enforceBudgetLocked() {
+	evicted := 0
+	for s.memoryBudget > 0 && s.historyBytes+s.snapshotBytes > s.memoryBudget {
+		if len(s.history) > 1 {
+			s.trimHistoryLocked(1)
+		} else if len(s.snapshots) > 1 {
+			s.trimSnapshotsLocked(1)
+		} else {
+			break
+		}
+		evicted++
+		s.metrics.Inc(MetricBufferEvictions)
+	}
+
+	if evicted > 0 {
+		s.bufferEvictions += evicted
+		if !s.budgetWarned {
+			s.budgetWarned = true
+			log.Printf("[WARN] Orçamento de memória (%d bytes) atingido: descartando entradas antigas do histórico e dos snapshots", s.memoryBudget)
+		}
+	}
+	s.metrics.Gauge(MetricBufferBytes, float64(s.historyBytes+s.snapshotBytes))
+}
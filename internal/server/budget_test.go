@@ -0,0 +1,61 @@
+package server
+
+import "testing"
+
+// Histórico e snapshots enchidos muito além do orçamento: os mais antigos
+// saem, o uso fica dentro do limite e as entradas recentes continuam lá
+func TestMemoryBudgetEvictsOldest(t *testing.T) {
+	const budget = 4096
+	m := newFakeMetrics()
+	s, f := votingServer(t, false, "ana")
+	s.SetMetrics(m)
+	if err := s.SetMemoryBudget(budget); err != nil {
+		t.Fatal(err)
+	}
+
+	s.mu.Lock()
+	for i := 0; i < historySize; i++ {
+		s.broadcastUpdateLocked()
+	}
+	last := s.broadcastSeq
+	s.mu.Unlock()
+	for i := 0; i < defaultSnapshotRetention; i++ {
+		s.SnapshotNow()
+	}
+
+	if used := s.BufferBytes(); used > budget {
+		t.Fatalf("uso = %d bytes, orçamento %d", used, budget)
+	}
+	s.mu.Lock()
+	history, evicted := len(s.history), s.bufferEvictions
+	s.mu.Unlock()
+	if history == 0 || history == historySize || evicted == 0 {
+		t.Fatalf("histórico com %d broadcasts, %d descartes", history, evicted)
+	}
+	snaps := s.Snapshots()
+	if len(snaps) == 0 || snaps[len(snaps)-1].SnapshotSeq != defaultSnapshotRetention {
+		t.Fatalf("snapshot mais recente descartado: %d retidos", len(snaps))
+	}
+
+	// O broadcast mais recente ainda é reenviado sob RESYNC
+	a := testAddr(1)
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.SeqNum == last })
+	deliver(s, a, Message{Type: "RESYNC", ClientID: "ana", SeqNum: last - 1})
+	if got := f.last(a); got.Type != "RESYNC" || got.SeqNum != last {
+		t.Fatalf("RESYNC do último broadcast = %+v", got)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	gauges := m.gauges[MetricBufferBytes]
+	if m.counters[MetricBufferEvictions] != evicted || len(gauges) == 0 || gauges[len(gauges)-1] > budget {
+		t.Fatalf("métricas: %d descartes, medidor %v", m.counters[MetricBufferEvictions], gauges)
+	}
+}
+
+func TestMemoryBudgetNegative(t *testing.T) {
+	s, _ := newFakeServer(t)
+	if err := s.SetMemoryBudget(-1); err == nil {
+		t.Fatal("orçamento negativo aceito")
+	}
+}
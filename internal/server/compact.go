@@ -0,0 +1,56 @@
+package server
+
+import (
+	"log"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Liberação de memória após o encerramento
+// ----------------------------------------------------------
+//
+// Encerrada a votação e exportado o resultado, os votos da rodada só
+// servem para consultas que o resultado final já responde. Com
+// SetCompactOnEnd, eles são trocados por maps vazios logo depois do envio
+// do broadcast final, e GetResults passa a responder a partir do retrato
+// final. Os registros dos clientes ficam: a próxima rodada (ResetVoting) e
+// as reconexões continuam valendo sem novo REGISTER.
+
+// SetCompactOnEnd libera os votos da rodada após o encerramento, desde que
+// o resultado tenha sido exportado com sucesso (SetResultsFile)
+func (s *UDPServer) SetCompactOnEnd(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.compactOnEnd = enabled
+}
+
+// compactLocked descarta os votos da votação encerrada
+func (s *UDPServer) compactLocked() {
+	if !s.compactOnEnd || s.compacted || s.votingState != VotingEnded {
+		return
+	}
+	if !s.persisted {
+		log.Println("[INFO] Memória mantida: resultado final não foi exportado")
+		return
+	}
+
+	released := len(s.votes) + len(s.delegations) + len(s.delivery)
+	s.votes = make(map[string]string)
+	s.voteWeights = make(map[string]int64)
+	s.lastChange = make(map[string]time.Time)
+	s.delegations = make(map[string]string)
+	s.delivery = make(map[string]*clientDelivery)
+	for _, c := range s.clients {
+		c.ackedRequests = nil // RequestIDs da rodada encerrada
+	}
+
+	// RESYNC tardio ainda recupera o broadcast final; o resto vira SNAPSHOT
+	if n := len(s.history); n > 1 {
+		s.trimHistoryLocked(n - 1)
+		s.history = append([]Message(nil), s.history...)
+		s.enforceBudgetLocked()
+	}
+
+	s.compacted = true
+	log.Printf("[INFO] Memória da votação liberada (%d entradas da rodada)", released)
+}
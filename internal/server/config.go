@@ -0,0 +1,370 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Configuração do servidor via arquivo JSON
+// ----------------------------------------------------------
+
+// Config reúne tudo o que define uma execução do servidor, para que um
+// deploy possa ser reproduzido a partir de um único arquivo. Campos
+// omitidos no arquivo ficam com os valores de DefaultConfig.
+type Config struct {
+	Addr        string   `json:"addr"`                   // endereço UDP host:porta (ex.: ":9000", "[::1]:9000")
+	StreamAddr  string   `json:"stream_addr,omitempty"`  // stream TCP (vazio = desligado)
+	HTTPAddr    string   `json:"http_addr,omitempty"`    // HTTP com /events (vazio = desligado)
+	MetricsAddr string   `json:"metrics_addr,omitempty"` // HTTP só com /metrics dos contadores (vazio = desligado)
+	Options     []string `json:"options"`
+
+	StartDelay int `json:"start_delay_s"` // espera antes de abrir a votação
+	Duration   int `json:"duration_s"`    // duração da votação
+
+	// Voto que chega exatamente no prazo é recusado (padrão: aceito)
+	DeadlineExclusive bool `json:"deadline_exclusive,omitempty"`
+
+	// Agendamento por horário (substitui start_delay_s e duration_s)
+	OpenAt  *time.Time `json:"open_at,omitempty"`
+	CloseAt *time.Time `json:"close_at,omitempty"`
+
+	// Arquivos (abertos pelo cmd/server; vazio = desligado)
+	LogFile      string `json:"log_file,omitempty"`
+	AuditLog     string `json:"audit_log,omitempty"`
+	ResultsFile  string `json:"results_file,omitempty"`
+	StateFile    string `json:"state_file,omitempty"`       // estado regravado a cada voto; recarregado ao subir
+	BroadcastLog string `json:"broadcast_log,omitempty"`    // broadcasts enviados, JSON por linha
+	BroadcastKey string `json:"broadcast_key,omitempty"`    // chave HMAC dos placares (clientes usam -key)
+	ClientSecret string `json:"client_secret,omitempty"`    // segredo dos tokens por cliente (VOTE/DELEGATE/UNREGISTER/MUTE/UNMUTE)
+	AdminSecret  string `json:"admin_secret,omitempty"`     // segredo do operador para ADMIN_END (encerramento antecipado)
+	CompactOnEnd bool   `json:"compact_on_end,omitempty"`   // libera os votos da rodada após exportar o resultado
+	MemoryBudget int    `json:"memory_budget_kb,omitempty"` // limite do histórico de broadcasts + snapshots (0 = sem limite)
+
+	// Segundo turno automático entre as opções empatadas (0 = desligado)
+	Runoff          int `json:"runoff_s,omitempty"`          // duração de cada segundo turno
+	RunoffMaxRounds int `json:"runoff_max_rounds,omitempty"` // segundos turnos seguidos (0 = 3)
+
+	Quorum int `json:"quorum,omitempty"` // votos mínimos para o resultado valer (0 = sem quórum)
+
+	ReportLoad         bool          `json:"report_load"`
+	Heartbeat          int           `json:"heartbeat_s,omitempty"`    // intervalo dos heartbeats durante a votação (0 = desligado)
+	IdleTimeout        int           `json:"idle_timeout_s,omitempty"` // remove clientes sem pacotes por esse tempo (0 = nunca)
+	Metrics            bool          `json:"metrics,omitempty"`        // métricas em memória, em /metrics do http_addr
+	ServerStats        bool          `json:"server_stats,omitempty"`   // responde a SERVER_STATS
+	BatchRead          int           `json:"batch_read,omitempty"`
+	Workers            int           `json:"workers,omitempty"`    // workers que tratam os pacotes (0 = padrão, 64)
+	ReusePort          int           `json:"reuse_port,omitempty"` // sockets com SO_REUSEPORT (Linux; <2 = um só)
+	FragmentThreshold  int           `json:"fragment_threshold"`   // bytes (0 = não verifica)
+	HideLive           bool          `json:"hide_live,omitempty"`
+	MinVotesToDisplay  int           `json:"min_votes_to_display,omitempty"` // menos votos que isso vão para "Outros" na exibição
+	ObserverToken      string        `json:"observer_token,omitempty"`
+	RegistrationRate   int           `json:"registration_rate,omitempty"`
+	SourceRate         int           `json:"source_rate,omitempty"`         // pacotes por segundo por endereço (0 = sem limite)
+	RegistrationPolicy string        `json:"registration_policy,omitempty"` // "" (REGISTER obrigatório) | auto_on_vote
+	MaxClients         int           `json:"max_clients,omitempty"`         // 0 = sem limite
+	AnnounceJoins      bool          `json:"announce_joins,omitempty"`      // JOIN com o número de participantes a cada registro
+	MinClientVersion   int           `json:"min_client_version,omitempty"`  // versão de protocolo mínima no REGISTER (0 = todas)
+	RegisterDifficulty int           `json:"register_difficulty,omitempty"`
+	AllowedTypes       []string      `json:"allowed_types,omitempty"`
+	Decision           *DecisionRule `json:"decision,omitempty"`
+	TieBreak           string        `json:"tie_break,omitempty"` // "" | alphabetical | earliest
+	SnapshotDir        string        `json:"snapshot_dir,omitempty"`
+	SnapshotRetention  int           `json:"snapshot_retention,omitempty"`
+	DelegatedVoting    bool          `json:"delegated_voting,omitempty"`
+	AllowRevote        bool          `json:"allow_revote,omitempty"`          // troca de voto antes do prazo
+	CaseInsensitive    bool          `json:"case_insensitive,omitempty"`      // opção do voto sem caixa nem espaços nas pontas
+	WeightedVoting     bool          `json:"weighted_voting,omitempty"`       // aceita o peso do voto no REGISTER
+	MirrorTarget       string        `json:"mirror_target,omitempty"`         // secundário que recebe a cópia dos votos
+	MirrorSource       string        `json:"mirror_source,omitempty"`         // primário de quem aceitar votos espelhados
+	AckCoalesce        int           `json:"ack_coalesce_ms,omitempty"`       // janela de agrupamento dos ACKs de voto (0 = um por voto)
+	BroadcastInterval  int           `json:"broadcast_interval_ms,omitempty"` // no máximo um broadcast por intervalo (0 = um por voto)
+	MultiHome          string        `json:"multi_home,omitempty"`            // "" | reject_new | fence
+	MultiHomeWindow    int           `json:"multi_home_window_s,omitempty"`   // segundos sem pacotes até o endereço deixar de contar
+}
+
+// DefaultConfig devolve a configuração usada quando não há arquivo
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":9000",
+		StreamAddr:        ":9001",
+		Options:           []string{"A", "B", "C"},
+		StartDelay:        5,
+		Duration:          300,
+		LogFile:           "logs/server_udp.log",
+		AuditLog:          "logs/audit.jsonl",
+		ResultsFile:       "logs/results.json",
+		ReportLoad:        true,
+		SnapshotRetention: defaultSnapshotRetention,
+		Workers:           defaultWorkers,
+		FragmentThreshold: defaultFragmentThreshold,
+	}
+}
+
+// LoadConfig lê e valida um arquivo de configuração JSON.
+// Chaves desconhecidas são rejeitadas para que erros de digitação não passem.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate confere a coerência da configuração
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.Addr) == "" {
+		return errors.New("addr não pode ser vazio")
+	}
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("addr inválido %q (use host:porta, ex.: :9000 ou [::1]:9000): %v", c.Addr, err)
+	}
+	if err := validateOptions(c.Options); err != nil {
+		return err
+	}
+	if c.Duration <= 0 {
+		return errors.New("duration_s deve ser positivo")
+	}
+	if c.StartDelay < 0 {
+		return errors.New("start_delay_s não pode ser negativo")
+	}
+	if (c.OpenAt == nil) != (c.CloseAt == nil) {
+		return errors.New("open_at e close_at devem ser informados juntos")
+	}
+	if c.OpenAt != nil && !c.CloseAt.After(*c.OpenAt) {
+		return errors.New("close_at deve ser posterior a open_at")
+	}
+	if c.HideLive && c.ObserverToken == "" {
+		return errors.New("hide_live exige observer_token")
+	}
+	if c.IdleTimeout < 0 {
+		return errors.New("idle_timeout_s não pode ser negativo")
+	}
+	if c.Heartbeat < 0 {
+		return errors.New("heartbeat_s não pode ser negativo")
+	}
+	if c.MemoryBudget < 0 {
+		return errors.New("memory_budget_kb não pode ser negativo")
+	}
+	if c.Runoff < 0 || c.RunoffMaxRounds < 0 {
+		return errors.New("runoff_s e runoff_max_rounds não podem ser negativos")
+	}
+	if c.Quorum < 0 {
+		return errors.New("quorum não pode ser negativo")
+	}
+	if c.ReusePort < 0 {
+		return errors.New("reuse_port não pode ser negativo")
+	}
+	if c.MaxClients < 0 {
+		return errors.New("max_clients não pode ser negativo")
+	}
+	if c.MinClientVersion < 0 || c.MinClientVersion > ProtocolVersion {
+		return fmt.Errorf("min_client_version deve estar entre 0 e %d", ProtocolVersion)
+	}
+	if c.MinVotesToDisplay < 0 {
+		return errors.New("min_votes_to_display não pode ser negativo")
+	}
+	if c.AckCoalesce < 0 {
+		return errors.New("ack_coalesce_ms não pode ser negativo")
+	}
+	if c.BroadcastInterval < 0 {
+		return errors.New("broadcast_interval_ms não pode ser negativo")
+	}
+	if c.MultiHomeWindow < 0 {
+		return errors.New("multi_home_window_s não pode ser negativo")
+	}
+	if c.Workers < 0 {
+		return errors.New("workers não pode ser negativo")
+	}
+	if c.SourceRate < 0 {
+		return errors.New("source_rate não pode ser negativo")
+	}
+	if c.Decision != nil && !slices.Contains(c.Options, c.Decision.Option) {
+		return errors.New("regra de decisão: opção inexistente " + c.Decision.Option)
+	}
+	return nil
+}
+
+// NewUDPServerFromConfig valida a configuração e monta o servidor com ela.
+// Endereços, prazos e arquivos de log ficam a cargo de quem o executa.
+func NewUDPServerFromConfig(cfg Config) (*UDPServer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s, err := NewUDPServer(cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SetDecisionRule(cfg.Decision); err != nil {
+		return nil, err
+	}
+	if err := s.SetTieBreak(cfg.TieBreak); err != nil {
+		return nil, err
+	}
+	if err := s.SetRegistrationPolicy(cfg.RegistrationPolicy); err != nil {
+		return nil, err
+	}
+	s.SetMaxClients(cfg.MaxClients)
+	s.SetAnnounceJoins(cfg.AnnounceJoins)
+	if err := s.SetMinClientVersion(cfg.MinClientVersion); err != nil {
+		return nil, err
+	}
+	if err := s.SetMultiHomePolicy(cfg.MultiHome, time.Duration(cfg.MultiHomeWindow)*time.Second); err != nil {
+		return nil, err
+	}
+	s.SetDeadlineInclusive(!cfg.DeadlineExclusive)
+	s.SetReportLoad(cfg.ReportLoad)
+	s.SetHeartbeat(time.Duration(cfg.Heartbeat) * time.Second)
+	s.SetIdleTimeout(time.Duration(cfg.IdleTimeout) * time.Second)
+	if cfg.Metrics {
+		s.SetMetrics(NewMemoryMetrics())
+	}
+	s.SetServerStatsReply(cfg.ServerStats)
+	s.SetDelegatedVoting(cfg.DelegatedVoting)
+	s.SetAllowRevote(cfg.AllowRevote)
+	s.SetCaseInsensitiveOptions(cfg.CaseInsensitive)
+	s.SetWeightedVoting(cfg.WeightedVoting)
+	s.SetAckCoalesce(time.Duration(cfg.AckCoalesce) * time.Millisecond)
+	s.SetBroadcastInterval(time.Duration(cfg.BroadcastInterval) * time.Millisecond)
+	s.SetBatchRead(cfg.BatchRead)
+	s.SetWorkers(cfg.Workers)
+	s.SetReusePort(cfg.ReusePort)
+	s.SetFragmentThreshold(cfg.FragmentThreshold)
+	s.SetHideLiveResults(cfg.HideLive, cfg.ObserverToken)
+	s.SetMinVotesToDisplay(cfg.MinVotesToDisplay)
+	s.SetRegistrationRate(cfg.RegistrationRate)
+	s.SetSourceRate(cfg.SourceRate)
+	s.SetRegisterDifficulty(cfg.RegisterDifficulty)
+	s.SetAllowedTypes(cfg.AllowedTypes)
+	s.SetResultsFile(cfg.ResultsFile)
+	s.SetBroadcastKey(cfg.BroadcastKey)
+	s.SetClientSecret(cfg.ClientSecret)
+	s.SetAdminSecret(cfg.AdminSecret)
+	s.SetCompactOnEnd(cfg.CompactOnEnd)
+	if err := s.SetMemoryBudget(cfg.MemoryBudget * 1024); err != nil {
+		return nil, err
+	}
+	if err := s.SetRunoff(cfg.Runoff, cfg.RunoffMaxRounds); err != nil {
+		return nil, err
+	}
+	if err := s.SetQuorum(cfg.Quorum); err != nil {
+		return nil, err
+	}
+	s.SetSnapshotDir(cfg.SnapshotDir)
+	if cfg.SnapshotRetention > 0 {
+		s.SetSnapshotRetention(cfg.SnapshotRetention)
+	}
+	if err := s.SetMirrorTarget(cfg.MirrorTarget); err != nil {
+		return nil, fmt.Errorf("mirror_target: %v", err)
+	}
+	if err := s.SetMirrorSource(cfg.MirrorSource); err != nil {
+		return nil, fmt.Errorf("mirror_source: %v", err)
+	}
+
+	// Por último: uma votação gravada vencida é encerrada ao carregar, com
+	// o resultado exportado e as regras de apuração já configurados
+	if err := s.SetStateFile(cfg.StateFile); err != nil {
+		return nil, fmt.Errorf("state_file: %v", err)
+	}
+
+	s.mu.Lock()
+	s.config = cfg
+	s.mu.Unlock()
+	return s, nil
+}
+
+// Config devolve a configuração efetiva: a usada na construção, atualizada
+// com o que foi alterado depois pelos setters
+func (s *UDPServer) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.config
+	c.Options = append([]string(nil), s.options...)
+	c.DeadlineExclusive = s.deadlineExclusive
+	c.ReportLoad = s.reportLoad
+	c.Heartbeat = int(s.heartbeat / time.Second)
+	c.IdleTimeout = int(s.idleTimeout / time.Second)
+	_, c.Metrics = s.metrics.(*MemoryMetrics)
+	c.ServerStats = s.serverStatsReply
+	c.DelegatedVoting = s.delegatedVoting
+	c.AllowRevote = s.allowRevote
+	c.CaseInsensitive = s.caseInsensitive
+	c.WeightedVoting = s.weightedVoting
+	c.AckCoalesce = int(s.ackCoalesce / time.Millisecond)
+	c.BroadcastInterval = int(s.broadcastInterval / time.Millisecond)
+	c.BatchRead = s.batchSize
+	c.Workers = s.workers
+	c.ReusePort = s.reusePort
+	c.FragmentThreshold = s.fragmentThreshold
+	c.HideLive = s.hideLive
+	c.MinVotesToDisplay = s.minDisplay
+	c.ObserverToken = s.observerToken
+	c.SourceRate = s.sourceRate
+	c.RegistrationRate = 0
+	if s.regLimiter != nil {
+		c.RegistrationRate = int(s.regLimiter.rate)
+	}
+	c.RegisterDifficulty = s.powDifficulty
+	c.RegistrationPolicy = s.regPolicy
+	c.MaxClients = s.maxClients
+	c.AnnounceJoins = s.announceJoins
+	c.MinClientVersion = s.minClientVersion
+	c.AllowedTypes = nil
+	for t := range s.allowedTypes {
+		c.AllowedTypes = append(c.AllowedTypes, t)
+	}
+	sort.Strings(c.AllowedTypes)
+	c.Decision = nil
+	if s.decisionRule != nil {
+		r := *s.decisionRule
+		c.Decision = &r
+	}
+	c.TieBreak = s.tieBreak
+	c.MultiHome = s.multiHome
+	c.MultiHomeWindow = 0
+	if s.multiHome != MultiHomeOff {
+		c.MultiHomeWindow = int(s.multiHomeWindow / time.Second)
+	}
+	c.ResultsFile = s.resultsFile
+	c.StateFile = s.stateFile
+	c.BroadcastKey = string(s.broadcastKey)
+	c.ClientSecret = string(s.clientSecret)
+	c.AdminSecret = string(s.adminSecret)
+	c.CompactOnEnd = s.compactOnEnd
+	c.MemoryBudget = s.memoryBudget / 1024
+	c.Runoff, c.RunoffMaxRounds = s.runoffSec, 0
+	if s.runoffSec > 0 {
+		c.RunoffMaxRounds = s.runoffMax
+	}
+	c.Quorum = s.quorum
+	c.SnapshotDir = s.snapshotDir
+	c.SnapshotRetention = s.snapshotRetention
+	c.MirrorTarget, c.MirrorSource = "", ""
+	if s.mirrorTarget != nil {
+		c.MirrorTarget = s.mirrorTarget.String()
+	}
+	if s.mirrorSource != nil {
+		c.MirrorSource = s.mirrorSource.String()
+	}
+	return c
+}
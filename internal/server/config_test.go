@@ -0,0 +1,107 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Um arquivo com todas as opções de apuração monta um servidor cuja
+// configuração efetiva é a do arquivo
+func TestLoadConfigFromFile(t *testing.T) {
+	dir := t.TempDir()
+	body := `{
+		"addr": "127.0.0.1:7000",
+		"options": ["Sim", "Não", "Abstenção"],
+		"start_delay_s": 0,
+		"duration_s": 120,
+		"deadline_exclusive": true,
+		"results_file": "` + filepath.Join(dir, "results.json") + `",
+		"state_file": "` + filepath.Join(dir, "state.json") + `",
+		"broadcast_key": "chave",
+		"client_secret": "segredo",
+		"admin_secret": "operador",
+		"compact_on_end": true,
+		"memory_budget_kb": 512,
+		"runoff_s": 30,
+		"runoff_max_rounds": 2,
+		"quorum": 10,
+		"report_load": false,
+		"heartbeat_s": 5,
+		"idle_timeout_s": 60,
+		"metrics": true,
+		"server_stats": true,
+		"batch_read": 16,
+		"workers": 8,
+		"fragment_threshold": 1200,
+		"hide_live": true,
+		"observer_token": "olho",
+		"min_votes_to_display": 2,
+		"registration_rate": 50,
+		"source_rate": 100,
+		"registration_policy": "auto_on_vote",
+		"max_clients": 500,
+		"announce_joins": true,
+		"min_client_version": 1,
+		"register_difficulty": 4,
+		"allowed_types": ["VOTE", "REGISTER"],
+		"decision": {"option": "Sim", "threshold": 0.5},
+		"tie_break": "earliest",
+		"snapshot_dir": "` + filepath.Join(dir, "snap") + `",
+		"snapshot_retention": 3,
+		"delegated_voting": true,
+		"case_insensitive": true,
+		"weighted_voting": true,
+		"mirror_target": "127.0.0.1:7100",
+		"ack_coalesce_ms": 20,
+		"broadcast_interval_ms": 100,
+		"multi_home": "fence",
+		"multi_home_window_s": 10
+	}`
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewUDPServerFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	want := cfg
+	want.AllowedTypes = []string{"REGISTER", "VOTE"}
+	if got := s.Config(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("configuração efetiva difere do arquivo:\n got  %+v\n want %+v", got, want)
+	}
+
+	// Setter chamado depois da construção aparece no Config()
+	s.SetAllowRevote(true)
+	if !s.Config().AllowRevote {
+		t.Fatal("Config() não reflete SetAllowRevote")
+	}
+}
+
+func TestLoadConfigRejects(t *testing.T) {
+	cases := map[string]string{
+		"chave desconhecida":  `{"options": ["A", "B"], "duraton_s": 60}`,
+		"prazo inválido":      `{"options": ["A", "B"], "duration_s": 0}`,
+		"hide_live sem token": `{"options": ["A", "B"], "hide_live": true}`,
+		"decisão inexistente": `{"options": ["A", "B"], "decision": {"option": "C", "threshold": 0.5}}`,
+	}
+	for name, body := range cases {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil || !strings.HasPrefix(err.Error(), path) {
+			t.Errorf("%s: LoadConfig = %v, esperava erro citando o arquivo", name, err)
+		}
+	}
+}
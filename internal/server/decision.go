@@ -0,0 +1,65 @@
+package server
+
+import (
+	"errors"
+	"math"
+)
+
+// ----------------------------------------------------------
+// Regra de decisão (polls estilo referendo)
+// ----------------------------------------------------------
+
+// Valores de FinalResult.Decision
+const (
+	DecisionApproved = "approved"
+	DecisionRejected = "rejected"
+)
+
+// DecisionRule aprova o resultado quando a fatia de votos de Option
+// passa de Threshold (ex.: SIM > 60%).
+type DecisionRule struct {
+	Option    string  `json:"option"`              // opção avaliada
+	Threshold float64 `json:"threshold"`           // fração exigida, entre 0 e 1
+	Inclusive bool    `json:"inclusive,omitempty"` // true: atingir exatamente o limite já aprova
+}
+
+// SetDecisionRule define a regra de decisão anunciada no encerramento.
+// nil remove a regra.
+func (s *UDPServer) SetDecisionRule(rule *DecisionRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule != nil {
+		if _, ok := s.voteCounts[rule.Option]; !ok {
+			return errors.New("regra de decisão: opção inexistente " + rule.Option)
+		}
+		if rule.Threshold < 0 || rule.Threshold > 1 {
+			return errors.New("regra de decisão: limite deve estar entre 0 e 1")
+		}
+		r := *rule
+		rule = &r
+	}
+	s.decisionRule = rule
+	return nil
+}
+
+// decisionLocked aplica a regra ao placar atual ("" se não houver regra).
+// Sem votos não há maioria a verificar, então o resultado é rejeitado.
+func (s *UDPServer) decisionLocked() string {
+	rule := s.decisionRule
+	if rule == nil {
+		return ""
+	}
+
+	total := totalVotes(s.voteCounts)
+	if total == 0 {
+		return DecisionRejected
+	}
+
+	share := float64(s.voteCounts[rule.Option]) / float64(total)
+	atThreshold := math.Abs(share-rule.Threshold) < 1e-9
+	if (atThreshold && rule.Inclusive) || (!atThreshold && share > rule.Threshold) {
+		return DecisionApproved
+	}
+	return DecisionRejected
+}
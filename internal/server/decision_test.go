@@ -0,0 +1,73 @@
+package server
+
+import (
+	"strconv"
+	"testing"
+)
+
+// Limite de 60% para SIM em torno da fronteira, inclusivo e exclusivo, e
+// sem nenhum voto
+func TestDecisionRuleThreshold(t *testing.T) {
+	cases := []struct {
+		name      string
+		sim, nao  int
+		inclusive bool
+		want      string
+	}{
+		{"acima do limite", 4, 1, false, DecisionApproved},
+		{"abaixo do limite", 2, 3, true, DecisionRejected},
+		{"no limite, inclusivo", 3, 2, true, DecisionApproved},
+		{"no limite, exclusivo", 3, 2, false, DecisionRejected},
+		{"sem votos", 0, 0, true, DecisionRejected},
+		{"só SIM", 1, 0, false, DecisionApproved},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t, "SIM", "NAO")
+			if err := s.SetDecisionRule(&DecisionRule{Option: "SIM", Threshold: 0.6, Inclusive: tc.inclusive}); err != nil {
+				t.Fatal(err)
+			}
+			obs := testAddr(200)
+			register(t, s, f, "obs", obs)
+			s.StartVoting(60)
+			for i := 0; i < tc.sim+tc.nao; i++ {
+				id, a := "v"+strconv.Itoa(i), testAddr(i+1)
+				register(t, s, f, id, a)
+				option := "SIM"
+				if i >= tc.sim {
+					option = "NAO"
+				}
+				if got := vote(s, f, id, a, option); got.Type != "ACK" {
+					t.Fatalf("voto %d = %+v", i, got)
+				}
+			}
+			s.EndVotingNow()
+
+			final := f.waitFor(t, obs, func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+			if final.Final.Decision != tc.want || s.GetResults().Decision != tc.want {
+				t.Fatalf("decisão = %q (resultado %q), esperava %q",
+					final.Final.Decision, s.GetResults().Decision, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecisionRuleValidation(t *testing.T) {
+	s, _ := newFakeServer(t, "SIM", "NAO")
+	if err := s.SetDecisionRule(&DecisionRule{Option: "TALVEZ", Threshold: 0.5}); err == nil {
+		t.Fatal("regra com opção inexistente aceita")
+	}
+	if err := s.SetDecisionRule(&DecisionRule{Option: "SIM", Threshold: 1.5}); err == nil {
+		t.Fatal("regra com limite acima de 1 aceita")
+	}
+
+	// Sem regra, nenhuma decisão é anunciada
+	if err := s.SetDecisionRule(nil); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(60)
+	s.EndVotingNow()
+	if d := s.GetResults().Decision; d != "" {
+		t.Fatalf("decisão sem regra = %q", d)
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"log"
+	"net"
+)
+
+// ----------------------------------------------------------
+// Delegação de voto (voto por procuração)
+// ----------------------------------------------------------
+//
+// Com SetDelegatedVoting, um cliente registrado pode enviar DELEGATE
+// indicando outro cliente. Quando o delegado vota, o voto conta também
+// para quem delegou a ele, transitivamente (A → B → C: o voto de C vale
+// para B e para A). Quem vota diretamente antes do delegado mantém o
+// próprio voto. Delegações que nunca chegam a um voto viram abstenção.
+
+// SetDelegatedVoting habilita a mensagem DELEGATE
+func (s *UDPServer) SetDelegatedVoting(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delegatedVoting = enabled
+}
+
+// DelegatedVotes devolve quantos votos foram contados por delegação
+func (s *UDPServer) DelegatedVotes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delegatedCount
+}
+
+// delegate registra que `id` delega seu voto a `to`
+func (s *UDPServer) delegate(id, to string, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.delegatedVoting {
+		s.send(addr, Message{Type: "ERROR", Message: "Operação não permitida"})
+		return
+	}
+	c, ok := s.clients[id]
+	if !ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Registre-se primeiro"})
+		return
+	}
+	if c.addr.String() != addr.String() {
+		s.send(addr, Message{Type: "ERROR", Message: "Endereço não corresponde"})
+		return
+	}
+	if s.votingState == VotingEnded {
+		s.send(addr, Message{Type: "ERROR", Message: "Votação encerrada"})
+		return
+	}
+	if _, ok := s.votes[id]; ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Voto duplicado"})
+		return
+	}
+	if to == id {
+		s.send(addr, Message{Type: "ERROR", Message: "Não é possível delegar a si mesmo"})
+		return
+	}
+	if _, ok := s.clients[to]; !ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Delegado não registrado"})
+		return
+	}
+	if s.delegationCycleLocked(id, to) {
+		s.send(addr, Message{Type: "ERROR", Message: "Delegação circular"})
+		return
+	}
+
+	s.delegations[id] = to
+	log.Printf("[DELEGATE] %s delegou o voto a %s", id, to)
+	s.send(addr, Message{Type: "ACK", Message: "Voto delegado a " + to})
+
+	// O delegado (ou alguém adiante na cadeia) pode já ter votado
+	if s.applyDelegationsLocked() > 0 {
+		s.broadcastUpdateLocked()
+	}
+}
+
+// delegationCycleLocked informa se delegar de `id` para `to` fecharia um ciclo
+func (s *UDPServer) delegationCycleLocked(id, to string) bool {
+	for hop := 0; hop <= len(s.delegations); hop++ {
+		if to == id {
+			return true
+		}
+		next, ok := s.delegations[to]
+		if !ok {
+			return false
+		}
+		to = next
+	}
+	return true
+}
+
+// resolveDelegationLocked segue a cadeia de `id` até alguém que já votou
+func (s *UDPServer) resolveDelegationLocked(id string) (string, bool) {
+	to := s.delegations[id]
+	for hop := 0; hop <= len(s.delegations); hop++ {
+		if option, ok := s.votes[to]; ok {
+			return option, true
+		}
+		next, ok := s.delegations[to]
+		if !ok {
+			return "", false
+		}
+		to = next
+	}
+	return "", false
+}
+
+// applyDelegationsLocked conta o voto de quem delegou a alguém que já votou
+// e devolve quantos votos foram contados
+func (s *UDPServer) applyDelegationsLocked() int {
+	if s.votingState != VotingActive {
+		return 0
+	}
+
+	applied := 0
+	for id := range s.delegations {
+		if _, voted := s.votes[id]; voted {
+			continue
+		}
+		option, ok := s.resolveDelegationLocked(id)
+		if !ok || s.tallyFullLocked(s.clientWeightLocked(id)) {
+			continue
+		}
+		s.recordVoteLocked(id, option)
+		s.delegatedCount++
+		applied++
+		if c, ok := s.clients[id]; ok {
+			s.send(c.addr, Message{Type: "ACK", Message: "Voto delegado registrado"})
+		}
+	}
+	return applied
+}
+
+// pendingDelegationsLocked conta as delegações cuja cadeia não chegou a um voto
+func (s *UDPServer) pendingDelegationsLocked() int {
+	n := 0
+	for id := range s.delegations {
+		if _, voted := s.votes[id]; !voted {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"maps"
+	"testing"
+)
+
+func delegatingServer(t *testing.T, voters ...string) (*UDPServer, *fakeConn) {
+	t.Helper()
+	s, f := votingServer(t, false, voters...)
+	s.SetDelegatedVoting(true)
+	return s, f
+}
+
+// ana → bia → caio: o voto de caio vale para os três; duda, que delega a
+// caio depois do voto, é contada na hora
+func TestDelegationChain(t *testing.T) {
+	s, f := delegatingServer(t, "ana", "bia", "caio", "duda")
+	delegations := []struct{ from, to string }{{"ana", "bia"}, {"bia", "caio"}}
+	for i, d := range delegations {
+		from := testAddr(i + 1)
+		deliver(s, from, Message{Type: "DELEGATE", ClientID: d.from, Delegate: d.to})
+		if m := f.last(from); m.Type != "ACK" || m.Message != "Voto delegado a "+d.to {
+			t.Fatalf("DELEGATE %s → %s = %+v", d.from, d.to, m)
+		}
+	}
+	if got := s.Results(); !maps.Equal(got, map[string]int64{"A": 0, "B": 0}) {
+		t.Fatalf("delegação sem voto contou: %v", got)
+	}
+
+	vote(s, f, "caio", testAddr(3), "B")
+	if got := s.Results(); !maps.Equal(got, map[string]int64{"A": 0, "B": 3}) {
+		t.Fatalf("placar = %v, esperava B=3", got)
+	}
+	if m := f.last(testAddr(1)); m.Type != "ACK" || m.Message != "Voto delegado registrado" {
+		t.Fatalf("ana não foi avisada do voto delegado: %+v", m)
+	}
+
+	deliver(s, testAddr(4), Message{Type: "DELEGATE", ClientID: "duda", Delegate: "caio"})
+	if got := s.Results()["B"]; got != 4 {
+		t.Fatalf("B = %d após duda delegar a quem já votou, esperava 4", got)
+	}
+	if n := s.DelegatedVotes(); n != 3 {
+		t.Fatalf("DelegatedVotes = %d, esperava 3", n)
+	}
+}
+
+// Autodelegação, ciclo (direto ou pela cadeia) e delegado desconhecido são
+// recusados sem alterar o grafo
+func TestDelegationRejected(t *testing.T) {
+	s, f := delegatingServer(t, "ana", "bia", "caio")
+	deliver(s, testAddr(1), Message{Type: "DELEGATE", ClientID: "ana", Delegate: "bia"})
+	deliver(s, testAddr(2), Message{Type: "DELEGATE", ClientID: "bia", Delegate: "caio"})
+
+	cases := []struct {
+		name string
+		from int
+		msg  Message
+		want string
+	}{
+		{"a si mesmo", 3, Message{Type: "DELEGATE", ClientID: "caio", Delegate: "caio"}, "Não é possível delegar a si mesmo"},
+		{"ciclo direto", 2, Message{Type: "DELEGATE", ClientID: "bia", Delegate: "ana"}, "Delegação circular"},
+		{"ciclo pela cadeia", 3, Message{Type: "DELEGATE", ClientID: "caio", Delegate: "ana"}, "Delegação circular"},
+		{"delegado desconhecido", 3, Message{Type: "DELEGATE", ClientID: "caio", Delegate: "zeca"}, "Delegado não registrado"},
+	}
+	for _, tc := range cases {
+		deliver(s, testAddr(tc.from), tc.msg)
+		if m := f.last(testAddr(tc.from)); m.Type != "ERROR" || m.Message != tc.want {
+			t.Errorf("%s: resposta = %+v, esperava %q", tc.name, m, tc.want)
+		}
+	}
+
+	// caio continua livre para votar, e a cadeia segue valendo
+	vote(s, f, "caio", testAddr(3), "A")
+	if got := s.Results(); !maps.Equal(got, map[string]int64{"A": 3, "B": 0}) {
+		t.Fatalf("placar = %v, esperava A=3", got)
+	}
+}
+
+func TestDelegationDisabled(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia")
+	deliver(s, testAddr(1), Message{Type: "DELEGATE", ClientID: "ana", Delegate: "bia"})
+	if m := f.last(testAddr(1)); m.Type != "ERROR" || m.Message != "Operação não permitida" {
+		t.Fatalf("DELEGATE sem o modo = %+v", m)
+	}
+}
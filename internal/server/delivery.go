@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net"
+	"sort"
+)
+
+// ----------------------------------------------------------
+// Estimativa de perda de broadcasts observada pelo servidor
+// ----------------------------------------------------------
+//
+// O servidor não recebe confirmação de broadcasts, mas cada RESYNC revela
+// um buraco: o cliente viu até SeqNum e o próximo que chegou foi UpTo.
+// Somando esses buracos contra os broadcasts enviados a cada cliente,
+// temos uma visão da confiabilidade do UDP independente do cliente.
+
+// clientDelivery acumula os broadcasts enviados a um cliente e os que ele
+// relatou ter perdido
+type clientDelivery struct {
+	sent    int
+	missed  int
+	resyncs int
+}
+
+// ClientLoss é a estimativa de perda de um cliente
+type ClientLoss struct {
+	ClientID  string  `json:"client_id"`
+	Sent      int     `json:"sent"`       // broadcasts enviados ao cliente
+	Missed    int     `json:"missed"`     // perdidos, segundo os RESYNC recebidos
+	Resyncs   int     `json:"resyncs"`    // pedidos de RESYNC recebidos
+	LossRatio float64 `json:"loss_ratio"` // missed / sent
+}
+
+// ServerStats resume a entrega de broadcasts vista pelo servidor
+type ServerStats struct {
+	BroadcastsSent   int          `json:"broadcasts_sent"`   // soma dos envios a todos os clientes
+	BroadcastsMissed int          `json:"broadcasts_missed"` // soma das perdas relatadas
+	DeliveryRatio    float64      `json:"delivery_ratio"`    // 1 - missed / sent (1 sem envios)
+	Clients          []ClientLoss `json:"clients"`           // ordenados por ClientID
+}
+
+// ServerStats devolve a estimativa atual de entrega de broadcasts
+func (s *UDPServer) ServerStats() ServerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serverStatsLocked()
+}
+
+func (s *UDPServer) serverStatsLocked() ServerStats {
+	st := ServerStats{DeliveryRatio: 1, Clients: make([]ClientLoss, 0, len(s.delivery))}
+	for id, d := range s.delivery {
+		c := ClientLoss{ClientID: id, Sent: d.sent, Missed: d.missed, Resyncs: d.resyncs}
+		if d.sent > 0 {
+			c.LossRatio = float64(d.missed) / float64(d.sent)
+		}
+		st.Clients = append(st.Clients, c)
+		st.BroadcastsSent += d.sent
+		st.BroadcastsMissed += d.missed
+	}
+	if st.BroadcastsSent > 0 {
+		st.DeliveryRatio = 1 - float64(st.BroadcastsMissed)/float64(st.BroadcastsSent)
+	}
+	sort.Slice(st.Clients, func(i, j int) bool { return st.Clients[i].ClientID < st.Clients[j].ClientID })
+	return st
+}
+
+// deliveryLocked devolve (criando, se preciso) o acumulador do cliente
+func (s *UDPServer) deliveryLocked(id string) *clientDelivery {
+	d, ok := s.delivery[id]
+	if !ok {
+		d = &clientDelivery{}
+		s.delivery[id] = d
+	}
+	return d
+}
+
+// recordResyncLocked contabiliza o buraco relatado por um RESYNC: o cliente
+// viu até `last` e recebeu `upTo` em seguida (0 = não informado)
+func (s *UDPServer) recordResyncLocked(id string, last, upTo int) {
+	d := s.deliveryLocked(id)
+	d.resyncs++
+	if upTo <= last {
+		upTo = s.broadcastSeq + 1
+	}
+	missed := upTo - last - 1
+	// Não conta mais perdas do que envios ao cliente
+	if d.missed+missed > d.sent {
+		missed = d.sent - d.missed
+	}
+	if missed > 0 {
+		d.missed += missed
+	}
+}
+
+// SetServerStatsReply habilita a mensagem administrativa SERVER_STATS,
+// que devolve ServerStats a clientes registrados
+func (s *UDPServer) SetServerStatsReply(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serverStatsReply = enabled
+}
+
+// replyServerStats responde ao SERVER_STATS
+func (s *UDPServer) replyServerStats(id string, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.serverStatsReply {
+		s.send(addr, Message{Type: "ERROR", Message: "Operação não permitida"})
+		return
+	}
+	if _, ok := s.clients[id]; !ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Registre-se primeiro"})
+		return
+	}
+	st := s.serverStatsLocked()
+	s.send(addr, Message{Type: "SERVER_STATS", Stats: &st})
+}
@@ -0,0 +1,55 @@
+package server
+
+import "testing"
+
+// Broadcasts que ana relata ter perdido (via RESYNC) baixam a taxa de
+// entrega; bia, que não perdeu nada, fica com perda zero
+func TestServerStatsDeliveryRatio(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia")
+	s.SetServerStatsReply(true)
+	a, b := testAddr(1), testAddr(2)
+
+	vote(s, f, "ana", a, "A")
+	seen := f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts["A"] == 1 })
+	s.mu.Lock()
+	for i := 0; i < 4; i++ {
+		s.broadcastUpdateLocked()
+	}
+	last := s.broadcastSeq
+	s.mu.Unlock()
+	f.waitFor(t, b, func(m Message) bool { return m.Type == "BROADCAST" && m.SeqNum == last })
+
+	// ana recebeu o primeiro e o último dos quatro: dois buracos de 1
+	deliver(s, a, Message{Type: "RESYNC", ClientID: "ana", SeqNum: seen.SeqNum + 1, UpTo: seen.SeqNum + 3})
+	deliver(s, a, Message{Type: "RESYNC", ClientID: "ana", SeqNum: seen.SeqNum + 3, UpTo: seen.SeqNum + 5})
+
+	st := s.ServerStats()
+	if len(st.Clients) != 2 || st.Clients[0].ClientID != "ana" || st.Clients[1].ClientID != "bia" {
+		t.Fatalf("clientes = %+v", st.Clients)
+	}
+	ana, bia := st.Clients[0], st.Clients[1]
+	if ana.Sent < 5 || ana.Missed != 2 || ana.Resyncs != 2 {
+		t.Fatalf("ana = %+v, esperava 2 perdidos em 2 RESYNC", ana)
+	}
+	if bia.Missed != 0 || bia.LossRatio != 0 {
+		t.Fatalf("bia = %+v, esperava perda zero", bia)
+	}
+	want := 1 - 2/float64(ana.Sent+bia.Sent)
+	if st.BroadcastsMissed != 2 || st.DeliveryRatio != want {
+		t.Fatalf("entrega = %v (perdidos %d), esperava %v", st.DeliveryRatio, st.BroadcastsMissed, want)
+	}
+
+	// A mesma visão chega pelo SERVER_STATS
+	deliver(s, b, Message{Type: "SERVER_STATS", ClientID: "bia"})
+	if m := f.last(b); m.Type != "SERVER_STATS" || m.Stats == nil || m.Stats.DeliveryRatio != want {
+		t.Fatalf("SERVER_STATS = %+v", m)
+	}
+}
+
+func TestServerStatsReplyDisabled(t *testing.T) {
+	s, f := votingServer(t, false, "ana")
+	deliver(s, testAddr(1), Message{Type: "SERVER_STATS", ClientID: "ana"})
+	if m := f.last(testAddr(1)); m.Type != "ERROR" || m.Message != "Operação não permitida" {
+		t.Fatalf("SERVER_STATS desligado = %+v", m)
+	}
+}
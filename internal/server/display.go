@@ -0,0 +1,71 @@
+package server
+
+// ----------------------------------------------------------
+// Opções com poucos votos agrupadas na exibição
+// ----------------------------------------------------------
+//
+// Em votações com sugestões livres, opções com pouquíssimos votos viram
+// ruído no placar público. Com SetMinVotesToDisplay(k), as opções com menos
+// de k votos são somadas em "Outros" no que é mostrado: broadcasts, stream,
+// RESYNC/SNAPSHOT, /events, a tabela final do log e a lista ordenada
+// ("results") dos arquivos exportados. A contagem oficial não muda: o log
+// de auditoria, GetResults, o vencedor e o vote_counts dos arquivos
+// exportados (conferido pelo cmd/verify) continuam com todas as opções.
+
+// Nome da linha que soma as opções abaixo do mínimo de exibição
+const OthersOption = "Outros"
+
+// SetMinVotesToDisplay agrupa em "Outros" as opções com menos de k votos
+// na exibição (0 = mostra todas)
+func (s *UDPServer) SetMinVotesToDisplay(k int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minDisplay = k
+}
+
+// exportLocked prepara r para gravação: a lista ordenada segue a exibição,
+// vote_counts mantém a contagem oficial
+func (s *UDPServer) exportLocked(r Results) Results {
+	_, r.Ordered = s.displayLocked(r.VoteCounts)
+	return r
+}
+
+// displayLocked devolve as contagens como devem ser mostradas: um map
+// novo e a mesma contagem na ordem declarada, com "Outros" ao final
+func (s *UDPServer) displayLocked(counts map[string]int64) (map[string]int64, []OptionCount) {
+	shown := make(map[string]int64, len(counts))
+	if s.minDisplay <= 0 {
+		for op, n := range counts {
+			shown[op] = n
+		}
+		return shown, OrderedResults(s.options, shown)
+	}
+
+	var ordered []OptionCount
+	others, grouped := int64(0), false
+	for _, op := range OrderedResults(s.options, counts) {
+		if op.Votes < int64(s.minDisplay) && op.Option != OthersOption {
+			others += op.Votes
+			grouped = true
+			continue
+		}
+		shown[op.Option] = op.Votes
+		ordered = append(ordered, op)
+	}
+	if !grouped || others == 0 {
+		return shown, ordered
+	}
+
+	// Uma opção declarada chamada "Outros" recebe a soma
+	if _, ok := shown[OthersOption]; ok {
+		shown[OthersOption] += others
+		for i := range ordered {
+			if ordered[i].Option == OthersOption {
+				ordered[i].Votes += others
+			}
+		}
+		return shown, ordered
+	}
+	shown[OthersOption] = others
+	return shown, append(ordered, OptionCount{Option: OthersOption, Votes: others})
+}
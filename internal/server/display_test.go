@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Com mínimo de 2 votos, C e D (1 voto cada) somam "Outros" no broadcast
+// e na lista exportada; a auditoria, GetResults e o vote_counts exportado
+// mantêm todas as opções
+func TestMinVotesToDisplay(t *testing.T) {
+	s, f := newFakeServer(t, "A", "B", "C", "D")
+	var audit bytes.Buffer
+	s.SetAuditLog(&audit)
+	s.SetMinVotesToDisplay(2)
+	path := filepath.Join(t.TempDir(), "results.json")
+	s.SetResultsFile(path)
+
+	ballots := []string{"A", "A", "A", "B", "B", "C", "D"}
+	for i := range ballots {
+		register(t, s, f, fmt.Sprintf("v%d", i), testAddr(i+1))
+	}
+	s.StartVoting(3600)
+	for i, option := range ballots {
+		vote(s, f, fmt.Sprintf("v%d", i), testAddr(i+1), option)
+	}
+
+	full := map[string]int64{"A": 3, "B": 2, "C": 1, "D": 1}
+	shown := map[string]int64{"A": 3, "B": 2, OthersOption: 2}
+	m := f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts[OthersOption] == 2 })
+	if !maps.Equal(m.VoteCounts, shown) {
+		t.Fatalf("broadcast = %v, esperava %v", m.VoteCounts, shown)
+	}
+	if got := s.GetResults().VoteCounts; !maps.Equal(got, full) {
+		t.Fatalf("GetResults = %v, esperava a contagem oficial %v", got, full)
+	}
+
+	records, err := ReadAuditLog(&audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audited []string
+	for _, r := range records {
+		audited = append(audited, r.Option)
+	}
+	if !slices.Equal(audited, ballots) {
+		t.Fatalf("auditoria = %v, esperava %v", audited, ballots)
+	}
+
+	s.EndVotingNow()
+	r, err := ReadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(r.VoteCounts, full) || r.Winner != "A" {
+		t.Fatalf("vote_counts exportado = %v (vencedor %q), esperava %v", r.VoteCounts, r.Winner, full)
+	}
+	want := []OptionCount{{"A", 3}, {"B", 2}, {OthersOption, 2}}
+	if !slices.Equal(r.Ordered, want) {
+		t.Fatalf("results exportado = %v, esperava %v", r.Ordered, want)
+	}
+}
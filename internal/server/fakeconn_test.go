@@ -0,0 +1,258 @@
+package server
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Conexão em memória para os testes dos handlers
+// ----------------------------------------------------------
+//
+// fakeConn satisfaz packetConn sem abrir socket: os datagramas injetados
+// saem no ReadFromUDP (para exercitar o readLoop) e cada envio do servidor
+// é decodificado e guardado por destinatário. deliver entrega um pacote
+// direto ao handlePacket, na goroutine do teste, para as asserções não
+// dependerem do escalonamento dos workers.
+
+// Prazo das esperas por mensagens assíncronas (broadcasts, timers)
+const waitTimeout = 2 * time.Second
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard) // o servidor loga cada pacote
+	}
+	os.Exit(m.Run())
+}
+
+type datagram struct {
+	data []byte
+	addr *net.UDPAddr
+}
+
+type fakeConn struct {
+	in        chan datagram
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu   sync.Mutex
+	sent map[string][]Message // key = endereço de destino
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		in:     make(chan datagram, 64),
+		closed: make(chan struct{}),
+		sent:   make(map[string][]Message),
+	}
+}
+
+func (f *fakeConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	select {
+	case d := <-f.in:
+		return copy(b, d.data), d.addr, nil
+	case <-f.closed:
+		return 0, nil, net.ErrClosed
+	}
+}
+
+func (f *fakeConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	var msg Message
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent[addr.String()] = append(f.sent[addr.String()], msg)
+	return len(b), nil
+}
+
+func (f *fakeConn) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9000}
+}
+
+func (f *fakeConn) Close() error {
+	f.closeOnce.Do(func() { close(f.closed) })
+	return nil
+}
+
+// inject enfileira um datagrama para o próximo ReadFromUDP
+func (f *fakeConn) inject(addr *net.UDPAddr, msg Message) {
+	data, _ := json.Marshal(msg)
+	f.in <- datagram{data: data, addr: addr}
+}
+
+// to devolve uma cópia das mensagens enviadas a addr
+func (f *fakeConn) to(addr *net.UDPAddr) []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent[addr.String()]...)
+}
+
+// last devolve a última mensagem enviada a addr (zero se nenhuma)
+func (f *fakeConn) last(addr *net.UDPAddr) Message {
+	msgs := f.to(addr)
+	if len(msgs) == 0 {
+		return Message{}
+	}
+	return msgs[len(msgs)-1]
+}
+
+// ofType filtra as mensagens enviadas a addr pelo tipo
+func (f *fakeConn) ofType(addr *net.UDPAddr, t string) []Message {
+	var out []Message
+	for _, m := range f.to(addr) {
+		if m.Type == t {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// waitFor espera até uma mensagem enviada a addr satisfazer match
+func (f *fakeConn) waitFor(t *testing.T, addr *net.UDPAddr, match func(Message) bool) Message {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		for _, m := range f.to(addr) {
+			if match(m) {
+				return m
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("nenhuma mensagem esperada para %s; recebidas: %+v", addr, f.to(addr))
+	return Message{}
+}
+
+// newFakeServer cria um servidor com as opções dadas ligado a uma fakeConn.
+// O Stop roda no fim do teste (antes dele, a fila de broadcast é esvaziada).
+func newFakeServer(t *testing.T, options ...string) (*UDPServer, *fakeConn) {
+	t.Helper()
+	if len(options) == 0 {
+		options = []string{"A", "B"}
+	}
+	s, err := NewUDPServer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFakeConn()
+	s.mu.Lock()
+	s.conn = f
+	s.mu.Unlock()
+	t.Cleanup(func() { stopFake(s, f) })
+	return s, f
+}
+
+// startUDP sobe s num socket de verdade em 127.0.0.1, numa porta livre
+// (veja s.Addr), para os testes com o pkg/client
+func startUDP(t testing.TB, s *UDPServer) {
+	t.Helper()
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start("127.0.0.1:0") }()
+	select {
+	case <-s.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+}
+
+// serveFake liga o readLoop e os workers à fakeConn, como o Start faz com
+// o socket; os pacotes passam a entrar por f.inject
+func serveFake(s *UDPServer, f *fakeConn) {
+	s.mu.Lock()
+	s.packets = make(chan packet, packetQueueSize)
+	for i := 0; i < s.workers; i++ {
+		go s.packetWorker(s.packets)
+	}
+	s.mu.Unlock()
+	go s.readLoop(f, new(atomic.Int64))
+}
+
+// stopFake encerra o servidor: fechar a fakeConn solta o readLoop, e o Stop
+// só retorna depois de enviar os broadcasts que estavam na fila
+func stopFake(s *UDPServer, f *fakeConn) {
+	f.Close()
+	s.Stop()
+}
+
+// testAddr devolve um endereço de cliente distinto para cada n
+func testAddr(n int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4(10, 0, 0, byte(n)), Port: 40000 + n}
+}
+
+// deliver entrega msg ao servidor como se tivesse chegado de addr
+func deliver(s *UDPServer, addr *net.UDPAddr, msg Message) {
+	data, _ := json.Marshal(msg)
+	s.handlePacket(data, addr)
+}
+
+// register registra id a partir de addr e devolve o ACK de registro
+func register(t *testing.T, s *UDPServer, f *fakeConn, id string, addr *net.UDPAddr) Message {
+	t.Helper()
+	deliver(s, addr, Message{Type: "REGISTER", ClientID: id})
+	ack := f.last(addr)
+	if ack.Type != "ACK" {
+		t.Fatalf("REGISTER de %s: esperava ACK, veio %+v", id, ack)
+	}
+	return ack
+}
+
+// vote envia um VOTE e devolve a resposta direta (ACK ou ERROR)
+func vote(s *UDPServer, f *fakeConn, id string, addr *net.UDPAddr, option string) Message {
+	deliver(s, addr, Message{Type: "VOTE", ClientID: id, VoteOption: option})
+	return f.last(addr)
+}
+
+// ----------------------------------------------------------
+// Testes da própria fakeConn
+// ----------------------------------------------------------
+
+func TestFakeConnHandlerVote(t *testing.T) {
+	s, f := newFakeServer(t)
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+	s.StartVoting(60)
+
+	ack := vote(s, f, "ana", a, "A")
+	if ack.Type != "ACK" || ack.Message != "Voto registrado" || ack.RecordedOption != "A" {
+		t.Fatalf("ACK do voto = %+v", ack)
+	}
+	if got := vote(s, f, "ana", a, "B"); got.Type != "ERROR" || got.Message != "Voto duplicado" {
+		t.Fatalf("segundo voto = %+v, esperava ERROR Voto duplicado", got)
+	}
+	if got := s.Results(); got["A"] != 1 || got["B"] != 0 {
+		t.Fatalf("placar = %v, esperava A=1 B=0", got)
+	}
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts["A"] == 1 })
+}
+
+func TestFakeConnReadLoop(t *testing.T) {
+	s, f := newFakeServer(t)
+	serveFake(s, f)
+	a := testAddr(1)
+
+	f.inject(a, Message{Type: "REGISTER", ClientID: "ana"})
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "ACK" })
+	s.StartVoting(60)
+	f.inject(a, Message{Type: "VOTE", ClientID: "ana", VoteOption: "B", RequestID: "r1"})
+	ack := f.waitFor(t, a, func(m Message) bool { return m.Type == "ACK" && m.RequestID == "r1" })
+	if ack.RecordedOption != "B" {
+		t.Fatalf("ACK do voto = %+v", ack)
+	}
+
+	stopFake(s, f)
+	if got := s.Results()["B"]; got != 1 {
+		t.Fatalf("votos em B = %d, esperava 1", got)
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Reinício sem queda: passagem do socket e do estado
+// ----------------------------------------------------------
+//
+// Handoff inicia a nova versão do binário entregando a ela o socket UDP já
+// aberto (mesmo protocolo LISTEN_FDS da ativação pelo systemd) e um retrato
+// do estado da votação: clientes registrados, votos, contagem, prazo e a
+// cadeia de auditoria. Enquanto o retrato é tirado, este processo para de
+// ler; os datagramas que chegam nesse meio-tempo esperam na fila do socket e
+// são lidos pelo novo processo. O histórico de broadcasts não passa: RESYNC
+// de um seq anterior à troca recebe SNAPSHOT.
+
+// HandoffState é o estado da votação passado ao novo processo
+type HandoffState struct {
+	Options     []string             `json:"options"`
+	State       VotingState          `json:"state"`
+	Deadline    time.Time            `json:"deadline,omitempty"`
+	OpenedAt    time.Time            `json:"opened_at,omitempty"` // abertura da votação em andamento
+	PausedAt    time.Time            `json:"paused_at,omitempty"` // início da pausa (estado PAUSED)
+	Round       int                  `json:"round,omitempty"`
+	PollRound   int                  `json:"poll_round,omitempty"` // rodada em que a votação atual começou
+	Clients     map[string]string    `json:"clients"`              // ClientID → endereço
+	Votes       map[string]string    `json:"votes"`
+	VoteCounts  map[string]int64     `json:"vote_counts"`
+	LastChange  map[string]time.Time `json:"last_change,omitempty"`
+	Muted       []string             `json:"muted,omitempty"`
+	Observers   []string             `json:"observers,omitempty"`
+	Delegations map[string]string    `json:"delegations,omitempty"`
+	Weights     map[string]int64     `json:"weights,omitempty"`      // peso dos clientes registrados (≠ 1)
+	VoteWeights map[string]int64     `json:"vote_weights,omitempty"` // peso dos votos contados (≠ 1)
+
+	BroadcastSeq int      `json:"broadcast_seq"`
+	AuditSeq     int      `json:"audit_seq"`
+	ChainHash    string   `json:"chain_hash,omitempty"`
+	Final        *Results `json:"final,omitempty"`
+}
+
+// handoffStateLocked tira o retrato do estado a passar adiante
+func (s *UDPServer) handoffStateLocked() HandoffState {
+	st := HandoffState{
+		Options:      append([]string(nil), s.options...),
+		State:        s.votingState,
+		Round:        s.round,
+		PollRound:    s.pollRound,
+		Clients:      make(map[string]string, len(s.clients)),
+		Votes:        make(map[string]string, len(s.votes)),
+		VoteCounts:   make(map[string]int64, len(s.voteCounts)),
+		LastChange:   make(map[string]time.Time, len(s.lastChange)),
+		Delegations:  make(map[string]string, len(s.delegations)),
+		Weights:      make(map[string]int64),
+		VoteWeights:  make(map[string]int64, len(s.voteWeights)),
+		BroadcastSeq: s.broadcastSeq,
+		AuditSeq:     s.auditSeq,
+		ChainHash:    s.chainHash,
+	}
+	if s.votingState != VotingNotStarted {
+		st.Deadline = s.votingDeadline
+		st.OpenedAt = s.votingOpened
+	}
+	if s.votingState == VotingPaused {
+		st.PausedAt = s.pausedAt
+	}
+	for id, c := range s.clients {
+		st.Clients[id] = c.addr.String()
+		if c.weight > 0 {
+			st.Weights[id] = c.weight
+		}
+	}
+	for id, w := range s.voteWeights {
+		st.VoteWeights[id] = w
+	}
+	for id, op := range s.votes {
+		st.Votes[id] = op
+	}
+	for op, n := range s.voteCounts {
+		st.VoteCounts[op] = n
+	}
+	for op, t := range s.lastChange {
+		st.LastChange[op] = t
+	}
+	for id, to := range s.delegations {
+		st.Delegations[id] = to
+	}
+	for id := range s.muted {
+		st.Muted = append(st.Muted, id)
+	}
+	for id := range s.observers {
+		st.Observers = append(st.Observers, id)
+	}
+	if s.final != nil {
+		f := s.final.clone()
+		st.Final = &f
+	}
+	return st
+}
+
+// RestoreHandoff aplica o estado recebido do processo anterior. Deve ser
+// chamado antes do Start; uma votação ativa volta com o prazo original.
+func (s *UDPServer) RestoreHandoff(st HandoffState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.restoreLocked(st); err != nil {
+		return err
+	}
+	log.Printf("[HANDOFF] Estado recebido: %d clientes, %d votos, votação %s",
+		len(s.clients), len(s.votes), s.votingState)
+	s.resumeVotingLocked()
+	return nil
+}
+
+// restoreLocked substitui o estado da votação por st (handoff ou arquivo
+// de estado)
+func (s *UDPServer) restoreLocked(st HandoffState) error {
+	if len(st.Options) < 2 {
+		return errors.New("estado recebido sem opções de voto")
+	}
+	now := s.now()
+	clients := make(map[string]*clientInfo, len(st.Clients))
+	for id, a := range st.Clients {
+		addr, err := net.ResolveUDPAddr("udp", a)
+		if err != nil {
+			return errors.New("endereço inválido no estado recebido: " + a)
+		}
+		clients[id] = newClientInfo(addr, now)
+		clients[id].weight = st.Weights[id]
+	}
+
+	s.options = st.Options
+	s.votingState = st.State
+	s.votingDeadline = st.Deadline
+	s.votingOpened = st.OpenedAt
+	s.pausedAt = st.PausedAt
+	s.round = st.Round
+	s.pollRound = st.PollRound
+	s.clients = clients
+	s.votes = st.Votes
+	s.voteCounts = st.VoteCounts
+	s.lastChange = st.LastChange
+	s.delegations = st.Delegations
+	s.muted = make(map[string]bool, len(st.Muted))
+	for _, id := range st.Muted {
+		s.muted[id] = true
+	}
+	s.observers = make(map[string]bool, len(st.Observers))
+	for _, id := range st.Observers {
+		s.observers[id] = true
+	}
+	if s.votes == nil {
+		s.votes = make(map[string]string)
+	}
+	if s.delegations == nil {
+		s.delegations = make(map[string]string)
+	}
+	s.voteWeights = st.VoteWeights
+	if s.voteWeights == nil {
+		s.voteWeights = make(map[string]int64)
+	}
+	if s.voteCounts == nil {
+		s.voteCounts = make(map[string]int64)
+	}
+	if s.lastChange == nil {
+		s.lastChange = make(map[string]time.Time)
+	}
+	s.broadcastSeq = st.BroadcastSeq
+	s.auditSeq = st.AuditSeq
+	s.chainHash = st.ChainHash
+	s.final = st.Final
+	s.persisted = st.Final != nil
+	s.metrics.Gauge(MetricClients, float64(len(s.clients)))
+	return nil
+}
+
+// resumeVotingLocked agenda o encerramento da votação restaurada no prazo
+// original; se o prazo já passou, encerra na hora
+func (s *UDPServer) resumeVotingLocked() {
+	if s.votingState != VotingActive {
+		return
+	}
+	if s.deadlinePassedLocked(s.now()) {
+		s.endVotingLocked()
+		return
+	}
+	s.scheduleEndLocked(s.votingDeadline.Sub(s.now()))
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package server
+
+import (
+	"errors"
+	"os"
+)
+
+// Handoff: a passagem de descritores para outro processo só existe em sistemas Unix
+func (s *UDPServer) Handoff(argv []string) (*os.Process, error) {
+	return nil, errors.New("handoff indisponível nesta plataforma")
+}
+
+// InheritedHandoff: sem handoff fora de sistemas Unix
+func InheritedHandoff() (*HandoffState, error) {
+	return nil, nil
+}
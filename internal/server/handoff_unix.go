@@ -0,0 +1,112 @@
+//go:build unix
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Variável que indica ao novo processo o descritor com o estado (o socket
+// vem antes, no descritor 3, pelo LISTEN_FDS)
+const envHandoff = "UDPVOTE_HANDOFF"
+
+// Handoff inicia argv (normalmente o novo binário com os mesmos argumentos)
+// entregando o socket UDP e o estado da votação, e devolve o processo
+// iniciado. Depois de um Handoff bem-sucedido este servidor não lê mais
+// pacotes (o Stop continua funcionando) e o processo deve sair; em caso de
+// erro, ele volta a atender normalmente. Não funciona com SetReusePort
+// (vários sockets).
+func (s *UDPServer) Handoff(argv []string) (*os.Process, error) {
+	if len(argv) == 0 {
+		return nil, errors.New("handoff: comando vazio")
+	}
+	s.mu.Lock()
+	conns, done := s.conns, s.handedOff
+	s.mu.Unlock()
+	if done {
+		return nil, errors.New("handoff já realizado")
+	}
+	if len(conns) != 1 {
+		return nil, errors.New("handoff exige exatamente um socket aberto (sem SO_REUSEPORT)")
+	}
+
+	// Cópias do descritor e o canal do estado para o novo processo
+	sock, err := conns[0].File()
+	if err != nil {
+		return nil, fmt.Errorf("handoff: %v", err)
+	}
+	defer sock.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("handoff: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	// O novo processo espera o estado antes de abrir o socket
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{sock, r} // descritores 3 e 4
+	cmd.Env = append(os.Environ(), "LISTEN_FDS=1", envHandoff+"="+strconv.Itoa(listenFdsStart+1))
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("handoff: %v", err)
+	}
+
+	// Para de ler e espera os pacotes já lidos serem tratados
+	conns[0].SetReadDeadline(time.Now())
+	s.readGate.Lock()
+	s.inflight.Wait()
+
+	s.mu.Lock()
+	st := s.handoffStateLocked()
+	s.handedOff = true
+	s.mu.Unlock()
+
+	if err := json.NewEncoder(w).Encode(st); err != nil {
+		// O novo processo não recebeu o estado: este volta a atender
+		cmd.Process.Kill()
+		cmd.Wait()
+		s.mu.Lock()
+		s.handedOff = false
+		s.mu.Unlock()
+		conns[0].SetReadDeadline(time.Time{})
+		s.readGate.Unlock()
+		return nil, fmt.Errorf("handoff: envio do estado: %v", err)
+	}
+	// O prazo vencido segue valendo: os loops veem handedOff e saem
+	s.readGate.Unlock()
+	log.Printf("[HANDOFF] Socket e estado entregues ao processo %d (%d clientes, %d votos)",
+		cmd.Process.Pid, len(st.Clients), len(st.Votes))
+	return cmd.Process, nil
+}
+
+// InheritedHandoff lê o estado passado pelo processo anterior (Handoff), ou
+// devolve nil quando o processo não foi iniciado assim. Deve ser chamado
+// antes do Start, que usa o socket herdado.
+func InheritedHandoff() (*HandoffState, error) {
+	v := os.Getenv(envHandoff)
+	if v == "" {
+		return nil, nil
+	}
+	os.Unsetenv(envHandoff)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil || fd <= listenFdsStart {
+		return nil, fmt.Errorf("%s inválido: %q", envHandoff, v)
+	}
+	f := os.NewFile(uintptr(fd), "handoff-state")
+	defer f.Close()
+
+	var st HandoffState
+	if err := json.NewDecoder(f).Decode(&st); err != nil {
+		return nil, fmt.Errorf("estado do handoff: %v", err)
+	}
+	return &st, nil
+}
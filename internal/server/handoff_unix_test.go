@@ -0,0 +1,43 @@
+//go:build unix
+
+package server
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// Depois de um Handoff bem-sucedido o Stop ainda retorna (antes, travava no
+// readGate que o Handoff deixava preso)
+func TestStopAfterHandoff(t *testing.T) {
+	bin, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip("sem o binário true:", err)
+	}
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	startUDP(t, s)
+
+	proc, err := s.Handoff([]string{bin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proc.Wait()
+	if _, err := s.Handoff([]string{bin}); err == nil {
+		t.Fatal("segundo Handoff aceito")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(waitTimeout):
+		t.Fatal("Stop travado depois do Handoff")
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import "time"
+
+// ----------------------------------------------------------
+// Heartbeats para manter o mapeamento NAT dos clientes
+// ----------------------------------------------------------
+//
+// Em votações longas e paradas, nenhum pacote flui até o cliente e o NAT
+// pode descartar o mapeamento: o cliente deixa de receber broadcasts sem
+// perceber. Com SetHeartbeat, todos os clientes registrados recebem um
+// HEARTBEAT mínimo a cada intervalo enquanto a votação está ativa. O
+// HEARTBEAT não tem SeqNum e não entra na contagem de entrega (RESYNC).
+
+// SetHeartbeat liga os heartbeats com o intervalo dado (0 desliga)
+func (s *UDPServer) SetHeartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.heartbeatStop != nil {
+		close(s.heartbeatStop)
+		s.heartbeatStop = nil
+	}
+	s.heartbeat = interval
+	if interval <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	s.heartbeatStop = stop
+	go s.heartbeatWorker(interval, stop)
+}
+
+// Worker que envia os heartbeats até SetHeartbeat ser chamado de novo
+func (s *UDPServer) heartbeatWorker(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.sendHeartbeat()
+		}
+	}
+}
+
+func (s *UDPServer) sendHeartbeat() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Durante a pausa o mapeamento também precisa sobreviver
+	if s.votingState != VotingActive && s.votingState != VotingPaused {
+		return
+	}
+	// Silenciados também recebem: precisam do mapeamento para o resultado final
+	for _, c := range s.clients {
+		s.send(c.addr, Message{Type: "HEARTBEAT"})
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// heartbeats conta os HEARTBEAT enviados a ana
+func heartbeats(f *fakeConn) int {
+	return len(f.ofType(testAddr(1), "HEARTBEAT"))
+}
+
+// HEARTBEAT só sai com a votação ativa, no intervalo configurado, sem
+// SeqNum e sem mexer na numeração dos broadcasts nem na contagem de entrega
+func TestHeartbeatInterval(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	s, f := newFakeServer(t)
+	register(t, s, f, "ana", testAddr(1))
+	s.SetHeartbeat(interval)
+	t.Cleanup(func() { s.SetHeartbeat(0) })
+
+	time.Sleep(3 * interval)
+	if n := heartbeats(f); n != 0 {
+		t.Fatalf("%d heartbeats antes da abertura", n)
+	}
+
+	s.StartVoting(3600)
+	f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" })
+	seq, delivery := s.Stats().BroadcastSeq, s.ServerStats().BroadcastsSent
+	time.Sleep(10 * interval)
+	n := heartbeats(f)
+	if n < 4 || n > 12 {
+		t.Fatalf("%d heartbeats em %s com intervalo de %s", n, 10*interval, interval)
+	}
+	for _, m := range f.ofType(testAddr(1), "HEARTBEAT") {
+		if m.SeqNum != 0 || m.VoteCounts != nil {
+			t.Fatalf("heartbeat com conteúdo de broadcast: %+v", m)
+		}
+	}
+	if got := s.Stats().BroadcastSeq; got != seq {
+		t.Fatalf("BroadcastSeq %d → %d com heartbeats", seq, got)
+	}
+	if got := s.ServerStats().BroadcastsSent; got != delivery {
+		t.Fatalf("broadcasts enviados %d → %d com heartbeats", delivery, got)
+	}
+
+	s.EndVotingNow()
+	time.Sleep(2 * interval)
+	n = heartbeats(f)
+	time.Sleep(3 * interval)
+	if got := heartbeats(f); got != n {
+		t.Fatalf("%d heartbeats depois do encerramento", got-n)
+	}
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Servidor HTTP opcional (Server-Sent Events para painéis web)
+// ----------------------------------------------------------
+
+// Intervalo dos comentários que mantêm a conexão SSE viva em proxies
+const sseHeartbeat = 15 * time.Second
+
+// Espera sugerida ao navegador antes de reconectar (campo retry do SSE)
+const sseRetry = 3 * time.Second
+
+// StartHTTP abre o servidor HTTP opcional. GET /events entrega um evento
+// SSE com os resultados (mesmo formato de GetResults) ao conectar e a cada
+// broadcast; com SetMetrics(NewMemoryMetrics()), GET /metrics expõe as
+// medições. Com SetHideLiveResults, os parciais só vão para quem passa o
+// token de observador em ?token=; o resultado final vai para todos.
+func (s *UDPServer) StartHTTP(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.serveEvents)
+	routes := "/events"
+
+	// Métricas em memória ficam disponíveis em /metrics
+	s.mu.Lock()
+	if m, ok := s.metrics.(*MemoryMetrics); ok {
+		mux.Handle("/metrics", m)
+		routes += ", /metrics"
+	}
+	s.mu.Unlock()
+	log.Printf("Servidor HTTP em %s (%s)", ln.Addr(), routes)
+	go http.Serve(ln, mux)
+	return nil
+}
+
+// StartMetrics abre um endpoint HTTP só com GET /metrics, para o
+// Prometheus coletar os contadores do servidor (Stats) sem ler o log. Com
+// SetMetrics(NewMemoryMetrics()), as medições em memória vêm em seguida.
+func (s *UDPServer) StartMetrics(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	log.Printf("Métricas em http://%s/metrics", ln.Addr())
+	go http.Serve(ln, mux)
+	return nil
+}
+
+func (s *UDPServer) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if s.Stats().WriteText(w) != nil {
+		return
+	}
+
+	s.mu.Lock()
+	m, ok := s.metrics.(*MemoryMetrics)
+	s.mu.Unlock()
+	if ok {
+		m.WriteText(w)
+	}
+}
+
+func (s *UDPServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	snap, updates, now := s.subscribeResults()
+	defer s.unsubscribe(updates)
+	observer := s.sseObserver(r.URL.Query().Get("token"))
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+
+	if snap.State != VotingEnded && !observer && s.liveHidden() {
+		snap = Results{TakenAt: snap.TakenAt, SeqNum: snap.SeqNum, State: snap.State}
+	}
+	if writeEvent(w, snap) != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case msg, ok := <-updates:
+			if !ok {
+				return // assinante lento: o navegador reconecta e recebe novo snapshot
+			}
+			if msg.Final == nil && !observer && s.liveHidden() {
+				continue
+			}
+			if writeEvent(w, resultsFromBroadcast(msg, now())) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent envia um evento SSE com os resultados; o id é o SeqNum
+func writeEvent(w http.ResponseWriter, r Results) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", r.SeqNum, data)
+	return err
+}
+
+// subscribeResults registra um assinante e devolve os resultados atuais e o
+// relógio do servidor (para datar os eventos seguintes fora do mutex)
+func (s *UDPServer) subscribeResults() (Results, chan Message, func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := s.addSubscriberLocked()
+	r := s.resultsLocked()
+	r.VoteCounts, r.Ordered = s.displayLocked(r.VoteCounts)
+	return r, ch, s.now
+}
+
+// sseObserver diz se o token dá acesso aos parciais ocultos
+func (s *UDPServer) sseObserver(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isObserverToken(token)
+}
+
+// liveHidden diz se os parciais estão ocultos para votantes comuns
+func (s *UDPServer) liveHidden() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hideLive
+}
+
+// resultsFromBroadcast monta os resultados a partir de um broadcast
+// publicado (o resultado final traz também o selo e o vencedor)
+func resultsFromBroadcast(msg Message, at time.Time) Results {
+	r := Results{
+		TakenAt:    at,
+		SeqNum:     msg.SeqNum,
+		State:      VotingActive,
+		VoteCounts: msg.VoteCounts,
+		Ordered:    msg.Results,
+	}
+	r.TotalVotes = totalVotes(msg.VoteCounts)
+	if f := msg.Final; f != nil {
+		r.State = VotingEnded
+		r.ChainHash, r.Decision = f.ChainHash, f.Decision
+		r.Winner, r.RunnerUp, r.Margin, r.MarginPct = f.Winner, f.RunnerUp, f.Margin, f.MarginPct
+		r.Quorum, r.NonBinding = f.Quorum, f.NonBinding
+		r.Tied = f.Tied
+	}
+	return r
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseClient lê os eventos de GET /events
+type sseClient struct {
+	t      *testing.T
+	events chan Results
+}
+
+func dialEvents(t *testing.T, s *UDPServer, query string) *sseClient {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(s.serveEvents))
+	t.Cleanup(ts.Close)
+	resp, err := http.Get(ts.URL + "/events" + query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	c := &sseClient{t: t, events: make(chan Results, 16)}
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			data, ok := strings.CutPrefix(sc.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var r Results
+			if json.Unmarshal([]byte(data), &r) == nil {
+				c.events <- r
+			}
+		}
+	}()
+	return c
+}
+
+func (c *sseClient) next() Results {
+	c.t.Helper()
+	select {
+	case r := <-c.events:
+		return r
+	case <-time.After(waitTimeout):
+		c.t.Fatal("evento SSE não chegou")
+		return Results{}
+	}
+}
+
+// Ao conectar chega o placar atual; cada voto gera um evento com o placar
+// atualizado, e o encerramento traz o vencedor
+func TestEventsStream(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia")
+	vote(s, f, "ana", testAddr(1), "A")
+
+	c := dialEvents(t, s, "")
+	if snap := c.next(); snap.State != VotingActive || snap.VoteCounts["A"] != 1 || snap.SeqNum == 0 {
+		t.Fatalf("snapshot inicial = %+v", snap)
+	}
+
+	vote(s, f, "bia", testAddr(2), "A")
+	if ev := c.next(); ev.VoteCounts["A"] != 2 || ev.TotalVotes != 2 {
+		t.Fatalf("evento após o voto = %+v", ev)
+	}
+
+	s.EndVotingNow()
+	if ev := c.next(); ev.State != VotingEnded || ev.Winner != "A" {
+		t.Fatalf("evento final = %+v", ev)
+	}
+}
+
+// Com parciais ocultos, só o observador vê a contagem antes do fim
+func TestEventsHideLive(t *testing.T) {
+	s, f := votingServer(t, false, "ana")
+	s.SetHideLiveResults(true, "olho")
+	vote(s, f, "ana", testAddr(1), "B")
+
+	if snap := dialEvents(t, s, "").next(); snap.VoteCounts != nil {
+		t.Fatalf("parcial exposto sem token: %+v", snap)
+	}
+	if snap := dialEvents(t, s, "?token=olho").next(); snap.VoteCounts["B"] != 1 {
+		t.Fatalf("observador sem parcial: %+v", snap)
+	}
+}
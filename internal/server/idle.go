@@ -0,0 +1,121 @@
+package server
+
+import (
+	"log"
+	"net"
+	"sort"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Remoção de clientes inativos
+// ----------------------------------------------------------
+//
+// Um cliente que caiu ou sumiu continua na lista e recebendo broadcasts
+// para sempre. Cada pacote do endereço registrado renova o lastSeen do
+// cliente. Com SetIdleTimeout, um worker confere a lista periodicamente:
+// quem passou da metade da janela sem dar sinal recebe um PING do servidor
+// (o pkg/client responde com PONG automaticamente), e quem passou da janela
+// inteira é removido como num UNREGISTER. O voto já dado continua contado.
+
+// Cliente registrado
+type clientInfo struct {
+	addr         *net.UDPAddr
+	registeredAt time.Time // registro aceito (ou estado restaurado)
+	lastSeen     time.Time // último pacote recebido do endereço registrado
+	weight       int64     // peso do voto (0 = 1, ver SetWeightedVoting)
+
+	ackedRequests []ackedRequest // últimos votos confirmados (ver reackVoteLocked)
+}
+
+// newClientInfo cria a entrada de um cliente registrado agora
+func newClientInfo(addr *net.UDPAddr, now time.Time) *clientInfo {
+	return &clientInfo{addr: addr, registeredAt: now, lastSeen: now}
+}
+
+// ActiveClients devolve, em ordem, os IDs registrados que mandaram algum
+// pacote nos últimos `within`
+func (s *UDPServer) ActiveClients(within time.Duration) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var ids []string
+	for id, c := range s.clients {
+		if now.Sub(c.lastSeen) <= within {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetIdleTimeout remove os clientes sem nenhum pacote por `timeout`
+// (0 desliga)
+func (s *UDPServer) SetIdleTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.idleStop != nil {
+		close(s.idleStop)
+		s.idleStop = nil
+	}
+	s.idleTimeout = timeout
+	if timeout <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	s.idleStop = stop
+	go s.idleWorker(timeout, stop)
+}
+
+// EvictedClients devolve quantos clientes foram removidos por inatividade
+func (s *UDPServer) EvictedClients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.evicted
+}
+
+// Worker que confere os clientes inativos até SetIdleTimeout ser chamado
+// de novo
+func (s *UDPServer) idleWorker(timeout time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(timeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.sweepIdle(timeout)
+		}
+	}
+}
+
+// sweepIdle sonda os clientes quietos e remove os que passaram da janela
+func (s *UDPServer) sweepIdle(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, c := range s.clients {
+		idle := now.Sub(c.lastSeen)
+		switch {
+		case idle > timeout:
+			s.removeClientLocked(id)
+			s.evicted++
+			log.Printf("[EVICT] %s (%s) sem atividade há %s (registrado há %s)", id, c.addr,
+				idle.Round(time.Second), now.Sub(c.registeredAt).Round(time.Second))
+		case idle > timeout/2:
+			s.send(c.addr, Message{Type: "PING"})
+		}
+	}
+}
+
+// touchClientLocked renova a atividade de `id` se o pacote veio do
+// endereço registrado
+func (s *UDPServer) touchClientLocked(id string, addr *net.UDPAddr) {
+	if c, ok := s.clients[id]; ok && c.addr.String() == addr.String() {
+		c.lastSeen = s.now()
+	}
+}
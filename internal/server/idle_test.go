@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Com relógio controlado: quem passa da metade da janela recebe um PING,
+// quem passa da janela inteira sai; qualquer pacote renova o prazo e o
+// voto do removido continua contado
+func TestIdleEviction(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia")
+	clock := time.Now()
+	s.SetClock(func() time.Time { return clock })
+	a, b := testAddr(1), testAddr(2)
+	s.mu.Lock()
+	for _, c := range s.clients {
+		c.lastSeen = clock
+	}
+	s.mu.Unlock()
+	vote(s, f, "ana", a, "A")
+
+	const timeout = time.Minute
+	clock = clock.Add(40 * time.Second)
+	s.sweepIdle(timeout)
+	for _, addr := range []*net.UDPAddr{a, b} {
+		if got := f.last(addr); got.Type != "PING" {
+			t.Fatalf("%s quieto há 40s recebeu %+v, esperava PING", addr, got)
+		}
+	}
+	// bia responde à sonda; ana não
+	deliver(s, b, Message{Type: "PONG", ClientID: "bia"})
+
+	clock = clock.Add(30 * time.Second)
+	s.sweepIdle(timeout)
+	if n := s.EvictedClients(); n != 1 {
+		t.Fatalf("%d removidos, esperava 1", n)
+	}
+	if got := s.ActiveClients(time.Hour); len(got) != 1 || got[0] != "bia" {
+		t.Fatalf("clientes restantes = %v, esperava [bia]", got)
+	}
+	if got := vote(s, f, "ana", a, "B"); got.Type != "ERROR" || got.Message != "Registre-se primeiro" {
+		t.Fatalf("voto depois da remoção = %+v", got)
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+}
+
+// Pela rede, com o worker de verdade: o pkg/client responde às sondas e
+// fica; um socket que registrou e parou de ler é removido
+func TestIdleWorkerEvictsSilentClient(t *testing.T) {
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	startUDP(t, s)
+
+	c, err := client.Dial(s.Addr().String(), "ana", client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	silent, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	data, _ := json.Marshal(Message{Type: "REGISTER", ClientID: "bia"})
+	silent.Write(data)
+	silent.SetReadDeadline(time.Now().Add(waitTimeout))
+	if _, err := silent.Read(make([]byte, 64*1024)); err != nil {
+		t.Fatalf("sem ACK de registro: %v", err)
+	}
+
+	s.SetIdleTimeout(200 * time.Millisecond)
+	defer s.SetIdleTimeout(0)
+
+	deadline := time.Now().Add(waitTimeout)
+	for s.EvictedClients() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := s.ActiveClients(time.Hour); len(got) != 1 || got[0] != "ana" {
+		t.Fatalf("clientes depois da varredura = %v, esperava [ana]", got)
+	}
+	// Mais algumas janelas: ana continua respondendo às sondas
+	time.Sleep(600 * time.Millisecond)
+	if n := s.EvictedClients(); n != 1 {
+		t.Fatalf("%d removidos, esperava só o socket silencioso", n)
+	}
+}
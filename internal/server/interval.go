@@ -0,0 +1,58 @@
+package server
+
+import "time"
+
+// ----------------------------------------------------------
+// Broadcast em intervalo fixo
+// ----------------------------------------------------------
+//
+// Por padrão cada voto aceito gera um broadcast. Numa enxurrada de votos
+// isso vira um broadcast por voto para cada cliente. Com
+// SetBroadcastInterval, o voto só marca o placar como alterado (o mesmo
+// pendingUpdate do modo degradado) e um worker envia no máximo um
+// broadcast por intervalo, apenas se algo mudou. O broadcast final continua
+// saindo na hora do encerramento.
+
+// SetBroadcastInterval envia no máximo um broadcast a cada `interval`
+// (0 volta ao broadcast por voto)
+func (s *UDPServer) SetBroadcastInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.intervalStop != nil {
+		close(s.intervalStop)
+		s.intervalStop = nil
+	}
+	s.broadcastInterval = interval
+	if interval <= 0 {
+		// Alteração que esperava o próximo intervalo sai agora
+		if s.pendingUpdate && !s.degraded {
+			s.pendingUpdate = false
+			s.enqueueBroadcastLocked(nil)
+		}
+		return
+	}
+	stop := make(chan struct{})
+	s.intervalStop = stop
+	go s.intervalWorker(interval, stop)
+}
+
+// Worker que envia o placar alterado até SetBroadcastInterval ser chamado de novo
+func (s *UDPServer) intervalWorker(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.pendingUpdate {
+				s.pendingUpdate = false
+				s.enqueueBroadcastLocked(nil)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
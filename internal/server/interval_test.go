@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 1000 votos com SetBroadcastInterval: o número de broadcasts fica limitado
+// pelos intervalos decorridos, e o último traz o placar completo
+func TestBroadcastIntervalBounded(t *testing.T) {
+	const voters, interval = 1000, 20 * time.Millisecond
+	s, f := newFakeServer(t)
+	s.SetBroadcastInterval(interval)
+	t.Cleanup(func() { s.SetBroadcastInterval(0) })
+	for i := 1; i <= voters; i++ {
+		register(t, s, f, fmt.Sprint("eleitor-", i), testAddr(i))
+	}
+	s.StartVoting(3600)
+
+	start := time.Now()
+	for i := 1; i <= voters; i++ {
+		vote(s, f, fmt.Sprint("eleitor-", i), testAddr(i), []string{"A", "B"}[i%2])
+	}
+	a := testAddr(1)
+	f.waitFor(t, a, func(m Message) bool {
+		return m.Type == "BROADCAST" && m.VoteCounts["A"]+m.VoteCounts["B"] == voters
+	})
+	elapsed := time.Since(start)
+
+	got := len(f.ofType(a, "BROADCAST"))
+	if limit := int(elapsed/interval) + 2; got > limit {
+		t.Fatalf("%d broadcasts em %s, esperava no máximo %d", got, elapsed, limit)
+	}
+	if got >= voters {
+		t.Fatalf("%d broadcasts para %d votos: não agrupou", got, voters)
+	}
+}
+
+// Sem intervalo, cada voto gera o seu broadcast; voltar a 0 envia na hora
+// o que esperava o próximo intervalo
+func TestBroadcastIntervalZero(t *testing.T) {
+	s, f := votingServer(t, true, "ana")
+	a := testAddr(1)
+	for i, op := range []string{"A", "B", "A"} {
+		vote(s, f, "ana", a, op)
+		f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.SeqNum == i+2 }) // #1 é o da abertura
+	}
+
+	s.SetBroadcastInterval(time.Hour)
+	vote(s, f, "ana", a, "B")
+	time.Sleep(20 * time.Millisecond)
+	if n := len(f.ofType(a, "BROADCAST")); n != 4 {
+		t.Fatalf("%d broadcasts antes do intervalo, esperava 4", n)
+	}
+	s.SetBroadcastInterval(0)
+	m := f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.SeqNum == 5 })
+	if m.VoteCounts["B"] != 1 {
+		t.Fatalf("broadcast pendente = %v", m.VoteCounts)
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ----------------------------------------------------------
+// Métricas plugáveis
+// ----------------------------------------------------------
+//
+// O servidor chama um Metrics nos pontos de instrumentação (pacotes, votos,
+// registros, broadcasts). O padrão não faz nada; MemoryMetrics acumula os
+// valores em memória e os expõe em /metrics (StartHTTP). Quem embute o
+// servidor pode ligar o próprio cliente (ex.: Prometheus) implementando a
+// interface, sem que este pacote dependa dele.
+
+// Nomes das métricas emitidas pelo servidor
+const (
+	MetricPacketBytes       = "udpvote_packet_bytes"        // Observe: tamanho de cada datagrama recebido
+	MetricRegistrations     = "udpvote_registrations_total" // Inc: registros aceitos
+	MetricVotesReceived     = "udpvote_votes_received_total"
+	MetricVotesAccepted     = "udpvote_votes_accepted_total"
+	MetricVotesRejected     = "udpvote_votes_rejected_total"
+	MetricBroadcastsSent    = "udpvote_broadcasts_sent_total"
+	MetricBroadcastsDropped = "udpvote_broadcasts_dropped_total" // Inc: descartados por fila cheia
+	MetricBroadcastFanout   = "udpvote_broadcast_fanout"         // Observe: destinatários de cada broadcast
+	MetricClients           = "udpvote_clients"                  // Gauge: clientes registrados
+	MetricBufferBytes       = "udpvote_buffer_bytes"             // Gauge: histórico de broadcasts + snapshots retidos
+	MetricBufferEvictions   = "udpvote_buffer_evictions_total"   // Inc: entradas descartadas pelo orçamento de memória
+)
+
+// Metrics recebe as medições do servidor. As chamadas acontecem com o
+// mutex do servidor travado: a implementação deve ser rápida e nunca
+// chamar o servidor de volta.
+type Metrics interface {
+	Inc(name string)                    // soma 1 a um contador
+	Observe(name string, value float64) // registra uma amostra (tamanhos, durações)
+	Gauge(name string, value float64)   // define o valor atual de um medidor
+}
+
+// Implementação padrão: descarta tudo
+type nopMetrics struct{}
+
+func (nopMetrics) Inc(string)              {}
+func (nopMetrics) Observe(string, float64) {}
+func (nopMetrics) Gauge(string, float64)   {}
+
+// SetMetrics define quem recebe as medições (nil desliga)
+func (s *UDPServer) SetMetrics(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = m
+}
+
+// ----------------------------------------------------------
+// Implementação em memória (texto no formato do Prometheus)
+// ----------------------------------------------------------
+
+// Soma e quantidade das amostras de uma métrica Observe
+type summary struct {
+	count int
+	sum   float64
+}
+
+// MemoryMetrics acumula as medições em memória. Observe vira um resumo
+// com soma e quantidade de amostras.
+type MemoryMetrics struct {
+	mu        sync.Mutex
+	counters  map[string]float64
+	gauges    map[string]float64
+	summaries map[string]*summary
+}
+
+// NewMemoryMetrics cria um acumulador vazio
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		counters:  make(map[string]float64),
+		gauges:    make(map[string]float64),
+		summaries: make(map[string]*summary),
+	}
+}
+
+func (m *MemoryMetrics) Inc(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name]++
+}
+
+func (m *MemoryMetrics) Observe(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sm, ok := m.summaries[name]
+	if !ok {
+		sm = &summary{}
+		m.summaries[name] = sm
+	}
+	sm.count++
+	sm.sum += value
+}
+
+func (m *MemoryMetrics) Gauge(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name] = value
+}
+
+// Counter devolve o valor atual de um contador
+func (m *MemoryMetrics) Counter(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+// WriteText grava todas as métricas no formato de texto do Prometheus,
+// em ordem alfabética
+func (m *MemoryMetrics) WriteText(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range sortedKeys(m.counters) {
+		if _, err := fmt.Fprintf(w, "# TYPE %s counter\n%s %g\n", name, name, m.counters[name]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(m.gauges) {
+		if _, err := fmt.Fprintf(w, "# TYPE %s gauge\n%s %g\n", name, name, m.gauges[name]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(m.summaries) {
+		sm := m.summaries[name]
+		if _, err := fmt.Fprintf(w, "# TYPE %s summary\n%s_sum %g\n%s_count %d\n",
+			name, name, sm.sum, name, sm.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP responde a /metrics
+func (m *MemoryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteText(w)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
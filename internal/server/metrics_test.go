@@ -0,0 +1,82 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeMetrics anota cada chamada recebida
+type fakeMetrics struct {
+	mu       sync.Mutex
+	counters map[string]int
+	observed map[string][]float64
+	gauges   map[string][]float64
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{counters: map[string]int{}, observed: map[string][]float64{}, gauges: map[string][]float64{}}
+}
+
+func (m *fakeMetrics) Inc(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name]++
+}
+
+func (m *fakeMetrics) Observe(name string, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observed[name] = append(m.observed[name], v)
+}
+
+func (m *fakeMetrics) Gauge(name string, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name] = append(m.gauges[name], v)
+}
+
+// Registro, votação (um voto aceito, um duplicado) e broadcasts passam
+// pelos pontos de instrumentação esperados
+func TestMetricsInstrumentation(t *testing.T) {
+	m := newFakeMetrics()
+	s, f := newFakeServer(t)
+	s.SetMetrics(m)
+	a, b := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", a)
+	register(t, s, f, "bia", b)
+	s.StartVoting(3600)
+	vote(s, f, "ana", a, "A")
+	deliver(s, a, Message{Type: "VOTE", ClientID: "ana", VoteOption: "B"})
+	stopFake(s, f) // os broadcasts da fila saem antes de conferir
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counters := map[string]int{
+		MetricRegistrations:  2,
+		MetricVotesReceived:  2,
+		MetricVotesAccepted:  1,
+		MetricVotesRejected:  1,
+		MetricBroadcastsSent: len(f.ofType(a, "BROADCAST")),
+	}
+	for name, want := range counters {
+		if got := m.counters[name]; got != want {
+			t.Errorf("%s = %d, esperava %d", name, got, want)
+		}
+	}
+	if m.counters[MetricBroadcastsSent] < 2 {
+		t.Errorf("%d broadcasts, esperava a abertura e o voto", m.counters[MetricBroadcastsSent])
+	}
+	if got := m.gauges[MetricClients]; !slices.Equal(got, []float64{1, 2}) {
+		t.Errorf("%s = %v, esperava [1 2]", MetricClients, got)
+	}
+	if got := m.observed[MetricPacketBytes]; len(got) != 4 || slices.Min(got) <= 0 {
+		t.Errorf("%s = %v, esperava os 4 datagramas", MetricPacketBytes, got)
+	}
+	for _, fanout := range m.observed[MetricBroadcastFanout] {
+		if fanout != 2 {
+			t.Errorf("%s = %v, esperava 2 destinatários", MetricBroadcastFanout, m.observed[MetricBroadcastFanout])
+			break
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"log"
+	"net"
+)
+
+// ----------------------------------------------------------
+// Espelhamento de votos para um servidor secundário
+// ----------------------------------------------------------
+//
+// O primário reenvia cada voto aceito como VOTE com Mirror=true para o
+// secundário, que mantém um placar paralelo (hot standby). O envio é um
+// único datagrama, sem espera nem retransmissão. Votos marcados como
+// espelhados nunca são reenviados, o que impede laços entre servidores.
+
+// SetMirrorTarget define o servidor que recebe a cópia dos votos aceitos.
+// Endereço vazio desliga o espelhamento.
+func (s *UDPServer) SetMirrorTarget(addr string) error {
+	var target *net.UDPAddr
+	if addr != "" {
+		var err error
+		if target, err = net.ResolveUDPAddr("udp", addr); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mirrorTarget = target
+	return nil
+}
+
+// SetMirrorSource define o único endereço (o do primário) de onde votos
+// espelhados são aceitos. Sem origem configurada, eles são descartados.
+func (s *UDPServer) SetMirrorSource(addr string) error {
+	var source *net.UDPAddr
+	if addr != "" {
+		var err error
+		if source, err = net.ResolveUDPAddr("udp", addr); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mirrorSource = source
+	return nil
+}
+
+// MirroredVotes devolve quantos votos foram enviados ao secundário
+// e quantos foram recebidos do primário
+func (s *UDPServer) MirroredVotes() (sent, received int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mirrorSent, s.mirrorReceived
+}
+
+// mirrorVoteLocked envia a cópia do voto aceito ao secundário, se houver
+func (s *UDPServer) mirrorVoteLocked(id, option string, weight int64) {
+	if s.mirrorTarget == nil {
+		return
+	}
+	msg := Message{Type: "VOTE", ClientID: id, VoteOption: option, Mirror: true}
+	if weight != 1 {
+		msg.Weight = &weight
+	}
+	s.send(s.mirrorTarget, msg)
+	s.mirrorSent++
+}
+
+// fromMirrorSourceLocked diz se addr é o primário configurado em
+// SetMirrorSource
+func (s *UDPServer) fromMirrorSourceLocked(addr *net.UDPAddr) bool {
+	src := s.mirrorSource
+	return src != nil && src.IP.Equal(addr.IP) && src.Port == addr.Port
+}
+
+// mirroredVoteLocked diz se msg é um voto espelhado legítimo: a marca
+// Mirror só vale em VOTE vindo do primário. Só esses pulam o token e a
+// fixação de origem, pois o primário já os validou.
+func (s *UDPServer) mirroredVoteLocked(msg Message, addr *net.UDPAddr) bool {
+	return msg.Mirror && msg.Type == "VOTE" && s.fromMirrorSourceLocked(addr)
+}
+
+// processMirroredVote aplica um voto já validado pelo primário. Não há
+// registro nem ACK: o votante é cliente do primário, não deste servidor.
+func (s *UDPServer) processMirroredVote(id, option string, weight *int64, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.votesReceived++
+	if !s.fromMirrorSourceLocked(addr) {
+		log.Printf("[MIRROR] Voto espelhado de origem não autorizada %s descartado", addr)
+		return
+	}
+
+	// Retransmissões do mesmo voto são ignoradas; uma opção diferente é
+	// troca de voto, aplicada se este servidor também a permite
+	prev, voted := s.votes[id]
+	if voted && (!s.allowRevote || prev == option) {
+		return
+	}
+	if _, valid := s.voteCounts[option]; !valid {
+		log.Printf("[MIRROR] Opção %q de %s não existe neste servidor", option, id)
+		return
+	}
+	if voted {
+		w := s.voteWeightLocked(id)
+		s.votes[id] = option
+		s.voteCounts[prev] -= w
+		s.voteCounts[option] += w
+		s.votesChanged++
+		s.votesAccepted++
+		s.touchOptionLocked(prev)
+		s.touchOptionLocked(option)
+		s.mirrorReceived++
+		s.appendAuditLocked(id, option, w)
+		s.broadcastUpdateLocked()
+		return
+	}
+
+	// O peso vem do primário, que validou o registro
+	w := int64(1)
+	if weight != nil && *weight > 0 {
+		w = *weight
+	}
+	if s.tallyFullLocked(w) {
+		log.Printf("[MIRROR] Voto de %s descartado: limite da contagem atingido", id)
+		return
+	}
+
+	s.votes[id] = option
+	s.voteCounts[option] += w
+	if w != 1 {
+		s.voteWeights[id] = w
+	}
+	s.votesAccepted++
+	s.touchOptionLocked(option)
+	s.mirrorReceived++
+	s.appendAuditLocked(id, option, w)
+	s.broadcastUpdateLocked()
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Primário e secundário reais, cada um apontando o espelhamento para o
+// outro: o placar do secundário converge para o do primário, e os votos
+// espelhados não voltam
+func TestMirrorConverges(t *testing.T) {
+	newServer := func() *UDPServer {
+		s, err := NewUDPServer([]string{"A", "B", "C"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.SetAllowRevote(true)
+		startUDP(t, s)
+		return s
+	}
+	primary, secondary := newServer(), newServer()
+	for _, err := range []error{
+		primary.SetMirrorTarget(secondary.Addr().String()),
+		secondary.SetMirrorSource(primary.Addr().String()),
+		secondary.SetMirrorTarget(primary.Addr().String()),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	primary.StartVoting(3600)
+
+	ballots := []struct{ name, option string }{
+		{"ana", "A"}, {"bia", "B"}, {"caio", "A"}, {"duda", "C"}, {"bia", "A"},
+	}
+	clients := make(map[string]*client.Client)
+	for _, b := range ballots {
+		c, ok := clients[b.name]
+		if !ok {
+			var err error
+			if c, err = client.Dial(primary.Addr().String(), b.name, client.Options{}); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { c.Close() })
+			if err := c.Register(); err != nil {
+				t.Fatal(err)
+			}
+			clients[b.name] = c
+		}
+		if _, err := c.Vote(b.option); err != nil {
+			t.Fatalf("%s votando %s: %v", b.name, b.option, err)
+		}
+	}
+
+	want := map[string]int64{"A": 3, "B": 0, "C": 1}
+	if got := primary.Results(); !maps.Equal(got, want) {
+		t.Fatalf("placar do primário = %v, esperava %v", got, want)
+	}
+	deadline := time.Now().Add(waitTimeout)
+	for !maps.Equal(secondary.Results(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("placar do secundário = %v, esperava %v", secondary.Results(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if sent, _ := primary.MirroredVotes(); sent != len(ballots) {
+		t.Fatalf("primário espelhou %d votos, esperava %d", sent, len(ballots))
+	}
+	if sent, received := secondary.MirroredVotes(); sent != 0 || received != len(ballots) {
+		t.Fatalf("secundário: enviados=%d recebidos=%d", sent, received)
+	}
+	if _, received := primary.MirroredVotes(); received != 0 {
+		t.Fatalf("%d votos espelhados voltaram ao primário", received)
+	}
+}
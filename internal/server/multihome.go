@@ -0,0 +1,154 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Uso simultâneo de um ID a partir de dois endereços
+// ----------------------------------------------------------
+//
+// Um cliente que troca de endereço (NAT que reatribui a porta, rede nova)
+// para de falar pelo endereço antigo. Já pacotes do mesmo ClientID chegando
+// intercalados de dois endereços ativos indicam ID roubado ou dois clientes
+// mal configurados. Cada ID registrado guarda os endereços vistos na janela
+// recente; um segundo endereço ativo abre um conflito, tratado pela
+// política escolhida em SetMultiHomePolicy.
+
+// Políticas para ID usado de dois endereços (SetMultiHomePolicy)
+const (
+	MultiHomeOff       = ""           // não verifica
+	MultiHomeRejectNew = "reject_new" // recusa o endereço que chegou depois
+	MultiHomeFence     = "fence"      // recusa todos até restar um único endereço ativo
+)
+
+// Janela padrão em que um endereço continua contando como ativo
+const defaultMultiHomeWindow = 30 * time.Second
+
+// SetMultiHomePolicy define o que fazer quando um ID é usado ao mesmo tempo
+// de dois endereços. Um endereço deixa de contar como ativo depois de
+// `window` sem pacotes (0 = janela padrão de 30s).
+func (s *UDPServer) SetMultiHomePolicy(policy string, window time.Duration) error {
+	switch policy {
+	case MultiHomeOff, MultiHomeRejectNew, MultiHomeFence:
+	default:
+		return errors.New("política de multi-endereço desconhecida: " + policy)
+	}
+	if window < 0 {
+		return errors.New("janela de multi-endereço não pode ser negativa")
+	}
+	if window == 0 {
+		window = defaultMultiHomeWindow
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.multiHome = policy
+	s.multiHomeWindow = window
+	if policy == MultiHomeOff {
+		s.sources = make(map[string]map[string]*sourceSeen)
+		s.conflicts = make(map[string]bool)
+	}
+	return nil
+}
+
+// MultiHomeConflicts devolve quantas vezes um ID foi visto em uso
+// simultâneo de dois endereços
+func (s *UDPServer) MultiHomeConflicts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.multiHomeCount
+}
+
+// Atividade de um endereço de origem de um ID
+type sourceSeen struct {
+	first time.Time // primeiro pacote da sequência ativa atual
+	last  time.Time // pacote mais recente
+}
+
+// sourceAllowed aplica a política de multi-endereço ao pacote de `id`
+// vindo de `addr`, respondendo ERROR aos recusados
+func (s *UDPServer) sourceAllowed(id string, addr *net.UDPAddr) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// IDs ainda não registrados não têm endereço a defender
+	if s.multiHome == MultiHomeOff || id == "" {
+		return true
+	}
+	if _, ok := s.clients[id]; !ok {
+		return true
+	}
+
+	now := s.now()
+	seen := s.sources[id]
+	if seen == nil {
+		seen = make(map[string]*sourceSeen)
+		s.sources[id] = seen
+	}
+	for a, src := range seen {
+		if now.Sub(src.last) > s.multiHomeWindow {
+			delete(seen, a)
+		}
+	}
+
+	key := addr.String()
+	cur, ok := seen[key]
+	if !ok {
+		cur = &sourceSeen{first: now}
+		seen[key] = cur
+	}
+	cur.last = now
+
+	// Endereço único ativo: uso normal, troca limpa de endereço ou conflito resolvido
+	if len(seen) == 1 {
+		if s.conflicts[id] {
+			delete(s.conflicts, id)
+			log.Printf("[INFO] %s voltou a usar um único endereço (%s)", id, addr)
+		}
+		return true
+	}
+
+	// O estabelecido é o endereço ativo há mais tempo
+	established := key
+	for a, src := range seen {
+		if src.first.Before(seen[established].first) {
+			established = a
+		}
+	}
+
+	if !s.conflicts[id] {
+		s.conflicts[id] = true
+		s.multiHomeCount++
+		other := established
+		if other == key {
+			for a := range seen {
+				if a != key {
+					other = a
+					break
+				}
+			}
+		}
+		log.Printf("[WARN] %s em uso simultâneo: %s e %s (política %s)", id, other, key, s.multiHome)
+	}
+
+	// reject_new mantém o estabelecido; o recém-chegado assume o ID só
+	// depois que o antigo ficar em silêncio pela janela
+	if s.multiHome == MultiHomeRejectNew && key == established {
+		return true
+	}
+	s.send(addr, Message{Type: "ERROR", Message: "ID em uso por outro endereço"})
+	return false
+}
+
+// touchSourceLocked anota o endereço de registro como o primeiro ativo do ID
+func (s *UDPServer) touchSourceLocked(id string, addr *net.UDPAddr) {
+	if s.multiHome == MultiHomeOff {
+		return
+	}
+	now := s.now()
+	s.sources[id] = map[string]*sourceSeen{addr.String(): {first: now, last: now}}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// multiHomeServer registra ana em testAddr(1) com a política e um relógio
+// controlado pelo teste
+func multiHomeServer(t *testing.T, policy string) (*UDPServer, *fakeConn, *time.Time) {
+	t.Helper()
+	s, f := newFakeServer(t)
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return clock })
+	if err := s.SetMultiHomePolicy(policy, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	register(t, s, f, "ana", testAddr(1))
+	return s, f, &clock
+}
+
+// ping entrega um PING de ana vindo de addr e diz se foi aceito
+func ping(t *testing.T, s *UDPServer, f *fakeConn, addr *net.UDPAddr) bool {
+	t.Helper()
+	deliver(s, addr, Message{Type: "PING", ClientID: "ana", SeqNum: 1})
+	switch m := f.last(addr); {
+	case m.Type == "PONG":
+		return true
+	case m.Type == "ERROR" && m.Message == "ID em uso por outro endereço":
+		return false
+	default:
+		t.Fatalf("PING de %s respondido com %+v", addr, m)
+		return false
+	}
+}
+
+// Pacotes de ana intercalados de dois endereços: reject_new mantém o
+// endereço estabelecido e recusa o novo até o antigo silenciar
+func TestMultiHomeRejectNew(t *testing.T) {
+	s, f, clock := multiHomeServer(t, MultiHomeRejectNew)
+	a, b := testAddr(1), testAddr(2)
+	for i := 0; i < 3; i++ {
+		*clock = clock.Add(time.Second)
+		if !ping(t, s, f, a) {
+			t.Fatalf("rodada %d: endereço estabelecido recusado", i)
+		}
+		if ping(t, s, f, b) {
+			t.Fatalf("rodada %d: endereço novo aceito em uso simultâneo", i)
+		}
+	}
+	if n := s.MultiHomeConflicts(); n != 1 {
+		t.Fatalf("conflitos = %d, esperava 1 (um por episódio)", n)
+	}
+
+	// a silencia pela janela: b assume o ID
+	*clock = clock.Add(11 * time.Second)
+	if !ping(t, s, f, b) {
+		t.Fatal("endereço novo recusado depois que o antigo silenciou")
+	}
+	if s.Stats().MultiHomeConflicts != 1 {
+		t.Fatal("troca limpa contou como conflito")
+	}
+}
+
+// fence recusa os dois endereços enquanto ambos estiverem ativos
+func TestMultiHomeFence(t *testing.T) {
+	s, f, clock := multiHomeServer(t, MultiHomeFence)
+	a, b := testAddr(1), testAddr(2)
+	*clock = clock.Add(time.Second)
+	if !ping(t, s, f, a) {
+		t.Fatal("endereço único recusado")
+	}
+	*clock = clock.Add(time.Second)
+	if ping(t, s, f, b) {
+		t.Fatal("segundo endereço aceito")
+	}
+	*clock = clock.Add(time.Second)
+	if ping(t, s, f, a) {
+		t.Fatal("fence aceitou o endereço estabelecido durante o conflito")
+	}
+
+	// Só b continua falando: passada a janela de a, o conflito se resolve
+	for i := 0; i < 3; i++ {
+		*clock = clock.Add(5 * time.Second)
+		ping(t, s, f, b)
+	}
+	if !ping(t, s, f, b) {
+		t.Fatal("conflito não se resolveu com um único endereço ativo")
+	}
+	if n := s.MultiHomeConflicts(); n != 1 {
+		t.Fatalf("conflitos = %d, esperava 1", n)
+	}
+}
+
+// Troca limpa de endereço (o antigo parou de falar) não é conflito
+func TestMultiHomeCleanRebind(t *testing.T) {
+	s, f, clock := multiHomeServer(t, MultiHomeFence)
+	*clock = clock.Add(11 * time.Second)
+	if !ping(t, s, f, testAddr(2)) || s.MultiHomeConflicts() != 0 {
+		t.Fatalf("troca de endereço recusada (conflitos %d)", s.MultiHomeConflicts())
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import "testing"
+
+// O voto do silenciado conta, mas ele só recebe o resultado final; o outro
+// cliente recebe todos os parciais
+func TestMutedClientVoteCountsWithoutPartials(t *testing.T) {
+	s, f := newFakeServer(t)
+	muted, loud := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", muted)
+	register(t, s, f, "bia", loud)
+
+	deliver(s, muted, Message{Type: "MUTE", ClientID: "ana"})
+	if got := f.last(muted); got.Type != "ACK" || got.Message != "Broadcasts silenciados" {
+		t.Fatalf("MUTE = %+v", got)
+	}
+
+	s.StartVoting(60)
+	if got := vote(s, f, "ana", muted, "A"); got.Type != "ACK" {
+		t.Fatalf("voto do silenciado = %+v", got)
+	}
+	if got := vote(s, f, "bia", loud, "B"); got.Type != "ACK" {
+		t.Fatalf("voto de bia = %+v", got)
+	}
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	stopFake(s, f) // esvazia a fila de broadcasts
+
+	if got := s.Results(); got["A"] != 1 || got["B"] != 1 {
+		t.Fatalf("placar = %v, esperava A=1 B=1", got)
+	}
+	for _, m := range f.ofType(muted, "BROADCAST") {
+		if m.Final == nil {
+			t.Fatalf("silenciado recebeu parcial #%d", m.SeqNum)
+		}
+	}
+	if n := len(f.ofType(muted, "BROADCAST")); n != 1 {
+		t.Fatalf("silenciado recebeu %d broadcasts, esperava só o final", n)
+	}
+	partials := 0
+	for _, m := range f.ofType(loud, "BROADCAST") {
+		if m.Final == nil {
+			partials++
+		}
+	}
+	if partials != 3 { // abertura e os dois votos
+		t.Fatalf("bia recebeu %d parciais, esperava 3", partials)
+	}
+}
+
+func TestUnmuteRestoresPartials(t *testing.T) {
+	s, f := newFakeServer(t)
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+	deliver(s, a, Message{Type: "MUTE", ClientID: "ana"})
+	deliver(s, a, Message{Type: "UNMUTE", ClientID: "ana"})
+	if got := f.last(a); got.Type != "ACK" || got.Message != "Broadcasts reativados" {
+		t.Fatalf("UNMUTE = %+v", got)
+	}
+
+	s.StartVoting(60)
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.Final == nil })
+}
+
+// Outro endereço não silencia nem reativa um ID que não é dele
+func TestMuteFromOtherAddressRejected(t *testing.T) {
+	s, f := newFakeServer(t)
+	owner, other := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", owner)
+
+	for _, typ := range []string{"MUTE", "UNMUTE"} {
+		deliver(s, other, Message{Type: typ, ClientID: "ana"})
+		if got := f.last(other); got.Type != "ERROR" || got.Message != "Endereço não corresponde" {
+			t.Fatalf("%s de outro endereço = %+v", typ, got)
+		}
+	}
+	s.mu.Lock()
+	isMuted := s.muted["ana"]
+	s.mu.Unlock()
+	if isMuted {
+		t.Fatal("MUTE de outro endereço silenciou o cliente")
+	}
+}
+
+func TestMuteRequiresClientToken(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetClientSecret("segredo")
+	a := testAddr(1)
+	ack := register(t, s, f, "ana", a)
+
+	deliver(s, a, Message{Type: "MUTE", ClientID: "ana"})
+	if got := f.last(a); got.Type != "ERROR" || got.Message != "Autenticação inválida" {
+		t.Fatalf("MUTE sem token = %+v", got)
+	}
+	deliver(s, a, Message{Type: "MUTE", ClientID: "ana", Auth: ack.Auth})
+	if got := f.last(a); got.Type != "ACK" {
+		t.Fatalf("MUTE com token = %+v", got)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Troca das opções antes da abertura
+// ----------------------------------------------------------
+
+// SetOptions troca as opções de voto enquanto a votação não começou. As
+// contagens voltam a zero com o novo conjunto e os clientes já registrados
+// recebem um OPTIONS com a lista nova.
+func (s *UDPServer) SetOptions(options []string) error {
+	if err := validateOptions(options); err != nil {
+		return err
+	}
+	options = slices.Clone(options)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.votingState != VotingNotStarted {
+		return errors.New("opções só podem ser alteradas antes do início da votação")
+	}
+	if s.decisionRule != nil && !slices.Contains(options, s.decisionRule.Option) {
+		return errors.New("regra de decisão: opção inexistente " + s.decisionRule.Option)
+	}
+
+	s.options = options
+	s.voteCounts = make(map[string]int64, len(options))
+	for _, op := range options {
+		s.voteCounts[op] = 0
+	}
+	s.lastChange = make(map[string]time.Time)
+	log.Printf("[INFO] Opções de voto alteradas: %v", options)
+
+	for _, c := range s.clients {
+		s.send(c.addr, Message{Type: "OPTIONS", Options: options})
+	}
+	return nil
+}
+
+// ----------------------------------------------------------
+// Comparação tolerante das opções no voto
+// ----------------------------------------------------------
+//
+// Por padrão o VOTE precisa trazer a opção exatamente como configurada. Com
+// SetCaseInsensitiveOptions, espaços nas pontas são ignorados e maiúsculas
+// e minúsculas se equivalem: " a" conta para "A". O voto é registrado (e
+// ecoado no ACK, em recorded_option) com a forma configurada. Se duas
+// opções só diferem na caixa, a igual ao texto enviado vence; sem ela, vale
+// a primeira na ordem declarada.
+
+// SetCaseInsensitiveOptions liga a comparação tolerante das opções no voto
+func (s *UDPServer) SetCaseInsensitiveOptions(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caseInsensitive = enabled
+}
+
+// canonicalOptionLocked devolve a opção configurada que corresponde a
+// `option`, ou `option` inalterada se nenhuma corresponder
+func (s *UDPServer) canonicalOptionLocked(option string) string {
+	if !s.caseInsensitive {
+		return option
+	}
+	trimmed := strings.TrimSpace(option)
+	if _, ok := s.voteCounts[trimmed]; ok {
+		return trimmed
+	}
+	for _, op := range s.options {
+		if strings.EqualFold(op, trimmed) {
+			return op
+		}
+	}
+	return option
+}
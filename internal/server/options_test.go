@@ -0,0 +1,188 @@
+package server
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+func TestCaseInsensitiveOptions(t *testing.T) {
+	cases := []struct {
+		sent        string
+		insensitive bool
+		want        string // opção contada ("" = recusado com Opção inválida)
+	}{
+		{"Sim", false, "Sim"},
+		{"sim", false, ""},
+		{" Sim", false, ""},
+		{"sim", true, "Sim"},
+		{" NÃO  ", true, "Não"},
+		{"\tsim\n", true, "Sim"},
+		{"talvez", true, ""},
+		{"", true, ""},
+		{"si m", true, ""},
+	}
+	for _, tc := range cases {
+		s, f := newFakeServer(t, "Sim", "Não")
+		s.SetCaseInsensitiveOptions(tc.insensitive)
+		a := testAddr(1)
+		register(t, s, f, "ana", a)
+		s.StartVoting(60)
+
+		got := vote(s, f, "ana", a, tc.sent)
+		if tc.want == "" {
+			if got.Type != "ERROR" || got.Message != "Opção inválida" {
+				t.Fatalf("%q (insensitive=%v) = %+v, esperava Opção inválida", tc.sent, tc.insensitive, got)
+			}
+			if n := totalVotes(s.Results()); n != 0 {
+				t.Fatalf("%q recusado, mas %d votos contados", tc.sent, n)
+			}
+			continue
+		}
+		if got.Type != "ACK" || got.RecordedOption != tc.want {
+			t.Fatalf("%q (insensitive=%v) = %+v, esperava ACK de %q", tc.sent, tc.insensitive, got, tc.want)
+		}
+		if r := s.Results(); r[tc.want] != 1 || totalVotes(r) != 1 {
+			t.Fatalf("%q: placar = %v, esperava só %s=1", tc.sent, r, tc.want)
+		}
+	}
+}
+
+// Opções que só diferem na caixa: vale a igual ao texto enviado, senão a
+// primeira declarada
+func TestCaseInsensitiveCollision(t *testing.T) {
+	s, f := newFakeServer(t, "abc", "ABC")
+	s.SetCaseInsensitiveOptions(true)
+	for i, tc := range []struct{ sent, want string }{
+		{"ABC", "ABC"},
+		{" abc", "abc"},
+		{"Abc", "abc"},
+	} {
+		a := testAddr(i + 1)
+		id := "v" + string(rune('0'+i))
+		register(t, s, f, id, a)
+		if i == 0 {
+			s.StartVoting(60)
+		}
+		if got := vote(s, f, id, a, tc.sent); got.Type != "ACK" || got.RecordedOption != tc.want {
+			t.Fatalf("%q = %+v, esperava %q", tc.sent, got, tc.want)
+		}
+	}
+	if r := s.Results(); r["abc"] != 2 || r["ABC"] != 1 {
+		t.Fatalf("placar = %v, esperava abc=2 ABC=1", r)
+	}
+}
+
+// O ACK ecoa a opção na forma configurada, também na troca de voto e no
+// reenvio de um pedido já confirmado
+func TestRecordedOptionEcho(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetCaseInsensitiveOptions(true)
+	s.SetAllowRevote(true)
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+	s.StartVoting(60)
+
+	if got := voteReq(s, f, "ana", a, "a", "ana-1"); got.Type != "ACK" || got.RecordedOption != "A" || got.Message != "Voto registrado" {
+		t.Fatalf("voto em \"a\" = %+v, esperava recorded_option A", got)
+	}
+	if got := voteReq(s, f, "ana", a, " b ", "ana-2"); got.Type != "ACK" || got.RecordedOption != "B" || got.Message != "Voto alterado" {
+		t.Fatalf("troca para \" b \" = %+v, esperava recorded_option B", got)
+	}
+	if got := voteReq(s, f, "ana", a, "a", "ana-1"); got.RecordedOption != "A" {
+		t.Fatalf("reenvio de ana-1 = %+v, esperava o ACK original (A)", got)
+	}
+	if r := s.Results(); r["A"] != 0 || r["B"] != 1 {
+		t.Fatalf("placar = %v, esperava A=0 B=1", r)
+	}
+}
+
+// O cliente guarda a opção ecoada pelo servidor, não a digitada
+func TestClientStoresRecordedOption(t *testing.T) {
+	s, err := NewUDPServer([]string{"Sim", "Não"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetCaseInsensitiveOptions(true)
+	startUDP(t, s)
+
+	c, err := client.Dial(s.Addr().String(), "ana", client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Register(); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(60)
+
+	if _, err := c.Vote("  sim"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.RecordedVote(); got != "Sim" {
+		t.Fatalf("RecordedVote = %q, esperava Sim", got)
+	}
+}
+
+// Votação sem escolha não sobe: zero ou uma opção é erro na construção
+func TestNewUDPServerOptionCount(t *testing.T) {
+	cases := []struct {
+		options []string
+		ok      bool
+	}{
+		{nil, false},
+		{[]string{}, false},
+		{[]string{"A"}, false},
+		{[]string{"A", "B"}, true},
+		{[]string{"A", "B", "C"}, true},
+	}
+	for _, tc := range cases {
+		s, err := NewUDPServer(tc.options)
+		if (err == nil) != tc.ok {
+			t.Errorf("NewUDPServer(%q): erro = %v", tc.options, err)
+		}
+		if s != nil && !tc.ok {
+			t.Errorf("NewUDPServer(%q) devolveu servidor junto com o erro", tc.options)
+		}
+	}
+	if _, err := NewUDPServerFromConfig(Config{Addr: ":9000", Duration: 60, Options: []string{"A"}}); err == nil {
+		t.Error("NewUDPServerFromConfig aceitou uma única opção")
+	}
+}
+
+// Antes da abertura as opções podem ser trocadas: quem já registrou recebe
+// a lista nova e o placar zera com ela. Depois da abertura, não.
+func TestSetOptionsBeforeStart(t *testing.T) {
+	s, f := newFakeServer(t)
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+
+	if err := s.SetOptions([]string{"A"}); err == nil {
+		t.Fatal("SetOptions aceitou uma única opção")
+	}
+	if err := s.SetOptions([]string{"Sim", "Não"}); err != nil {
+		t.Fatal(err)
+	}
+	if m := f.last(a); m.Type != "OPTIONS" || !slices.Equal(m.Options, []string{"Sim", "Não"}) {
+		t.Fatalf("cliente registrado recebeu %+v, esperava OPTIONS com a lista nova", m)
+	}
+	if got := s.Results(); !maps.Equal(got, map[string]int64{"Sim": 0, "Não": 0}) {
+		t.Fatalf("placar = %v", got)
+	}
+
+	s.StartVoting(60)
+	deliver(s, a, Message{Type: "VOTE", ClientID: "ana", VoteOption: "A"})
+	if m := f.last(a); m.Type != "ERROR" || m.Message != "Opção inválida" {
+		t.Fatalf("voto em opção antiga = %+v", m)
+	}
+	vote(s, f, "ana", a, "Sim")
+
+	if err := s.SetOptions([]string{"X", "Y"}); err == nil {
+		t.Fatal("SetOptions aceito com a votação aberta")
+	}
+	if got := s.Results(); !maps.Equal(got, map[string]int64{"Sim": 1, "Não": 0}) {
+		t.Fatalf("placar depois da troca recusada = %v", got)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Pausa da votação em andamento
+// ----------------------------------------------------------
+//
+// PauseVoting congela uma votação aberta sem encerrá-la (ex.: para apurar
+// uma contestação): os votos recebidos na pausa são recusados com
+// "Votação pausada" e o timer de encerramento deixa de valer. ResumeVoting
+// reabre a votação com o prazo estendido pelo tempo de pausa, de modo que o
+// tempo de votação não diminui. Os clientes registrados recebem um PAUSE e
+// um RESUME a cada mudança.
+
+// PauseVoting pausa a votação ativa
+func (s *UDPServer) PauseVoting() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Prazo vencido antes do timer: encerra em vez de pausar
+	if s.votingState == VotingActive && s.deadlinePassedLocked(s.now()) {
+		s.endVotingLocked()
+	}
+	if s.votingState != VotingActive {
+		return errors.New("só uma votação ativa pode ser pausada")
+	}
+
+	s.votingState = VotingPaused
+	s.pausedAt = s.now()
+	s.pauses++
+	s.stopEndTimerLocked() // o RESUME agenda o prazo estendido
+	remaining := s.votingDeadline.Sub(s.pausedAt)
+	log.Printf("[PAUSE] Votação pausada (%s restantes)", remaining.Truncate(time.Second))
+
+	s.notifyClientsLocked(Message{Type: "PAUSE", Message: "Votação pausada"})
+	s.saveStateLocked()
+	return nil
+}
+
+// ResumeVoting retoma a votação pausada, estendendo o prazo pelo tempo que
+// ela ficou pausada
+func (s *UDPServer) ResumeVoting() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.votingState != VotingPaused {
+		return errors.New("a votação não está pausada")
+	}
+
+	now := s.now()
+	paused := now.Sub(s.pausedAt)
+	s.votingDeadline = s.votingDeadline.Add(paused)
+	s.votingState = VotingActive
+	s.pausedAt = time.Time{}
+	remaining := s.votingDeadline.Sub(now)
+	log.Printf("[PAUSE] Votação retomada após %s (%s restantes)", paused.Truncate(time.Millisecond), remaining.Truncate(time.Second))
+
+	s.notifyClientsLocked(Message{
+		Type:    "RESUME",
+		Message: fmt.Sprintf("Votação retomada (%s restantes)", remaining.Truncate(time.Second)),
+	})
+	s.scheduleEndLocked(remaining)
+	s.saveStateLocked()
+	return nil
+}
+
+// notifyClientsLocked envia um aviso a todos os clientes registrados
+func (s *UDPServer) notifyClientsLocked(msg Message) {
+	for _, c := range s.clients {
+		s.send(c.addr, msg)
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPausedVoteRejected(t *testing.T) {
+	s, f := newFakeServer(t)
+	a, b := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", a)
+	register(t, s, f, "bia", b)
+	s.StartVoting(60)
+	vote(s, f, "bia", b, "B")
+
+	if err := s.PauseVoting(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.last(a); got.Type != "PAUSE" {
+		t.Fatalf("aviso de pausa = %+v", got)
+	}
+	if got := vote(s, f, "ana", a, "A"); got.Type != "ERROR" || got.Message != "Votação pausada" {
+		t.Fatalf("voto na pausa = %+v", got)
+	}
+	if got := s.Results(); got["A"] != 0 || got["B"] != 1 {
+		t.Fatalf("placar = %v, esperava A=0 B=1", got)
+	}
+	if err := s.PauseVoting(); err == nil {
+		t.Fatal("segunda pausa aceita")
+	}
+
+	if err := s.ResumeVoting(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.last(b); got.Type != "RESUME" {
+		t.Fatalf("aviso de retomada = %+v", got)
+	}
+	// O voto recusado na pausa não ficou marcado: pode votar depois
+	if got := vote(s, f, "ana", a, "A"); got.Type != "ACK" {
+		t.Fatalf("voto após a retomada = %+v", got)
+	}
+	if got := s.Results(); got["A"] != 1 || got["B"] != 1 {
+		t.Fatalf("placar = %v, esperava A=1 B=1", got)
+	}
+	if err := s.ResumeVoting(); err == nil {
+		t.Fatal("retomada sem pausa aceita")
+	}
+}
+
+// O prazo é estendido pelo tempo de pausa: os 60s de votação continuam
+// sendo 60s
+func TestResumeExtendsDeadline(t *testing.T) {
+	s, f := newFakeServer(t)
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := start
+	s.SetClock(func() time.Time { return clock })
+	a, b := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", a)
+	register(t, s, f, "bia", b)
+	s.StartVoting(60)
+
+	clock = start.Add(20 * time.Second)
+	if err := s.PauseVoting(); err != nil {
+		t.Fatal(err)
+	}
+	clock = start.Add(120 * time.Second) // 100s pausada, além do prazo original
+	if err := s.ResumeVoting(); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	deadline := s.votingDeadline
+	s.mu.Unlock()
+	if want := start.Add(160 * time.Second); !deadline.Equal(want) {
+		t.Fatalf("prazo = %s, esperava %s (60s + 100s de pausa)", deadline, want)
+	}
+
+	clock = start.Add(159 * time.Second)
+	if got := vote(s, f, "ana", a, "A"); got.Type != "ACK" {
+		t.Fatalf("voto dentro do prazo estendido = %+v", got)
+	}
+	clock = start.Add(161 * time.Second)
+	if got := vote(s, f, "bia", b, "B"); got.Type != "ERROR" || got.Message != "Votação encerrada" {
+		t.Fatalf("voto após o prazo estendido = %+v", got)
+	}
+	if got := s.Results(); got["A"] != 1 || got["B"] != 0 {
+		t.Fatalf("placar = %v, esperava A=1 B=0", got)
+	}
+}
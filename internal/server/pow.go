@@ -0,0 +1,57 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+	"net"
+)
+
+// ----------------------------------------------------------
+// Prova de trabalho no REGISTER
+// ----------------------------------------------------------
+//
+// Com dificuldade > 0, o primeiro REGISTER recebe um CHALLENGE. O cliente
+// precisa achar um nonce tal que sha256(challenge + ":" + nonce) comece com
+// `difficulty` bits zero e reenviar o REGISTER com challenge e nonce.
+// O desafio é derivado do ID e do endereço com uma chave do servidor,
+// então não há estado guardado por desafio emitido.
+
+// SetRegisterDifficulty define quantos bits zero a prova de trabalho exige.
+// Zero desativa a exigência.
+func (s *UDPServer) SetRegisterDifficulty(difficulty int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.powDifficulty = difficulty
+	if difficulty > 0 && s.powKey == nil {
+		s.powKey = make([]byte, 32)
+		rand.Read(s.powKey)
+	}
+}
+
+// challengeLocked calcula o desafio do par (ID, endereço)
+func (s *UDPServer) challengeLocked(id string, addr *net.UDPAddr) string {
+	mac := hmac.New(sha256.New, s.powKey)
+	mac.Write([]byte(id + "|" + addr.String()))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// powValid confere se o nonce resolve o desafio na dificuldade pedida
+func powValid(challenge, nonce string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	return leadingZeroBits(sum[:]) >= difficulty
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c != 0 {
+			return n + bits.LeadingZeros8(c)
+		}
+		n += 8
+	}
+	return n
+}
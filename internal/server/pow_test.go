@@ -0,0 +1,58 @@
+package server
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Com dificuldade baixa, o REGISTER resolvido é aceito; nonce errado ou
+// desafio de outro endereço não
+func TestRegisterProofOfWork(t *testing.T) {
+	const difficulty = 8
+	s, f := newFakeServer(t)
+	s.SetRegisterDifficulty(difficulty)
+	a, other := testAddr(1), testAddr(2)
+
+	deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", SeqNum: 1})
+	ch := f.last(a)
+	if ch.Type != "CHALLENGE" || ch.Challenge == "" || ch.Difficulty != difficulty || ch.SeqNum != 1 {
+		t.Fatalf("primeiro REGISTER = %+v, esperava CHALLENGE", ch)
+	}
+	if clientCount(s) != 0 {
+		t.Fatal("ID registrado antes da prova de trabalho")
+	}
+
+	// Nonce que não resolve o desafio
+	bad := ""
+	for n := 0; bad == ""; n++ {
+		if nonce := strconv.Itoa(n); !powValid(ch.Challenge, nonce, difficulty) {
+			bad = nonce
+		}
+	}
+	deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", Challenge: ch.Challenge, Nonce: bad, SeqNum: 2})
+	if got := f.last(a); got.Type != "ERROR" || got.Message != "Prova de trabalho inválida" || got.SeqNum != 2 {
+		t.Fatalf("nonce errado = %+v", got)
+	}
+
+	// O desafio vale só para o par (ID, endereço): de outro endereço, um novo
+	nonce := client.SolveChallenge(ch.Challenge, difficulty)
+	deliver(s, other, Message{Type: "REGISTER", ClientID: "ana", Challenge: ch.Challenge, Nonce: nonce})
+	if got := f.last(other); got.Type != "CHALLENGE" || got.Challenge == ch.Challenge {
+		t.Fatalf("desafio reaproveitado de outro endereço = %+v", got)
+	}
+
+	deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", Challenge: ch.Challenge, Nonce: nonce, SeqNum: 3})
+	if got := f.last(a); got.Type != "ACK" || got.SeqNum != 3 || clientCount(s) != 1 {
+		t.Fatalf("REGISTER resolvido = %+v", got)
+	}
+}
+
+// Dificuldade zero volta ao registro direto
+func TestRegisterProofOfWorkDisabled(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetRegisterDifficulty(8)
+	s.SetRegisterDifficulty(0)
+	register(t, s, f, "ana", testAddr(1))
+}
@@ -0,0 +1,40 @@
+package server
+
+import "errors"
+
+// ----------------------------------------------------------
+// Quórum mínimo para o resultado valer
+// ----------------------------------------------------------
+//
+// Com SetQuorum, uma votação que termina com menos votos que o quórum
+// continua sendo apurada e anunciada, mas o resultado final sai marcado
+// como não vinculante (NonBinding) e o broadcast de encerramento traz a
+// mensagem "Quórum não atingido". A contagem usa o total de votos do
+// placar (com SetWeightedVoting, a soma dos pesos).
+
+// SetQuorum define quantos votos o resultado precisa para valer (0 desliga)
+func (s *UDPServer) SetQuorum(n int) error {
+	if n < 0 {
+		return errors.New("quórum não pode ser negativo")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quorum = n
+	return nil
+}
+
+// quorumMissedLocked diz se a votação encerrada ficou abaixo do quórum
+func (s *UDPServer) quorumMissedLocked() bool {
+	if s.quorum <= 0 || s.votingState != VotingEnded {
+		return false
+	}
+	return totalVotes(s.voteCounts) < int64(s.quorum)
+}
+
+// notice é o texto que acompanha o broadcast final
+func (f *FinalResult) notice() string {
+	if f != nil && f.NonBinding {
+		return "Quórum não atingido"
+	}
+	return ""
+}
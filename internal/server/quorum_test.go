@@ -0,0 +1,38 @@
+package server
+
+import "testing"
+
+// Quórum de 3: com 2 votos o resultado final sai não vinculante, com 3 ou 4
+// ele vale
+func TestQuorum(t *testing.T) {
+	for _, tc := range []struct {
+		votes      int
+		nonBinding bool
+	}{{2, true}, {3, false}, {4, false}} {
+		s, f := votingServer(t, false, "ana", "bia", "caio", "duda")
+		if err := s.SetQuorum(3); err != nil {
+			t.Fatal(err)
+		}
+		for i, id := range []string{"ana", "bia", "caio", "duda"}[:tc.votes] {
+			vote(s, f, id, testAddr(i+1), "A")
+		}
+		s.EndVotingNow()
+
+		m := f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+		wantMsg := ""
+		if tc.nonBinding {
+			wantMsg = "Quórum não atingido"
+		}
+		if m.Final.NonBinding != tc.nonBinding || m.Message != wantMsg || m.Final.Quorum != 3 {
+			t.Fatalf("%d votos: final = %q %+v", tc.votes, m.Message, m.Final)
+		}
+		if r := s.GetResults(); r.NonBinding != tc.nonBinding || r.TotalVotes != int64(tc.votes) {
+			t.Fatalf("%d votos: resultado = %+v", tc.votes, r)
+		}
+	}
+
+	s, _ := newFakeServer(t)
+	if err := s.SetQuorum(-1); err == nil {
+		t.Fatal("quórum negativo aceito")
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Token bucket usado pelos limitadores de taxa
+// ----------------------------------------------------------
+
+// tokenBucket repõe `rate` fichas por segundo, acumulando até `burst`.
+// Não é seguro para uso concorrente: o servidor o acessa com s.mu travado.
+type tokenBucket struct {
+	rate   float64   // fichas repostas por segundo
+	burst  float64   // capacidade máxima
+	tokens float64   // fichas disponíveis
+	last   time.Time // última reposição
+}
+
+func newTokenBucket(rate, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// allow consome uma ficha, se houver
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// ----------------------------------------------------------
+// Limite de pacotes por endereço de origem
+// ----------------------------------------------------------
+//
+// Um cliente com defeito (ou malicioso) pode mandar milhares de pacotes
+// por segundo. Com SetSourceRate, cada endereço de origem (IP:porta) tem o
+// próprio bucket; o excesso é descartado em silêncio, sem resposta que
+// amplifique a enxurrada. Buckets de endereços parados são removidos de
+// tempos em tempos. O primário do espelhamento não entra no limite.
+
+// Intervalo entre as limpezas dos buckets de origens paradas
+const sourcePruneInterval = time.Minute
+
+// SetSourceRate limita quantos pacotes cada endereço de origem pode mandar
+// por segundo (com rajada de até perSecond). Zero remove o limite.
+func (s *UDPServer) SetSourceRate(perSecond int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sourceRate = perSecond
+	s.sourceBuckets = make(map[string]*tokenBucket)
+	s.sourcePruned = s.now()
+}
+
+// ThrottledPackets devolve quantos pacotes foram descartados pelo limite por origem
+func (s *UDPServer) ThrottledPackets() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sourceThrottled
+}
+
+// packetAllowedLocked aplica o limite por origem ao pacote vindo de addr
+func (s *UDPServer) packetAllowedLocked(addr *net.UDPAddr) bool {
+	if s.sourceRate <= 0 {
+		return true
+	}
+	if s.fromMirrorSourceLocked(addr) {
+		return true
+	}
+
+	now := s.now()
+	if now.Sub(s.sourcePruned) > sourcePruneInterval {
+		s.pruneSourcesLocked(now)
+	}
+
+	key := addr.String()
+	b, ok := s.sourceBuckets[key]
+	if !ok {
+		b = newTokenBucket(s.sourceRate, s.sourceRate, now)
+		s.sourceBuckets[key] = b
+	}
+	if b.allow(now) {
+		return true
+	}
+	s.sourceThrottled++
+	return false
+}
+
+// pruneSourcesLocked remove os buckets que já voltaram a ficar cheios
+// (origem parada desde então não tem limite a lembrar)
+func (s *UDPServer) pruneSourcesLocked(now time.Time) {
+	for key, b := range s.sourceBuckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst {
+			delete(s.sourceBuckets, key)
+		}
+	}
+	s.sourcePruned = now
+}
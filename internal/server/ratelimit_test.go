@@ -0,0 +1,78 @@
+package server
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Uma rajada de REGISTER com IDs diferentes passa só até o limite por
+// segundo; o resto recebe "Tente novamente". No ritmo do limite, todos entram.
+func TestRegistrationRateBurst(t *testing.T) {
+	s, f := newFakeServer(t)
+	clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return clock })
+	s.SetRegistrationRate(10)
+
+	accepted := 0
+	for i := 1; i <= 100; i++ {
+		a := testAddr(i)
+		deliver(s, a, Message{Type: "REGISTER", ClientID: "r" + strconv.Itoa(i)})
+		switch got := f.last(a); {
+		case got.Type == "ACK":
+			accepted++
+		case got.Type != "ERROR" || got.Message != "Tente novamente":
+			t.Fatalf("REGISTER %d na rajada = %+v", i, got)
+		}
+	}
+	if accepted != 10 || s.ThrottledRegistrations() != 90 || clientCount(s) != 10 {
+		t.Fatalf("aceitos %d, recusados %d, clientes %d; esperava 10, 90 e 10",
+			accepted, s.ThrottledRegistrations(), clientCount(s))
+	}
+
+	// Um registro a cada 100ms cabe no limite
+	for i := 101; i <= 120; i++ {
+		clock = clock.Add(100 * time.Millisecond)
+		register(t, s, f, "r"+strconv.Itoa(i), testAddr(i))
+	}
+	if s.ThrottledRegistrations() != 90 {
+		t.Fatalf("registros no ritmo recusados: %d", s.ThrottledRegistrations()-90)
+	}
+}
+
+// Rajada de 1000 pacotes de um endereço com limite de 20/s: só a rajada
+// inicial passa e o excesso some sem resposta; o cliente bem-comportado em
+// outro endereço não é afetado. Depois de parada, a origem é esquecida.
+func TestSourceRateFlood(t *testing.T) {
+	s, f := newFakeServer(t)
+	clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return clock })
+	s.SetSourceRate(20)
+	bad, good := testAddr(1), testAddr(2)
+	register(t, s, f, "mau", bad)
+	register(t, s, f, "bom", good)
+	s.StartVoting(3600)
+
+	for i := 0; i < 1000; i++ {
+		deliver(s, bad, Message{Type: "VOTE", ClientID: "mau", VoteOption: "A"})
+	}
+	if got := s.ThrottledPackets(); got != 1000-19 {
+		t.Fatalf("%d pacotes descartados, esperava %d", got, 1000-19)
+	}
+	if n := len(f.ofType(bad, "ACK")) + len(f.ofType(bad, "ERROR")); n != 20 {
+		t.Fatalf("%d respostas para a origem da rajada, esperava 20", n)
+	}
+	if got := vote(s, f, "bom", good, "B"); got.Type != "ACK" {
+		t.Fatalf("voto do cliente bem-comportado = %+v", got)
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 1})
+
+	clock = clock.Add(sourcePruneInterval + time.Second)
+	deliver(s, good, Message{Type: "PING", ClientID: "bom"})
+	s.mu.Lock()
+	_, kept := s.sourceBuckets[bad.String()]
+	s.mu.Unlock()
+	if kept {
+		t.Fatal("bucket da origem parada não foi removido")
+	}
+}
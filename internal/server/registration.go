@@ -0,0 +1,199 @@
+package server
+
+import (
+	"crypto/hmac"
+	"errors"
+	"log"
+	"net"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------
+// Política de registro e limite de clientes
+// ----------------------------------------------------------
+
+// Políticas para VOTE de um ID não registrado (SetRegistrationPolicy)
+const (
+	StrictRegistration = ""             // recusa: o cliente precisa enviar REGISTER antes
+	AutoRegisterOnVote = "auto_on_vote" // registra no primeiro voto, com a votação aberta
+)
+
+// Tamanho máximo do ClientID, em bytes
+const maxClientIDLen = 64
+
+// validClientID recusa IDs vazios, longos demais ou com caracteres não
+// imprimíveis: o ID vai para os logs e vira chave dos mapas de clientes
+func validClientID(id string) bool {
+	if id == "" || len(id) > maxClientIDLen || !utf8.ValidString(id) {
+		return false
+	}
+	for _, r := range id {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetRegistrationPolicy define o que acontece com o VOTE de um ID não
+// registrado. Com AutoRegisterOnVote, o ID é registrado no endereço de
+// origem e o voto processado em seguida, desde que o registro normal também
+// fosse aceito (limite de clientes e de taxa). Com prova de trabalho ativa
+// ou versão mínima do cliente (SetMinClientVersion) o registro automático
+// não acontece, pois o desafio e a versão vão no REGISTER; com
+// SetClientSecret também não, pois o token sai no ACK de registro.
+func (s *UDPServer) SetRegistrationPolicy(policy string) error {
+	switch policy {
+	case StrictRegistration, AutoRegisterOnVote:
+	default:
+		return errors.New("política de registro desconhecida: " + policy)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.regPolicy = policy
+	return nil
+}
+
+// SetMaxClients limita quantos clientes podem estar registrados (0 = sem limite)
+func (s *UDPServer) SetMaxClients(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxClients = n
+}
+
+// SetAnnounceJoins envia a cada registro novo um JOIN aos clientes já
+// registrados, com o número de participantes (client_count). Desligado por
+// padrão: em votações grandes, cada registro viraria um pacote por cliente.
+func (s *UDPServer) SetAnnounceJoins(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.announceJoins = on
+}
+
+// admitLocked aplica os limites a um registro novo; devolve o motivo da
+// recusa ("" = aceito)
+func (s *UDPServer) admitLocked() string {
+	if s.maxClients > 0 && len(s.clients) >= s.maxClients {
+		return "Limite de clientes atingido"
+	}
+
+	// Limite global contra enxurradas de REGISTER com IDs diferentes
+	if s.regLimiter != nil && !s.regLimiter.allow(s.now()) {
+		s.regThrottled++
+		return "Tente novamente"
+	}
+	return ""
+}
+
+// addClientLocked registra o ID no endereço de origem
+func (s *UDPServer) addClientLocked(id string, addr *net.UDPAddr, how string) {
+	s.clients[id] = newClientInfo(addr, s.now())
+	s.touchSourceLocked(id, addr)
+	s.metrics.Inc(MetricRegistrations)
+	s.metrics.Gauge(MetricClients, float64(len(s.clients)))
+	log.Printf("[JOIN] %s (%s)%s", id, addr, how)
+
+	if s.announceJoins {
+		notice := Message{Type: "JOIN", ClientID: id, ClientCount: len(s.clients)}
+		for other, c := range s.clients {
+			if other != id {
+				s.send(c.addr, notice)
+			}
+		}
+	}
+}
+
+// rebindLocked move o registro de `id` para `addr` quando o endereço
+// antigo está em silêncio há mais que a janela de multi-endereço (padrão
+// 30s), como numa troca de porta pelo NAT. Com SetClientSecret, a troca
+// exige o token do ID; sem ele, um ID ocioso poderia ser tomado.
+func (s *UDPServer) rebindLocked(id string, addr *net.UDPAddr, auth string) bool {
+	prev := s.clients[id]
+	window := s.multiHomeWindow
+	if window == 0 {
+		window = defaultMultiHomeWindow
+	}
+	if s.now().Sub(prev.lastSeen) <= window {
+		return false
+	}
+	if s.clientSecret != nil && !hmac.Equal([]byte(auth), []byte(s.authTokenLocked(id))) {
+		return false
+	}
+
+	log.Printf("[JOIN] %s mudou de endereço: %s → %s", id, prev.addr, addr)
+	prev.addr = addr
+	prev.lastSeen = s.now()
+	s.touchSourceLocked(id, addr)
+	return true
+}
+
+// unregisterClient atende o UNREGISTER: o cliente sai da lista de
+// destinatários. Só o endereço registrado pode cancelar o próprio ID. O
+// voto já dado continua contado (está na cadeia de auditoria) e o ID não
+// vota de novo se voltar a se registrar.
+func (s *UDPServer) unregisterClient(id string, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.clients[id]
+	if !ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Registre-se primeiro"})
+		return
+	}
+	if prev.addr.String() != addr.String() {
+		s.send(addr, Message{Type: "ERROR", Message: "ID registrado em outro endereço"})
+		return
+	}
+
+	// Os broadcasts percorrem s.clients com o mutex travado, então a
+	// remoção nunca acontece no meio de um envio
+	s.removeClientLocked(id)
+	s.send(addr, Message{Type: "ACK", Message: "Registro cancelado"})
+	log.Printf("[LEAVE] %s (%s) após %s", id, addr, s.now().Sub(prev.registeredAt).Round(time.Second))
+}
+
+// removeClientLocked apaga o ID e o estado por cliente ligado a ele
+func (s *UDPServer) removeClientLocked(id string) {
+	delete(s.clients, id)
+	delete(s.muted, id)
+	delete(s.observers, id)
+	delete(s.delivery, id)
+	delete(s.sources, id)
+	delete(s.conflicts, id)
+	if _, voted := s.votes[id]; !voted {
+		delete(s.delegations, id) // delegação ainda não usada sai junto
+	}
+	s.metrics.Gauge(MetricClients, float64(len(s.clients)))
+}
+
+// autoRegisterLocked tenta registrar no voto um ID desconhecido; devolve o
+// motivo da recusa ("" = registrado)
+func (s *UDPServer) autoRegisterLocked(id string, addr *net.UDPAddr) string {
+	if s.regPolicy != AutoRegisterOnVote || s.powDifficulty > 0 || s.minClientVersion > 0 || s.clientSecret != nil {
+		return "Registre-se primeiro"
+	}
+	if !validClientID(id) {
+		return "ID inválido"
+	}
+	// Fora da votação ativa o ID não é registrado: a recusa é a que o voto
+	// receberia pelo estado
+	if s.votingState == VotingActive && s.deadlinePassedLocked(s.now()) {
+		s.endVotingLocked()
+	}
+	switch s.votingState {
+	case VotingNotStarted:
+		return s.notStartedMessageLocked()
+	case VotingPaused:
+		return "Votação pausada"
+	case VotingEnded:
+		return "Votação encerrada"
+	}
+	if reason := s.admitLocked(); reason != "" {
+		return reason
+	}
+	s.addClientLocked(id, addr, " registrado no voto")
+	return ""
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Um VOTE de ID desconhecido fora da votação ativa não registra o ID e
+// recebe a recusa do estado (antes, derrubava o servidor com nil pointer)
+func TestAutoRegisterNotStarted(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(s *UDPServer, clock *time.Time)
+		want  string
+	}{
+		{"não iniciada", func(s *UDPServer, _ *time.Time) {}, "Votação não iniciada"},
+		{"pausada", func(s *UDPServer, _ *time.Time) {
+			s.StartVoting(60)
+			s.PauseVoting()
+		}, "Votação pausada"},
+		{"encerrada", func(s *UDPServer, _ *time.Time) {
+			s.StartVoting(60)
+			s.EndVotingNow()
+		}, "Votação encerrada"},
+		{"prazo vencido", func(s *UDPServer, clock *time.Time) {
+			s.StartVoting(60)
+			*clock = clock.Add(61 * time.Second)
+		}, "Votação encerrada"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			s.SetClock(func() time.Time { return clock })
+			if err := s.SetRegistrationPolicy(AutoRegisterOnVote); err != nil {
+				t.Fatal(err)
+			}
+			tc.setup(s, &clock)
+
+			a := testAddr(1)
+			got := vote(s, f, "ana", a, "A")
+			if got.Type != "ERROR" || got.Message != tc.want {
+				t.Fatalf("resposta = %+v, esperava ERROR %q", got, tc.want)
+			}
+			if clientCount(s) != 0 {
+				t.Fatalf("ID registrado fora da votação ativa (%d clientes)", clientCount(s))
+			}
+			if got := s.Results()["A"]; got != 0 {
+				t.Fatalf("votos em A = %d, esperava 0", got)
+			}
+		})
+	}
+}
+
+func TestAutoRegisterOnVote(t *testing.T) {
+	s, f := newFakeServer(t)
+	if err := s.SetRegistrationPolicy(AutoRegisterOnVote); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(60)
+
+	a := testAddr(1)
+	if got := vote(s, f, "ana", a, "A"); got.Type != "ACK" {
+		t.Fatalf("voto com registro automático = %+v", got)
+	}
+	if clientCount(s) != 1 || s.Results()["A"] != 1 {
+		t.Fatalf("clientes = %d, placar = %v", clientCount(s), s.Results())
+	}
+
+	// Sem a política, o ID desconhecido continua precisando de REGISTER
+	s2, f2 := newFakeServer(t)
+	s2.StartVoting(60)
+	if got := vote(s2, f2, "ana", a, "A"); got.Type != "ERROR" || got.Message != "Registre-se primeiro" {
+		t.Fatalf("voto sem registro = %+v", got)
+	}
+}
+
+// O registro automático respeita SetMaxClients: o voto que passaria do
+// limite é recusado e não conta, e quem já entrou continua votando
+func TestAutoRegisterClientLimit(t *testing.T) {
+	const limit = 3
+	s, f := newFakeServer(t)
+	if err := s.SetRegistrationPolicy(AutoRegisterOnVote); err != nil {
+		t.Fatal(err)
+	}
+	s.SetMaxClients(limit)
+	s.SetAllowRevote(true)
+	s.StartVoting(60)
+
+	for i := 1; i <= limit; i++ {
+		if got := vote(s, f, "v"+strconv.Itoa(i), testAddr(i), "A"); got.Type != "ACK" {
+			t.Fatalf("voto %d dentro do limite = %+v", i, got)
+		}
+	}
+	extra := testAddr(limit + 1)
+	if got := vote(s, f, "extra", extra, "B"); got.Type != "ERROR" || got.Message != "Limite de clientes atingido" {
+		t.Fatalf("voto além do limite = %+v, esperava ERROR \"Limite de clientes atingido\"", got)
+	}
+	if clientCount(s) != limit || s.Results()["B"] != 0 {
+		t.Fatalf("clientes = %d, placar = %v", clientCount(s), s.Results())
+	}
+
+	// O REGISTER explícito bate no mesmo limite
+	deliver(s, extra, Message{Type: "REGISTER", ClientID: "extra", SeqNum: 4})
+	if got := f.last(extra); got.Type != "ERROR" || got.Message != "Limite de clientes atingido" {
+		t.Fatalf("REGISTER além do limite = %+v", got)
+	}
+	if got := vote(s, f, "v1", testAddr(1), "B"); got.Type != "ACK" || s.Results()["B"] != 1 {
+		t.Fatalf("troca de voto de quem já entrou = %+v (placar %v)", got, s.Results())
+	}
+}
+
+func TestVoteFromOtherAddressRejected(t *testing.T) {
+	s, f := newFakeServer(t)
+	owner, other := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", owner)
+	s.StartVoting(60)
+
+	if got := vote(s, f, "ana", other, "B"); got.Type != "ERROR" || got.Message != "Endereço não corresponde" {
+		t.Fatalf("voto de outro endereço = %+v", got)
+	}
+	if got := vote(s, f, "ana", owner, "A"); got.Type != "ACK" {
+		t.Fatalf("voto do endereço registrado = %+v", got)
+	}
+	if got := s.Results(); got["A"] != 1 || got["B"] != 0 {
+		t.Fatalf("placar = %v, esperava A=1 B=0", got)
+	}
+}
+
+var clientIDCases = []struct {
+	name string
+	id   string
+	ok   bool
+}{
+	{"válido", "ana", true},
+	{"acentuado", "joão-ç", true},
+	{"no limite", strings.Repeat("a", maxClientIDLen), true},
+	{"vazio", "", false},
+	{"longo demais", strings.Repeat("a", maxClientIDLen+1), false},
+	{"escape ANSI", "\x1b[31mana", false},
+	{"quebra de linha", "ana\nFORJADO", false},
+	{"byte nulo", "ana\x00", false},
+}
+
+// REGISTER com ID inválido recebe ERROR com o SeqNum do pedido e não entra
+// na lista de clientes
+func TestRegisterClientIDValidation(t *testing.T) {
+	for _, tc := range clientIDCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			a := testAddr(1)
+			deliver(s, a, Message{Type: "REGISTER", ClientID: tc.id, SeqNum: 7})
+			got := f.last(a)
+			if tc.ok {
+				if got.Type != "ACK" || clientCount(s) != 1 {
+					t.Fatalf("ID %q recusado: %+v", tc.id, got)
+				}
+				return
+			}
+			if got.Type != "ERROR" || got.Message != "ID inválido" || got.SeqNum != 7 {
+				t.Fatalf("ID %q: resposta = %+v, esperava ERROR \"ID inválido\" com SeqNum 7", tc.id, got)
+			}
+			if clientCount(s) != 0 {
+				t.Fatalf("ID %q registrado", tc.id)
+			}
+		})
+	}
+}
+
+// O registro automático no voto aplica a mesma validação do REGISTER
+func TestAutoRegisterClientIDValidation(t *testing.T) {
+	for _, tc := range clientIDCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			if err := s.SetRegistrationPolicy(AutoRegisterOnVote); err != nil {
+				t.Fatal(err)
+			}
+			s.StartVoting(60)
+			got := vote(s, f, tc.id, testAddr(1), "A")
+			if tc.ok {
+				if got.Type != "ACK" || s.Results()["A"] != 1 {
+					t.Fatalf("ID %q recusado no voto: %+v", tc.id, got)
+				}
+				return
+			}
+			if got.Type != "ERROR" || got.Message != "ID inválido" {
+				t.Fatalf("ID %q: resposta = %+v, esperava ERROR \"ID inválido\"", tc.id, got)
+			}
+			if clientCount(s) != 0 || s.Results()["A"] != 0 {
+				t.Fatalf("ID %q registrado no voto (clientes = %d, placar = %v)", tc.id, clientCount(s), s.Results())
+			}
+		})
+	}
+}
+
+// clientCount devolve quantos IDs estão registrados
+func clientCount(s *UDPServer) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Com SetAnnounceJoins, cada registro novo chega aos já registrados como
+// JOIN com o número de participantes; desligado, ninguém é avisado
+func TestAnnounceJoins(t *testing.T) {
+	for _, on := range []bool{true, false} {
+		s, f := newFakeServer(t)
+		s.SetAnnounceJoins(on)
+		a, b, c := testAddr(1), testAddr(2), testAddr(3)
+		register(t, s, f, "ana", a)
+		register(t, s, f, "bia", b)
+		register(t, s, f, "bia", b) // retransmissão: não é registro novo
+		register(t, s, f, "caio", c)
+
+		joins := f.ofType(a, "JOIN")
+		if !on {
+			if len(joins) != 0 {
+				t.Fatalf("JOIN com anúncio desligado: %+v", joins)
+			}
+			continue
+		}
+		if len(joins) != 2 || joins[0].ClientID != "bia" || joins[0].ClientCount != 2 ||
+			joins[1].ClientID != "caio" || joins[1].ClientCount != 3 {
+			t.Fatalf("JOINs para ana = %+v", joins)
+		}
+		if got := f.ofType(b, "JOIN"); len(got) != 1 || got[0].ClientID != "caio" {
+			t.Fatalf("JOINs para bia = %+v", got)
+		}
+		if got := f.ofType(c, "JOIN"); len(got) != 0 {
+			t.Fatalf("novo cliente recebeu o próprio JOIN: %+v", got)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package server
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// ----------------------------------------------------------
+// Retransmissão, replay e reordenação de votos
+// ----------------------------------------------------------
+//
+// Cada cenário confere o placar exato: um voto por votante (ou o que as
+// regras configuradas mandam, como a troca de voto) não importa quantas
+// cópias de cada VOTE cheguem nem em que ordem.
+
+// voteReq envia um VOTE com RequestID e devolve a resposta direta
+func voteReq(s *UDPServer, f *fakeConn, id string, addr *net.UDPAddr, option, reqID string) Message {
+	deliver(s, addr, Message{Type: "VOTE", ClientID: id, VoteOption: option, RequestID: reqID})
+	return f.last(addr)
+}
+
+// wantTally confere o placar inteiro
+func wantTally(t *testing.T, s *UDPServer, want map[string]int64) {
+	t.Helper()
+	got := s.Results()
+	for op, n := range want {
+		if got[op] != n {
+			t.Fatalf("placar = %v, esperava %v", got, want)
+		}
+	}
+	if totalVotes(got) != totalVotes(want) {
+		t.Fatalf("placar = %v, esperava %v", got, want)
+	}
+}
+
+// votingServer registra os votantes (ana, bia, ...) e abre a votação
+func votingServer(t *testing.T, revote bool, voters ...string) (*UDPServer, *fakeConn) {
+	t.Helper()
+	s, f := newFakeServer(t)
+	s.SetAllowRevote(revote)
+	for i, id := range voters {
+		register(t, s, f, id, testAddr(i+1))
+	}
+	s.StartVoting(3600)
+	return s, f
+}
+
+// ACK perdido: o cliente reenvia o mesmo VOTE e recebe o mesmo ACK, sem
+// contar de novo
+func TestReplayRetransmitAfterLostAck(t *testing.T) {
+	for _, revote := range []bool{false, true} {
+		s, f := votingServer(t, revote, "ana")
+		a := testAddr(1)
+
+		first := voteReq(s, f, "ana", a, "A", "ana-1")
+		for i := 0; i < 3; i++ {
+			again := voteReq(s, f, "ana", a, "A", "ana-1")
+			if again.Type != "ACK" || again.Message != first.Message ||
+				again.RequestID != "ana-1" || again.RecordedOption != "A" {
+				t.Fatalf("revote=%v: reenvio %d = %+v, esperava o ACK original %+v", revote, i+1, again, first)
+			}
+		}
+		wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+		if st := s.Stats(); st.VotesChanged != 0 {
+			t.Fatalf("revote=%v: reenvio contado como troca (%d)", revote, st.VotesChanged)
+		}
+	}
+}
+
+// Datagrama antigo repetido depois de uma troca de voto não desfaz a troca
+func TestReplayOldDatagramAfterChange(t *testing.T) {
+	s, f := votingServer(t, true, "ana")
+	a := testAddr(1)
+
+	voteReq(s, f, "ana", a, "A", "ana-1")
+	if got := voteReq(s, f, "ana", a, "B", "ana-2"); got.Type != "ACK" || got.Message != "Voto alterado" {
+		t.Fatalf("troca = %+v", got)
+	}
+	replayed := voteReq(s, f, "ana", a, "A", "ana-1")
+	if replayed.Type != "ACK" || replayed.RecordedOption != "A" {
+		t.Fatalf("replay = %+v, esperava o ACK original", replayed)
+	}
+	wantTally(t, s, map[string]int64{"A": 0, "B": 1})
+	if st := s.Stats(); st.VotesChanged != 1 {
+		t.Fatalf("trocas = %d, esperava 1", st.VotesChanged)
+	}
+}
+
+// Sem troca de voto, o replay de um VOTE sem RequestID é só duplicado
+func TestReplayWithoutRequestID(t *testing.T) {
+	s, f := votingServer(t, false, "ana")
+	a := testAddr(1)
+
+	vote(s, f, "ana", a, "A")
+	for i := 0; i < 3; i++ {
+		if got := vote(s, f, "ana", a, "A"); got.Type != "ERROR" || got.Message != "Voto duplicado" {
+			t.Fatalf("replay %d = %+v", i+1, got)
+		}
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+}
+
+// RequestID reaproveitado para outra opção não troca o voto: vale o que o
+// servidor já confirmou para aquele pedido
+func TestReplayRequestIDReusedForChange(t *testing.T) {
+	s, f := votingServer(t, true, "ana")
+	a := testAddr(1)
+
+	voteReq(s, f, "ana", a, "A", "ana-1")
+	got := voteReq(s, f, "ana", a, "B", "ana-1")
+	if got.Type != "ACK" || got.RecordedOption != "A" {
+		t.Fatalf("RequestID reaproveitado = %+v, esperava o ACK de A", got)
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+}
+
+// O replay de uma votação anterior não conta na seguinte: o pedido era de
+// outra rodada
+func TestReplayAcrossRounds(t *testing.T) {
+	s, f := votingServer(t, false, "ana")
+	a := testAddr(1)
+	voteReq(s, f, "ana", a, "A", "ana-1")
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetVoting([]string{"X", "Y"}); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(3600)
+
+	if got := voteReq(s, f, "ana", a, "A", "ana-1"); got.Type != "ERROR" || got.Message != "Opção inválida" {
+		t.Fatalf("replay na rodada seguinte = %+v", got)
+	}
+	wantTally(t, s, map[string]int64{"X": 0, "Y": 0})
+}
+
+// Votos reordenados: cada votante conta uma vez. Sem troca vale o primeiro
+// a chegar; com troca, o último a chegar.
+func TestReplayReorderedVotes(t *testing.T) {
+	cases := []struct {
+		revote bool
+		want   map[string]int64
+	}{
+		{false, map[string]int64{"A": 0, "B": 2}},
+		{true, map[string]int64{"A": 2, "B": 0}},
+	}
+	for _, tc := range cases {
+		s, f := votingServer(t, tc.revote, "ana", "bia")
+		// Cada votante mandou A e depois B; os pacotes chegam invertidos,
+		// com uma cópia atrasada de cada
+		for i, id := range []string{"ana", "bia"} {
+			addr := testAddr(i + 1)
+			voteReq(s, f, id, addr, "B", id+"-2")
+			voteReq(s, f, id, addr, "B", id+"-2")
+			voteReq(s, f, id, addr, "A", id+"-1")
+			voteReq(s, f, id, addr, "A", id+"-1")
+		}
+		wantTally(t, s, tc.want)
+	}
+}
+
+// Muitas cópias do mesmo datagrama pelo readLoop e pelos workers, em
+// paralelo: o voto conta uma vez e cada cópia recebe o mesmo ACK
+func TestReplayConcurrentCopies(t *testing.T) {
+	s, f := newFakeServer(t)
+	serveFake(s, f)
+	voters := []string{"ana", "bia", "caio", "duda"}
+	for i, id := range voters {
+		f.inject(testAddr(i+1), Message{Type: "REGISTER", ClientID: id})
+	}
+	for i := range voters {
+		f.waitFor(t, testAddr(i+1), func(m Message) bool { return m.Type == "ACK" })
+	}
+	s.StartVoting(3600)
+
+	const copies = 20
+	var wg sync.WaitGroup
+	for i, id := range voters {
+		i, id := i, id
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < copies; n++ {
+				f.inject(testAddr(i+1), Message{Type: "VOTE", ClientID: id, VoteOption: "A", RequestID: id + "-1"})
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range voters {
+		addr := testAddr(i + 1)
+		deadline := time.Now().Add(waitTimeout)
+		for countAcks(f, addr) < copies && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if n := countAcks(f, addr); n != copies {
+			t.Fatalf("%s: %d respostas, esperava %d", voters[i], n, copies)
+		}
+	}
+	stopFake(s, f)
+	wantTally(t, s, map[string]int64{"A": int64(len(voters)), "B": 0})
+}
+
+// countAcks conta os ACKs de voto enviados a addr
+func countAcks(f *fakeConn, addr *net.UDPAddr) int {
+	n := 0
+	for _, m := range f.ofType(addr, "ACK") {
+		if m.RequestID != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// ----------------------------------------------------------
+// Pela rede de verdade, com o cliente retransmitindo
+// ----------------------------------------------------------
+
+// dropProxy repassa datagramas entre um cliente e o servidor, descartando
+// as primeiras `drop` respostas do servidor que satisfazem match
+type dropProxy struct {
+	conn *net.UDPConn
+
+	mu      sync.Mutex
+	client  *net.UDPAddr
+	drop    int
+	dropped int
+	match   func([]byte) bool
+}
+
+func newDropProxy(t *testing.T, server net.Addr, drop int, match func([]byte) bool) *dropProxy {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, err := net.DialUDP("udp", nil, server.(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &dropProxy{conn: conn, drop: drop, match: match}
+	t.Cleanup(func() { conn.Close(); up.Close() })
+
+	// cliente → servidor
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			p.mu.Lock()
+			p.client = from
+			p.mu.Unlock()
+			up.Write(buf[:n])
+		}
+	}()
+	// servidor → cliente
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := up.Read(buf)
+			if err != nil {
+				return
+			}
+			p.mu.Lock()
+			to := p.client
+			skip := p.dropped < p.drop && p.match(buf[:n])
+			if skip {
+				p.dropped++
+			}
+			p.mu.Unlock()
+			if !skip && to != nil {
+				conn.WriteToUDP(buf[:n], to)
+			}
+		}
+	}()
+	return p
+}
+
+func TestReplayClientRetransmitsLostAck(t *testing.T) {
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start("127.0.0.1:0") }()
+	select {
+	case <-s.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+
+	// Perde os dois primeiros ACKs do voto; o terceiro envio é confirmado
+	isVoteAck := func(b []byte) bool { return containsAll(string(b), `"type":"ACK"`, `"request_id"`) }
+	proxy := newDropProxy(t, s.Addr(), 2, isVoteAck)
+
+	var retries atomic.Int32
+	c, err := client.Dial(proxy.conn.LocalAddr().String(), "ana", client.Options{
+		VoteTimeout: 50 * time.Millisecond,
+		VoteRetries: 5,
+		OnVoteRetry: func(string, int) { retries.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Register(); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(3600)
+
+	if _, err := c.Vote("A"); err != nil {
+		t.Fatalf("voto com ACKs perdidos: %v", err)
+	}
+	if n := retries.Load(); n < 2 {
+		t.Fatalf("%d reenvios, esperava pelo menos 2", n)
+	}
+	if c.RecordedVote() != "A" {
+		t.Fatalf("opção registrada = %q, esperava A", c.RecordedVote())
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+	if st := s.Stats(); st.VotesReceived < 3 {
+		t.Fatalf("servidor recebeu %d VOTE, esperava as 3 cópias", st.VotesReceived)
+	}
+}
+
+// containsAll diz se s contém todos os trechos
+func containsAll(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
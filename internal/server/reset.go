@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Nova votação sem reiniciar o servidor
+// ----------------------------------------------------------
+//
+// Encerrada uma votação, ResetVoting prepara a próxima com outras opções:
+// votos, contagens e delegações recomeçam do zero e o estado volta a
+// VotingNotStarted, pronto para um novo StartVoting. Os clientes continuam
+// registrados e recebem um NEW_ROUND com as opções novas. Como no segundo
+// turno, a cadeia de auditoria continua e a nova votação ganha o número de
+// rodada seguinte, para a apuração não misturar os votos das duas.
+
+// ResetVoting prepara uma nova votação com `options`. Só é aceito depois do
+// encerramento da votação atual.
+func (s *UDPServer) ResetVoting(options []string) error {
+	if err := validateOptions(options); err != nil {
+		return err
+	}
+	options = slices.Clone(options)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.votingState != VotingEnded {
+		return errors.New("nova votação só pode ser preparada depois do encerramento")
+	}
+	if s.decisionRule != nil && !slices.Contains(options, s.decisionRule.Option) {
+		return errors.New("regra de decisão: opção inexistente " + s.decisionRule.Option)
+	}
+
+	// Nenhum timer da votação anterior sobrevive à nova
+	s.stopEndTimerLocked()
+	s.round++
+	s.pollRound = s.round
+	s.options = options
+	s.voteCounts = make(map[string]int64, len(options))
+	for _, op := range options {
+		s.voteCounts[op] = 0
+	}
+	s.votes = make(map[string]string)
+	s.voteWeights = make(map[string]int64)
+	s.lastChange = make(map[string]time.Time)
+	s.delegations = make(map[string]string)
+	s.final = nil
+	s.persisted = false
+	s.compacted = false
+	s.openAt = time.Time{}
+	s.votingOpened = time.Time{}
+	s.votingState = VotingNotStarted
+	log.Printf("[ROUND] Nova votação #%d preparada: %v", s.round, options)
+
+	notice := Message{
+		Type:    "NEW_ROUND",
+		Message: "Nova votação",
+		Options: options,
+		Round:   s.round,
+	}
+	for _, c := range s.clients {
+		s.send(c.addr, notice)
+	}
+	s.saveStateLocked()
+	return nil
+}
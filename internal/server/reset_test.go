@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// Duas votações seguidas no mesmo processo: a segunda começa zerada, os
+// clientes continuam registrados e a auditoria da rodada nova conta só os
+// votos dela
+func TestResetVotingTwoRounds(t *testing.T) {
+	var audit bytes.Buffer
+	voters := []string{"ana", "bia", "caio"}
+	s, f := votingServer(t, false, voters...)
+	s.SetAuditLog(&audit)
+	for i, op := range []string{"A", "B", "A"} {
+		if got := vote(s, f, voters[i], testAddr(i+1), op); got.Type != "ACK" {
+			t.Fatalf("rodada 1, %s = %+v", voters[i], got)
+		}
+	}
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	wantTally(t, s, map[string]int64{"A": 2, "B": 1})
+	first := s.Round()
+
+	if err := s.ResetVoting([]string{"A", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Round() != first+1 || s.State() != VotingNotStarted {
+		t.Fatalf("depois do reset: rodada %d, estado %v", s.Round(), s.State())
+	}
+	for i := 1; i <= 3; i++ {
+		got := f.last(testAddr(i))
+		if got.Type != "NEW_ROUND" || got.Round != first+1 || len(got.Options) != 2 {
+			t.Fatalf("cliente %d recebeu %+v, esperava NEW_ROUND da rodada %d", i, got, first+1)
+		}
+	}
+	wantTally(t, s, map[string]int64{"A": 0, "C": 0})
+	if clientCount(s) != 3 {
+		t.Fatalf("%d clientes depois do reset, esperava 3", clientCount(s))
+	}
+
+	// Quem votou na primeira pode votar de novo; caio se abstém
+	s.StartVoting(3600)
+	if got := vote(s, f, "ana", testAddr(1), "C"); got.Type != "ACK" {
+		t.Fatalf("rodada 2, ana = %+v", got)
+	}
+	if got := vote(s, f, "bia", testAddr(2), "A"); got.Type != "ACK" {
+		t.Fatalf("rodada 2, bia = %+v", got)
+	}
+	if got := vote(s, f, "bia", testAddr(2), "B"); got.Type != "ERROR" {
+		t.Fatalf("opção da rodada anterior aceita: %+v", got)
+	}
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "C": 1})
+
+	records, err := ReadAuditLog(&audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyAuditChain(records); err != nil {
+		t.Fatalf("cadeia quebrada entre as rodadas: %v", err)
+	}
+	var round []AuditRecord
+	for _, rec := range records {
+		if rec.Round == s.Round() {
+			round = append(round, rec)
+		}
+	}
+	got := ReplayAudit(round)
+	if len(got) != 2 || got["A"] != 1 || got["C"] != 1 {
+		t.Fatalf("auditoria da rodada 2 = %v, esperava A=1 C=1", got)
+	}
+}
+
+// Só uma votação encerrada pode ser substituída
+func TestResetVotingRequiresEnded(t *testing.T) {
+	s, f := votingServer(t, false, "ana")
+	vote(s, f, "ana", testAddr(1), "A")
+	if err := s.ResetVoting([]string{"X", "Y"}); err == nil {
+		t.Fatal("ResetVoting aceito com a votação aberta")
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetVoting([]string{"X"}); err == nil {
+		t.Fatal("ResetVoting aceito com uma opção só")
+	}
+}
+
+// Com SetCompactOnEnd, a liberação de memória depois do final descarta os
+// votos da rodada mas não os registros: a rodada seguinte aceita os mesmos
+// clientes sem novo REGISTER
+func TestResetAfterCompaction(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia")
+	s.SetResultsFile(filepath.Join(t.TempDir(), "resultado.json"))
+	s.SetCompactOnEnd(true)
+	vote(s, f, "ana", testAddr(1), "A")
+	vote(s, f, "bia", testAddr(2), "B")
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+	s.mu.Lock()
+	compacted, votes := s.compacted, len(s.votes)
+	s.mu.Unlock()
+	if !compacted || votes != 0 {
+		t.Fatalf("compactada=%v com %d votos guardados", compacted, votes)
+	}
+	if clientCount(s) != 2 {
+		t.Fatalf("%d clientes depois da compactação, esperava 2", clientCount(s))
+	}
+	if r := s.GetResults(); r.VoteCounts["A"] != 1 || r.VoteCounts["B"] != 1 {
+		t.Fatalf("resultado depois da compactação = %v", r.VoteCounts)
+	}
+
+	if err := s.ResetVoting([]string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(3600)
+	for i, id := range []string{"ana", "bia"} {
+		if got := vote(s, f, id, testAddr(i+1), "A"); got.Type != "ACK" {
+			t.Fatalf("%s na rodada seguinte à compactação = %+v", id, got)
+		}
+	}
+	// Reconexão do mesmo endereço continua sendo retransmissão do registro
+	register(t, s, f, "ana", testAddr(1))
+	wantTally(t, s, map[string]int64{"A": 2, "B": 0})
+}
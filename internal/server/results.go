@@ -0,0 +1,294 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// OptionCount é a contagem de uma opção na forma ordenada do resultado
+type OptionCount struct {
+	Option string `json:"option"`
+	Votes  int64  `json:"votes"`
+}
+
+// OrderedResults lista as contagens na ordem em que as opções foram declaradas
+// (não alfabética). Opções presentes apenas em counts vão ao final, em ordem
+// alfabética, para que a saída seja sempre determinística.
+func OrderedResults(options []string, counts map[string]int64) []OptionCount {
+	out := make([]OptionCount, 0, len(counts))
+	declared := make(map[string]bool, len(options))
+	for _, op := range options {
+		declared[op] = true
+		out = append(out, OptionCount{Option: op, Votes: counts[op]})
+	}
+
+	var extra []string
+	for op := range counts {
+		if !declared[op] {
+			extra = append(extra, op)
+		}
+	}
+	sort.Strings(extra)
+	for _, op := range extra {
+		out = append(out, OptionCount{Option: op, Votes: counts[op]})
+	}
+	return out
+}
+
+// MarshalResults serializa o resultado ordenado; para o mesmo placar e a
+// mesma ordem de opções, os bytes gerados são sempre idênticos
+func MarshalResults(options []string, counts map[string]int64) ([]byte, error) {
+	return json.Marshal(OrderedResults(options, counts))
+}
+
+// totalVotes soma as contagens. O servidor não conta votos além de
+// math.MaxInt64 no total (tallyFullLocked), então a soma nunca estoura.
+func totalVotes(counts map[string]int64) int64 {
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// ----------------------------------------------------------
+// Resultado exportado da votação
+// ----------------------------------------------------------
+
+// Results é o retrato da apuração, usado na exportação e por quem
+// consulta o servidor sem passar pelo UDP.
+type Results struct {
+	TakenAt     time.Time        `json:"taken_at"`               // momento do retrato
+	OpenedAt    *time.Time       `json:"opened_at,omitempty"`    // abertura da votação (ausente antes dela)
+	SnapshotSeq int              `json:"snapshot_seq,omitempty"` // número do snapshot (SnapshotNow)
+	SeqNum      int              `json:"seq_num"`                // último broadcast emitido até então
+	State       VotingState      `json:"state"`
+	Round       int              `json:"round,omitempty"` // segundo turno (0 = votação original)
+	VoteCounts  map[string]int64 `json:"vote_counts"`
+	TotalVotes  int64            `json:"total_votes"`
+	Ordered     []OptionCount    `json:"results"`               // mesmas contagens, na ordem declarada
+	ChainHash   string           `json:"chain_hash,omitempty"`  // selo da cadeia de auditoria
+	Decision    string           `json:"decision,omitempty"`    // approved | rejected (com DecisionRule)
+	Winner      string           `json:"winner,omitempty"`      // opção vencedora (após o desempate)
+	Tied        []string         `json:"tied,omitempty"`        // empatadas na liderança, sem vencedor
+	RunnerUp    string           `json:"runner_up,omitempty"`   // segundo colocado (após o desempate)
+	Margin      int64            `json:"margin"`                // votos do vencedor menos os do segundo
+	MarginPct   float64          `json:"margin_pct"`            // mesma vantagem, em % do total de votos
+	Quorum      int              `json:"quorum,omitempty"`      // votos mínimos exigidos (SetQuorum)
+	NonBinding  bool             `json:"non_binding,omitempty"` // encerrada abaixo do quórum
+}
+
+// GetResults devolve uma cópia da apuração atual
+func (s *UDPServer) GetResults() Results {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Após a compactação, o retrato final é a única fonte completa
+	if s.compacted && s.final != nil {
+		return s.final.clone()
+	}
+	return s.resultsLocked()
+}
+
+// Results devolve uma cópia da contagem atual por opção. Alterar o map
+// devolvido não afeta o servidor.
+func (s *UDPServer) Results() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := make(map[string]int64, len(s.voteCounts))
+	for op, n := range s.voteCounts {
+		c[op] = n
+	}
+	return c
+}
+
+// State devolve o estado atual da votação
+func (s *UDPServer) State() VotingState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.votingState
+}
+
+func (s *UDPServer) resultsLocked() Results {
+	r := Results{
+		TakenAt:    s.now(),
+		SeqNum:     s.broadcastSeq,
+		State:      s.votingState,
+		Round:      s.round,
+		VoteCounts: make(map[string]int64, len(s.voteCounts)),
+		ChainHash:  s.chainHash,
+		Decision:   s.decisionLocked(),
+		Winner:     s.winnerLocked(),
+		Tied:       s.tiedLocked(),
+	}
+	for op, n := range s.voteCounts {
+		r.VoteCounts[op] = n
+	}
+	r.TotalVotes = totalVotes(s.voteCounts)
+	r.Ordered = OrderedResults(s.options, s.voteCounts)
+	r.RunnerUp, r.Margin, r.MarginPct = s.marginLocked()
+	r.Quorum, r.NonBinding = s.quorum, s.quorumMissedLocked()
+	if !s.votingOpened.IsZero() {
+		opened := s.votingOpened
+		r.OpenedAt = &opened
+	}
+	return r
+}
+
+// clone devolve uma cópia independente (os maps não são compartilhados)
+func (r Results) clone() Results {
+	c := r
+	c.VoteCounts = make(map[string]int64, len(r.VoteCounts))
+	for op, n := range r.VoteCounts {
+		c.VoteCounts[op] = n
+	}
+	c.Ordered = append([]OptionCount(nil), r.Ordered...)
+	c.Tied = append([]string(nil), r.Tied...)
+	return c
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// SNAPSHOTS OFICIAIS
+///////////////////////////////////////////////////////////////////////////////
+
+// Quantidade padrão de snapshots mantidos em memória
+const defaultSnapshotRetention = 24
+
+// SnapshotNow congela o placar atual em um snapshot numerado e datado, sem
+// afetar a votação. Os snapshots mais antigos são descartados além do limite
+// de retenção; com SetSnapshotDir, cada um também é gravado em disco.
+func (s *UDPServer) SnapshotNow() Results {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshotSeq++
+	r := s.resultsLocked()
+	r.SnapshotSeq = s.snapshotSeq
+
+	s.snapshots = append(s.snapshots, r)
+	s.snapshotBytes += jsonSize(r)
+	if extra := len(s.snapshots) - s.snapshotRetention; extra > 0 {
+		s.trimSnapshotsLocked(extra)
+	}
+	s.enforceBudgetLocked()
+
+	if s.snapshotDir != "" {
+		path := filepath.Join(s.snapshotDir, fmt.Sprintf("snapshot-%04d.json", r.SnapshotSeq))
+		if err := writeResultsFile(path, s.exportLocked(r)); err != nil {
+			log.Println("[SNAPSHOT] Erro ao gravar snapshot:", err)
+		}
+	}
+
+	log.Printf("[SNAPSHOT] #%d %v", r.SnapshotSeq, r.VoteCounts)
+	return r.clone()
+}
+
+// Snapshots lista os snapshots retidos, do mais antigo ao mais recente
+func (s *UDPServer) Snapshots() []Results {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Results, len(s.snapshots))
+	for i, r := range s.snapshots {
+		out[i] = r.clone()
+	}
+	return out
+}
+
+// Snapshot devolve o snapshot de número seq, se ainda estiver retido
+func (s *UDPServer) Snapshot(seq int) (Results, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.snapshots {
+		if r.SnapshotSeq == seq {
+			return r.clone(), true
+		}
+	}
+	return Results{}, false
+}
+
+// SetSnapshotDir define o diretório onde os snapshots são gravados (vazio = só memória)
+func (s *UDPServer) SetSnapshotDir(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshotDir = dir
+}
+
+// SetSnapshotRetention define quantos snapshots ficam em memória
+func (s *UDPServer) SetSnapshotRetention(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n < 1 {
+		n = 1
+	}
+	s.snapshotRetention = n
+	if extra := len(s.snapshots) - n; extra > 0 {
+		s.trimSnapshotsLocked(extra)
+		s.enforceBudgetLocked()
+	}
+}
+
+// SetResultsFile define o arquivo onde o resultado final é gravado
+// ao encerrar a votação. Vazio desativa a exportação.
+func (s *UDPServer) SetResultsFile(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resultsFile = path
+}
+
+// writeResultsLocked exporta o resultado, se houver arquivo configurado,
+// e informa se ele ficou gravado
+func (s *UDPServer) writeResultsLocked(r Results) bool {
+	if s.resultsFile == "" {
+		return false
+	}
+	if err := writeResultsFile(s.resultsFile, s.exportLocked(r)); err != nil {
+		log.Println("[RESULTS] Erro ao exportar resultado:", err)
+		return false
+	}
+	return true
+}
+
+// writeResultsFile grava r no formato lido por ReadResults, trocando o
+// arquivo de uma vez (quem o lê durante a gravação nunca vê JSON cortado)
+func writeResultsFile(path string, r Results) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// ReadResults lê um resultado exportado por SetResultsFile
+func ReadResults(path string) (Results, error) {
+	var r Results
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(data, &r)
+	return r, err
+}
+
+// ReplayAudit recalcula a contagem de votos a partir dos registros de
+// auditoria. Vale o último registro de cada cliente (SetAllowRevote), com
+// o peso gravado nele (SetWeightedVoting).
+func ReplayAudit(records []AuditRecord) map[string]int64 {
+	last := make(map[string]AuditRecord, len(records))
+	for _, rec := range records {
+		last[rec.ClientID] = rec
+	}
+	counts := make(map[string]int64)
+	for _, rec := range last {
+		counts[rec.Option] += rec.weight()
+	}
+	return counts
+}
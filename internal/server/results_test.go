@@ -0,0 +1,213 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Saída byte a byte estável: opções na ordem declarada e, no fim, as que só
+// aparecem no placar, em ordem alfabética
+func TestMarshalResultsGolden(t *testing.T) {
+	options := []string{"Sim", "Não", "Abstenção"}
+	counts := map[string]int64{"Abstenção": 1, "Sim": 10, "Não": 7, "Zeta": 2, "Beta": 0}
+	const golden = `[{"option":"Sim","votes":10},{"option":"Não","votes":7},{"option":"Abstenção","votes":1},` +
+		`{"option":"Beta","votes":0},{"option":"Zeta","votes":2}]`
+
+	for i := 0; i < 50; i++ {
+		got, err := MarshalResults(options, counts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != golden {
+			t.Fatalf("MarshalResults =\n%s\nesperava\n%s", got, golden)
+		}
+	}
+
+	// Opção declarada sem entrada no placar aparece com zero
+	got, _ := MarshalResults([]string{"B", "A"}, map[string]int64{"A": 3})
+	if string(got) != `[{"option":"B","votes":0},{"option":"A","votes":3}]` {
+		t.Fatalf("opção sem contagem: %s", got)
+	}
+}
+
+// O broadcast leva a mesma forma ordenada, na ordem do NewUDPServer
+func TestBroadcastOrderedResults(t *testing.T) {
+	s, f := newFakeServer(t, "C", "A", "B")
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+	s.StartVoting(60)
+	vote(s, f, "ana", a, "B")
+
+	b := f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts["B"] == 1 })
+	want := []OptionCount{{"C", 0}, {"A", 0}, {"B", 1}}
+	if len(b.Results) != len(want) {
+		t.Fatalf("results = %+v, esperava %+v", b.Results, want)
+	}
+	for i := range want {
+		if b.Results[i] != want[i] {
+			t.Fatalf("results = %+v, esperava %+v", b.Results, want)
+		}
+	}
+}
+
+// Dois snapshots com votos entre eles: cada um guarda o placar do seu
+// momento, é recuperável pelo número e não muda com a votação
+func TestSnapshotNow(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia", "caio")
+	dir := t.TempDir()
+	s.SetSnapshotDir(dir)
+
+	vote(s, f, "ana", testAddr(1), "A")
+	first := s.SnapshotNow()
+	vote(s, f, "bia", testAddr(2), "B")
+	vote(s, f, "caio", testAddr(3), "B")
+	second := s.SnapshotNow()
+
+	if first.SnapshotSeq != 1 || second.SnapshotSeq != 2 {
+		t.Fatalf("números = %d e %d", first.SnapshotSeq, second.SnapshotSeq)
+	}
+	if first.VoteCounts["A"] != 1 || first.VoteCounts["B"] != 0 || second.VoteCounts["B"] != 2 {
+		t.Fatalf("snapshots = %v e %v", first.VoteCounts, second.VoteCounts)
+	}
+	if second.TakenAt.Before(first.TakenAt) || s.State() != VotingActive {
+		t.Fatalf("snapshot afetou a votação (estado %s)", s.State())
+	}
+
+	// A cópia devolvida é independente da retida
+	first.VoteCounts["A"] = 99
+	got, ok := s.Snapshot(1)
+	if !ok || got.VoteCounts["A"] != 1 || got.VoteCounts["B"] != 0 {
+		t.Fatalf("Snapshot(1) = %v, %v", got.VoteCounts, ok)
+	}
+	if list := s.Snapshots(); len(list) != 2 || list[1].VoteCounts["B"] != 2 {
+		t.Fatalf("Snapshots = %+v", list)
+	}
+	disk, err := ReadResults(filepath.Join(dir, "snapshot-0002.json"))
+	if err != nil || disk.VoteCounts["B"] != 2 || disk.SnapshotSeq != 2 {
+		t.Fatalf("snapshot em disco = %+v, %v", disk, err)
+	}
+
+	// A retenção descarta os mais antigos
+	s.SetSnapshotRetention(1)
+	if _, ok := s.Snapshot(1); ok {
+		t.Fatal("snapshot 1 retido além do limite")
+	}
+	if _, ok := s.Snapshot(2); !ok {
+		t.Fatal("snapshot mais recente descartado")
+	}
+}
+
+// 10 x 7: vencedor A, segundo B, vantagem de 3 votos (3/17 do total), no
+// GetResults e no broadcast final
+func TestMarginOfVictory(t *testing.T) {
+	voters := make([]string, 17)
+	for i := range voters {
+		voters[i] = fmt.Sprintf("v%02d", i)
+	}
+	s, f := votingServer(t, false, voters...)
+	for i, id := range voters {
+		option := "A"
+		if i >= 10 {
+			option = "B"
+		}
+		vote(s, f, id, testAddr(i+1), option)
+	}
+
+	pct := 3.0 / 17 * 100
+	r := s.GetResults()
+	if r.Winner != "A" || r.RunnerUp != "B" || r.Margin != 3 || math.Abs(r.MarginPct-pct) > 1e-9 {
+		t.Fatalf("GetResults: vencedor %q, segundo %q, vantagem %d (%.2f%%)", r.Winner, r.RunnerUp, r.Margin, r.MarginPct)
+	}
+
+	s.EndVotingNow()
+	m := f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+	if fr := m.Final; fr.Winner != "A" || fr.RunnerUp != "B" || fr.Margin != 3 || math.Abs(fr.MarginPct-pct) > 1e-9 {
+		t.Fatalf("resultado final = %+v", fr)
+	}
+}
+
+func TestMarginEdgeCases(t *testing.T) {
+	// Sem votos: sem vencedor nem vantagem
+	s, f := votingServer(t, false, "ana", "bia")
+	if r := s.GetResults(); r.Winner != "" || r.RunnerUp != "" || r.Margin != 0 || r.MarginPct != 0 {
+		t.Fatalf("sem votos: %+v", r)
+	}
+
+	// Votos numa só opção: a vantagem é o total do vencedor
+	vote(s, f, "ana", testAddr(1), "B")
+	vote(s, f, "bia", testAddr(2), "B")
+	if r := s.GetResults(); r.Winner != "B" || r.RunnerUp != "" || r.Margin != 2 || r.MarginPct != 100 {
+		t.Fatalf("opção única: vencedor %q, segundo %q, vantagem %d (%.1f%%)", r.Winner, r.RunnerUp, r.Margin, r.MarginPct)
+	}
+
+	// Empate resolvido pelo desempate: o perdedor é o segundo, com vantagem zero
+	s, f = votingServer(t, false, "ana", "bia")
+	s.SetTieBreak(TieBreakAlphabetical)
+	vote(s, f, "ana", testAddr(1), "B")
+	vote(s, f, "bia", testAddr(2), "A")
+	if r := s.GetResults(); r.Winner != "A" || r.RunnerUp != "B" || r.Margin != 0 {
+		t.Fatalf("empate desempatado: vencedor %q, segundo %q, vantagem %d", r.Winner, r.RunnerUp, r.Margin)
+	}
+
+	// Empate sem estratégia: nem vencedor nem vantagem
+	s.SetTieBreak(TieBreakNone)
+	if r := s.GetResults(); r.Winner != "" || r.RunnerUp != "" || r.Margin != 0 {
+		t.Fatalf("empate sem desempate: %+v", r)
+	}
+}
+
+// Rode com -race: Results e State lidos (e a cópia alterada) enquanto
+// votos chegam em paralelo; o placar do servidor não é afetado
+func TestResultsConcurrentCopy(t *testing.T) {
+	const voters = 200
+	s, f := newFakeServer(t)
+	for i := 1; i <= voters; i++ {
+		register(t, s, f, fmt.Sprint("v", i), testAddr(i))
+	}
+	s.StartVoting(3600)
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				c := s.Results()
+				if totalVotes(c) > voters || s.State() != VotingActive {
+					t.Errorf("retrato incoerente: %v", c)
+					return
+				}
+				c["A"] = -1
+				c["Z"] = 99
+				delete(c, "B")
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= voters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			deliver(s, testAddr(i), Message{Type: "VOTE", ClientID: fmt.Sprint("v", i), VoteOption: []string{"A", "B"}[i%2]})
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	got := s.Results()
+	if len(got) != 2 {
+		t.Fatalf("cópia alterada vazou para o servidor: %v", got)
+	}
+	wantTally(t, s, map[string]int64{"A": voters / 2, "B": voters / 2})
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"log"
+	"net"
+)
+
+// ----------------------------------------------------------
+// Recuperação de broadcasts perdidos (RESYNC)
+// ----------------------------------------------------------
+//
+// O cliente que percebe um buraco no SeqNum envia RESYNC com o último SeqNum
+// que viu (e, em UpTo, o que chegou depois do buraco). O servidor reenvia os broadcasts que faltam a partir do histórico
+// recente, cada um como RESYNC com o SeqNum original. Se o pedido for antigo
+// demais (fora do histórico ou além do limite de reenvio), responde com um
+// SNAPSHOT do placar atual. GET_RESULTS pede esse SNAPSHOT diretamente, sem
+// precisar de um buraco.
+
+const (
+	historySize     = 64 // broadcasts mantidos para reenvio
+	resyncMaxReplay = 32 // acima disso, um SNAPSHOT sai mais barato
+)
+
+// recordHistoryLocked guarda o broadcast no histórico circular
+func (s *UDPServer) recordHistoryLocked(msg Message) {
+	s.history = append(s.history, msg)
+	s.historyBytes += jsonSize(msg)
+	if extra := len(s.history) - historySize; extra > 0 {
+		s.trimHistoryLocked(extra)
+	}
+	s.enforceBudgetLocked()
+}
+
+// resync responde ao pedido de recuperação de quem viu até o SeqNum `last`;
+// upTo é o SeqNum que revelou o buraco (entra na estimativa de perda)
+func (s *UDPServer) resync(id string, last, upTo int, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[id]; !ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Registre-se primeiro"})
+		return
+	}
+	if last >= s.broadcastSeq {
+		return // nada a recuperar
+	}
+	s.recordResyncLocked(id, last, upTo)
+
+	// Parciais ocultos continuam ocultos para quem não é observador
+	hidden := s.hideLive && !s.observers[id]
+
+	missing := s.broadcastSeq - last
+	oldest := s.broadcastSeq + 1
+	if len(s.history) > 0 {
+		oldest = s.history[0].SeqNum
+	}
+
+	if last < 0 || last+1 < oldest || missing > resyncMaxReplay {
+		log.Printf("[RESYNC] %s pediu desde #%d: enviando snapshot #%d", id, last, s.broadcastSeq)
+		s.send(addr, s.snapshotLocked(hidden))
+		return
+	}
+
+	sent := 0
+	for _, msg := range s.history {
+		if msg.SeqNum <= last || (hidden && msg.Final == nil) {
+			continue
+		}
+		msg.Type = "RESYNC"
+		s.send(addr, msg)
+		sent++
+	}
+	log.Printf("[RESYNC] %s pediu desde #%d: %d broadcasts reenviados", id, last, sent)
+}
+
+// getResults responde ao GET_RESULTS com o placar atual e o SeqNum do
+// último broadcast, só para quem pediu
+func (s *UDPServer) getResults(id string, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[id]; !ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Registre-se primeiro"})
+		return
+	}
+	s.send(addr, s.snapshotLocked(s.hideLive && !s.observers[id]))
+}
+
+// snapshotLocked monta o SNAPSHOT do placar atual; com `hidden`, os
+// parciais ficam de fora até o encerramento
+func (s *UDPServer) snapshotLocked(hidden bool) Message {
+	snap := Message{Type: "SNAPSHOT", SeqNum: s.broadcastSeq, Options: s.options}
+	if !hidden || s.votingState == VotingEnded {
+		snap.VoteCounts, snap.Results = s.displayLocked(s.voteCounts)
+	}
+	return snap
+}
@@ -0,0 +1,66 @@
+package server
+
+import "testing"
+
+// Buraco de 3 SeqNums: o RESYNC com o último visto traz exatamente os três
+// broadcasts perdidos, com os placares de cada momento
+func TestResyncRecoversGap(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia", "caio", "duda")
+	a := testAddr(1)
+	vote(s, f, "ana", a, "A")
+	seen := f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts["A"] == 1 })
+
+	// Três broadcasts que "se perdem" no caminho até ana
+	vote(s, f, "bia", testAddr(2), "B")
+	vote(s, f, "caio", testAddr(3), "B")
+	vote(s, f, "duda", testAddr(4), "A")
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.SeqNum == seen.SeqNum+3 })
+
+	deliver(s, a, Message{Type: "RESYNC", ClientID: "ana", SeqNum: seen.SeqNum, UpTo: seen.SeqNum + 3})
+	got := f.ofType(a, "RESYNC")
+	want := []map[string]int64{{"A": 1, "B": 1}, {"A": 1, "B": 2}, {"A": 2, "B": 2}}
+	if len(got) != len(want) {
+		t.Fatalf("RESYNC trouxe %d broadcasts, esperava %d: %+v", len(got), len(want), got)
+	}
+	for i, m := range got {
+		if m.SeqNum != seen.SeqNum+1+i || m.VoteCounts["A"] != want[i]["A"] || m.VoteCounts["B"] != want[i]["B"] {
+			t.Fatalf("reenvio %d = #%d %v, esperava #%d %v", i, m.SeqNum, m.VoteCounts, seen.SeqNum+1+i, want[i])
+		}
+	}
+
+	// Em dia com o servidor, nada a reenviar
+	deliver(s, a, Message{Type: "RESYNC", ClientID: "ana", SeqNum: seen.SeqNum + 3})
+	if n := len(f.ofType(a, "RESYNC")); n != 3 {
+		t.Fatalf("RESYNC sem buraco reenviou %d broadcasts", n-3)
+	}
+}
+
+// Pedido mais antigo que o histórico (ou que o limite de reenvio) recebe o
+// placar atual em um SNAPSHOT
+func TestResyncTooOldSendsSnapshot(t *testing.T) {
+	s, f := votingServer(t, false, "ana")
+	a := testAddr(1)
+	s.mu.Lock()
+	for i := 0; i < resyncMaxReplay+2; i++ {
+		s.broadcastUpdateLocked()
+	}
+	last := s.broadcastSeq
+	s.mu.Unlock()
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.SeqNum == last })
+
+	deliver(s, a, Message{Type: "RESYNC", ClientID: "ana", SeqNum: 0})
+	snap := f.last(a)
+	if snap.Type != "SNAPSHOT" || snap.SeqNum != last || len(snap.Options) != 2 {
+		t.Fatalf("RESYNC antigo = %+v, esperava SNAPSHOT #%d", snap, last)
+	}
+	if n := len(f.ofType(a, "RESYNC")); n != 0 {
+		t.Fatalf("%d broadcasts reenviados junto com o SNAPSHOT", n)
+	}
+
+	// Sem registro, nada é reenviado
+	other := testAddr(9)
+	deliver(s, other, Message{Type: "RESYNC", ClientID: "ninguém", SeqNum: 0})
+	if got := f.last(other); got.Type != "ERROR" || got.Message != "Registre-se primeiro" {
+		t.Fatalf("RESYNC sem registro = %+v", got)
+	}
+}
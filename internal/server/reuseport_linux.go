@@ -0,0 +1,53 @@
+//go:build linux
+
+package server
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reusePortSupported indica que o kernel distribui os datagramas entre
+// sockets com SO_REUSEPORT na mesma porta
+const reusePortSupported = true
+
+// listenReusePort abre n sockets UDP com SO_REUSEPORT no mesmo endereço.
+// Com porta 0, os demais usam a porta que o sistema escolheu para o primeiro.
+func listenReusePort(addr *net.UDPAddr, n int) ([]*net.UDPConn, error) {
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var serr error
+			err := c.Control(func(fd uintptr) {
+				serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return serr
+		},
+	}
+
+	conns := make([]*net.UDPConn, 0, n)
+	closeAll := func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}
+	address := addr.String()
+	for i := 0; i < n; i++ {
+		pc, err := lc.ListenPacket(context.Background(), "udp", address)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		conn := pc.(*net.UDPConn)
+		conns = append(conns, conn)
+		if i == 0 {
+			address = conn.LocalAddr().String()
+		}
+	}
+	return conns, nil
+}
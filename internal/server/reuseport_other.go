@@ -0,0 +1,17 @@
+//go:build !linux
+
+package server
+
+import (
+	"errors"
+	"net"
+)
+
+// reusePortSupported: a distribuição entre sockets com SO_REUSEPORT só é
+// garantida no Linux
+const reusePortSupported = false
+
+// listenReusePort não está disponível fora do Linux
+func listenReusePort(addr *net.UDPAddr, n int) ([]*net.UDPConn, error) {
+	return nil, errors.New("SO_REUSEPORT não suportado nesta plataforma")
+}
@@ -0,0 +1,38 @@
+package server
+
+import "log"
+
+// ----------------------------------------------------------
+// Troca de voto antes do prazo
+// ----------------------------------------------------------
+//
+// Por padrão o segundo VOTE de um cliente é recusado com "Voto duplicado".
+// Com SetAllowRevote, um cliente registrado pode mudar de opção enquanto a
+// votação está aberta: o voto sai da opção antiga e entra na nova. Cada
+// troca vira um novo registro na cadeia de auditoria, e a apuração
+// (ReplayAudit) conta o último registro de cada cliente. Votar de novo na
+// mesma opção continua sendo "Voto duplicado", o que mantém as
+// retransmissões do cliente inofensivas.
+
+// SetAllowRevote permite que o cliente troque o voto antes do prazo
+func (s *UDPServer) SetAllowRevote(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allowRevote = enabled
+}
+
+// changeVoteLocked move o voto de `id` da opção `from` para `to`
+func (s *UDPServer) changeVoteLocked(id, from, to string) {
+	w := s.voteWeightLocked(id)
+	s.votes[id] = to
+	s.voteCounts[from] -= w
+	s.voteCounts[to] += w
+	s.votesChanged++
+	s.votesAccepted++
+	s.metrics.Inc(MetricVotesAccepted)
+	s.touchOptionLocked(from)
+	s.touchOptionLocked(to)
+	s.appendAuditLocked(id, to, w)
+	s.mirrorVoteLocked(id, to, w)
+	log.Printf("[REVOTE] %s: %s → %s", id, from, to)
+}
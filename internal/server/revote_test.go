@@ -0,0 +1,38 @@
+package server
+
+import "testing"
+
+// Com troca de voto, ana vai de A para B e volta para A: o placar acompanha
+// cada troca sem perder nem duplicar votos
+func TestRevoteRoundTrip(t *testing.T) {
+	s, f := votingServer(t, true, "ana", "bia")
+	a := testAddr(1)
+	vote(s, f, "bia", testAddr(2), "B")
+
+	steps := []struct {
+		option, reply string
+		want          map[string]int64
+	}{
+		{"A", "Voto registrado", map[string]int64{"A": 1, "B": 1}},
+		{"B", "Voto alterado", map[string]int64{"A": 0, "B": 2}},
+		{"A", "Voto alterado", map[string]int64{"A": 1, "B": 1}},
+		{"A", "Voto duplicado", map[string]int64{"A": 1, "B": 1}},
+	}
+	for _, st := range steps {
+		if got := vote(s, f, "ana", a, st.option); got.Message != st.reply {
+			t.Fatalf("voto em %s = %+v, esperava %q", st.option, got, st.reply)
+		}
+		wantTally(t, s, st.want)
+	}
+}
+
+// Sem troca de voto, o segundo VOTE é recusado e o placar não muda
+func TestRevoteDisabled(t *testing.T) {
+	s, f := votingServer(t, false, "ana")
+	a := testAddr(1)
+	vote(s, f, "ana", a, "A")
+	if got := vote(s, f, "ana", a, "B"); got.Type != "ERROR" || got.Message != "Voto duplicado" {
+		t.Fatalf("segundo voto = %+v", got)
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 0})
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Segundo turno automático em caso de empate
+// ----------------------------------------------------------
+//
+// Com SetRunoff, uma votação que termina empatada na liderança (sem
+// vencedor pelo desempate configurado) não fica sem resultado: o servidor
+// abre na hora uma nova rodada só com as opções empatadas. Todos os
+// clientes continuam registrados e podem votar de novo; eles recebem um
+// RUNOFF com as opções da rodada. A cadeia de auditoria continua de uma
+// rodada para a outra e cada registro leva o número da rodada.
+
+// Limite padrão de segundos turnos seguidos
+const defaultRunoffRounds = 3
+
+// SetRunoff liga o segundo turno automático, com `sec` segundos de votação
+// por rodada (0 desliga). maxRounds limita os segundos turnos seguidos
+// (0 = padrão de 3); esgotado o limite, o empate fica como resultado.
+func (s *UDPServer) SetRunoff(sec, maxRounds int) error {
+	if sec < 0 || maxRounds < 0 {
+		return errors.New("segundo turno: duração e limite de rodadas não podem ser negativos")
+	}
+	if maxRounds == 0 {
+		maxRounds = defaultRunoffRounds
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runoffSec = sec
+	s.runoffMax = maxRounds
+	return nil
+}
+
+// Round devolve a rodada atual (0 = votação original, 1 = primeiro
+// segundo turno ou nova votação de ResetVoting...)
+func (s *UDPServer) Round() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.round
+}
+
+// runoffLocked abre um segundo turno se a votação encerrada terminou
+// empatada e ainda há rodadas disponíveis. Decisões por limiar
+// (SetDecisionRule) não têm segundo turno.
+func (s *UDPServer) runoffLocked() bool {
+	if s.runoffSec <= 0 || s.decisionRule != nil || s.winnerLocked() != "" {
+		return false
+	}
+	tied, _ := s.leadersLocked("")
+	if len(tied) < 2 {
+		return false // sem votos
+	}
+	if s.round-s.pollRound >= s.runoffMax {
+		log.Printf("[RUNOFF] Empate entre %v após %d segundo(s) turno(s): limite atingido", tied, s.round-s.pollRound)
+		return false
+	}
+
+	s.round++
+	s.options = tied
+	s.voteCounts = make(map[string]int64, len(tied))
+	for _, op := range tied {
+		s.voteCounts[op] = 0
+	}
+	s.votes = make(map[string]string)
+	s.voteWeights = make(map[string]int64)
+	s.lastChange = make(map[string]time.Time)
+	s.final = nil
+	s.persisted = false
+	s.votingOpened = time.Time{}
+	s.votingState = VotingNotStarted
+	log.Printf("[RUNOFF] Empate: segundo turno #%d entre %v (%ds)", s.round, tied, s.runoffSec)
+
+	notice := Message{
+		Type:    "RUNOFF",
+		Message: fmt.Sprintf("Empate: segundo turno entre %v (%ds)", tied, s.runoffSec),
+		Options: tied,
+		Round:   s.round,
+	}
+	for _, c := range s.clients {
+		s.send(c.addr, notice)
+	}
+	s.startVotingLocked(s.runoffSec)
+	return true
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"testing"
+)
+
+// Empate entre A e B (C sem votos): abre um segundo turno só com as
+// empatadas; um novo empate depois do limite de rodadas fica como resultado
+func TestRunoffOnTie(t *testing.T) {
+	s, f := newFakeServer(t, "A", "B", "C")
+	if err := s.SetRunoff(60, 1); err != nil {
+		t.Fatal(err)
+	}
+	a, b := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", a)
+	register(t, s, f, "bia", b)
+	s.StartVoting(3600)
+	vote(s, f, "ana", a, "A")
+	vote(s, f, "bia", b, "B")
+	s.EndVotingNow()
+
+	for _, addr := range []*net.UDPAddr{a, b} {
+		got := f.ofType(addr, "RUNOFF")
+		if len(got) != 1 || !slices.Equal(got[0].Options, []string{"A", "B"}) || got[0].Round != 1 {
+			t.Fatalf("RUNOFF para %s = %+v", addr, got)
+		}
+	}
+	r := s.GetResults()
+	if r.State != VotingActive || r.Round != 1 || len(r.VoteCounts) != 2 || r.TotalVotes != 0 {
+		t.Fatalf("segundo turno = %+v", r)
+	}
+	if got := vote(s, f, "ana", a, "C"); got.Type != "ERROR" {
+		t.Fatalf("voto em opção fora do segundo turno = %+v", got)
+	}
+
+	// Novo empate com o limite de uma rodada esgotado: fica o empate
+	vote(s, f, "ana", a, "A")
+	vote(s, f, "bia", b, "B")
+	s.EndVotingNow()
+	if n := len(f.ofType(a, "RUNOFF")); n != 1 {
+		t.Fatalf("%d RUNOFF depois do limite de rodadas", n)
+	}
+	r = s.GetResults()
+	if r.State != VotingEnded || !slices.Equal(r.Tied, []string{"A", "B"}) {
+		t.Fatalf("resultado após o limite = %+v", r)
+	}
+}
+
+// Com vencedor, ou sem SetRunoff, a votação simplesmente encerra
+func TestRunoffNotNeeded(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		runoff int
+		votes  []string
+	}{
+		{"com vencedor", 60, []string{"A", "A"}},
+		{"desligado", 0, []string{"A", "B"}},
+	} {
+		s, f := newFakeServer(t)
+		if err := s.SetRunoff(tc.runoff, 0); err != nil {
+			t.Fatal(err)
+		}
+		for i := range tc.votes {
+			register(t, s, f, fmt.Sprint("eleitor", i), testAddr(i+1))
+		}
+		s.StartVoting(3600)
+		for i, op := range tc.votes {
+			vote(s, f, fmt.Sprint("eleitor", i), testAddr(i+1), op)
+		}
+		s.EndVotingNow()
+		if got := f.ofType(testAddr(1), "RUNOFF"); len(got) != 0 || s.State() != VotingEnded {
+			t.Fatalf("%s: RUNOFF %+v, estado %v", tc.name, got, s.State())
+		}
+	}
+
+	s, _ := newFakeServer(t)
+	if err := s.SetRunoff(-1, 0); err == nil {
+		t.Fatal("duração negativa aceita")
+	}
+}
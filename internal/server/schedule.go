@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Relógio e agendamento da votação
+// ----------------------------------------------------------
+//
+// Prazos, registros de auditoria e limites de taxa usam s.now, que pode ser
+// trocado por um relógio controlado (SetClock). Como um relógio falso não
+// dispara timers, abertura e encerramento agendados também são conferidos
+// a cada pacote recebido: o primeiro pacote depois de openAt abre a votação.
+
+// SetClock troca a fonte de horário do servidor (nil volta ao relógio real)
+func (s *UDPServer) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// ScheduleVoting agenda a abertura em openAt e o encerramento em closeAt.
+// Antes da abertura, o REGISTER funciona e o VOTE é recusado informando o
+// horário de abertura.
+func (s *UDPServer) ScheduleVoting(openAt, closeAt time.Time) error {
+	if !closeAt.After(openAt) {
+		return errors.New("o encerramento deve ser posterior à abertura")
+	}
+
+	s.mu.Lock()
+	if s.votingState != VotingNotStarted {
+		s.mu.Unlock()
+		return errors.New("votação já iniciada")
+	}
+	s.openAt = openAt
+	s.votingDeadline = closeAt
+	wait := openAt.Sub(s.now())
+	s.mu.Unlock()
+
+	log.Printf("Votação agendada: abre %s, encerra %s",
+		openAt.Format(time.RFC3339), closeAt.Format(time.RFC3339))
+
+	if wait <= 0 {
+		s.checkSchedule()
+		return nil
+	}
+	time.AfterFunc(wait, s.checkSchedule)
+	return nil
+}
+
+// checkSchedule aplica as transições de estado que já venceram pelo relógio
+func (s *UDPServer) checkSchedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.votingState == VotingNotStarted && !s.openAt.IsZero() && !now.Before(s.openAt) {
+		log.Printf("Votação aberta conforme agendamento (até %s)", s.votingDeadline.Format(time.RFC3339))
+		s.openVotingLocked(s.votingDeadline)
+	}
+	if s.votingState == VotingActive && s.deadlinePassedLocked(now) {
+		s.endVotingLocked()
+	}
+}
+
+// SetDeadlineInclusive define se um voto que chega exatamente no prazo é
+// aceito (padrão) ou recusado
+func (s *UDPServer) SetDeadlineInclusive(inclusive bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadlineExclusive = !inclusive
+}
+
+// deadlinePassedLocked diz se o prazo da votação venceu em `now`. O instante
+// do prazo conta como dentro da votação, salvo com SetDeadlineInclusive(false).
+func (s *UDPServer) deadlinePassedLocked(now time.Time) bool {
+	if s.deadlineExclusive {
+		return !now.Before(s.votingDeadline)
+	}
+	return now.After(s.votingDeadline)
+}
+
+// notStartedMessageLocked explica por que o voto foi recusado antes da abertura
+func (s *UDPServer) notStartedMessageLocked() string {
+	if s.openAt.IsZero() {
+		return "Votação não iniciada"
+	}
+	return "Votação não iniciada (abre em " + s.openAt.Format(time.RFC3339) + ")"
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// A abertura agendada registra a hora de abertura como o início manual:
+// opened_at sai no resultado e no arquivo de resultados do encerramento
+func TestScheduledOpenRecordsOpenedAt(t *testing.T) {
+	s, f := newFakeServer(t)
+	clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return clock })
+	path := filepath.Join(t.TempDir(), "resultado.json")
+	s.SetResultsFile(path)
+	register(t, s, f, "ana", testAddr(1))
+
+	openAt := clock.Add(10 * time.Minute)
+	if err := s.ScheduleVoting(openAt, openAt.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if r := s.GetResults(); r.OpenedAt != nil {
+		t.Fatalf("opened_at antes da abertura: %v", r.OpenedAt)
+	}
+
+	clock = openAt
+	if got := vote(s, f, "ana", testAddr(1), "A"); got.Type != "ACK" {
+		t.Fatalf("voto depois da abertura agendada = %+v", got)
+	}
+	if r := s.GetResults(); r.OpenedAt == nil || !r.OpenedAt.Equal(openAt) {
+		t.Fatalf("opened_at = %v, esperava %v", r.OpenedAt, openAt)
+	}
+
+	clock = clock.Add(5 * time.Minute)
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ReadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.OpenedAt == nil || !saved.OpenedAt.Equal(openAt) {
+		t.Fatalf("arquivo de resultados com opened_at = %v, esperava %v", saved.OpenedAt, openAt)
+	}
+}
+
+// Voto exatamente no prazo: aceito por padrão, recusado com
+// SetDeadlineInclusive(false). Depois do prazo a recusa é "Votação
+// encerrada" e o estado já é VotingEnded, mesmo antes do timer.
+func TestVoteAtDeadline(t *testing.T) {
+	cases := []struct {
+		name      string
+		inclusive bool
+		offset    time.Duration // em relação ao prazo
+		accepted  bool
+	}{
+		{"inclusivo, no prazo", true, 0, true},
+		{"inclusivo, 1ns depois", true, time.Nanosecond, false},
+		{"exclusivo, 1ns antes", false, -time.Nanosecond, true},
+		{"exclusivo, no prazo", false, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+			clock := start
+			s.SetClock(func() time.Time { return clock })
+			s.SetDeadlineInclusive(tc.inclusive)
+			a := testAddr(1)
+			register(t, s, f, "ana", a)
+			s.StartVoting(60)
+
+			clock = start.Add(60*time.Second + tc.offset)
+			deliver(s, a, Message{Type: "VOTE", ClientID: "ana", VoteOption: "A"})
+			got := f.last(a)
+			if tc.accepted {
+				if got.Type != "ACK" || s.State() != VotingActive {
+					t.Fatalf("resposta = %+v, estado %s; esperava ACK com a votação ativa", got, s.State())
+				}
+				return
+			}
+			if got.Type != "ERROR" || got.Message != "Votação encerrada" {
+				t.Fatalf("resposta = %+v, esperava ERROR \"Votação encerrada\"", got)
+			}
+			if s.State() != VotingEnded {
+				t.Fatalf("estado = %s depois da recusa por prazo", s.State())
+			}
+			if got := s.Results()["A"]; got != 0 {
+				t.Fatalf("voto fora do prazo contado (%d)", got)
+			}
+		})
+	}
+}
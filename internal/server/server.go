@@ -1,41 +1,282 @@
 package server
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/juander/udp-vote/pkg/resultfmt"
+)
+
+// Tamanho do buffer de leitura de cada datagrama recebido
+const readBufferSize = 4096
+
+// Pool que trata os pacotes recebidos: quantidade padrão de workers e
+// tamanho da fila entre os loops de leitura e eles
+const (
+	defaultWorkers  = 64
+	packetQueueSize = 1024
+)
+
+// Acima deste tamanho um datagrama tende a ser fragmentado na rede
+// (MTU típico de 1500 menos cabeçalhos IP/UDP e folga para túneis)
+const defaultFragmentThreshold = 1400
+
+// Parâmetros do modo degradado de broadcast
+const (
+	degradeThreshold = 20                     // descartes na janela que ativam o modo degradado
+	degradeWindow    = time.Second            // janela de observação dos descartes
+	degradedInterval = 500 * time.Millisecond // intervalo de envio agrupado no modo degradado
 )
 
+// packetConn é a parte do socket UDP que o servidor usa para ler e enviar
+// pacotes. *net.UDPConn a satisfaz; um teste pode pôr em s.conn uma conexão
+// em memória que injeta pacotes e captura as respostas, sem abrir socket.
+type packetConn interface {
+	ReadFromUDP(b []byte) (int, *net.UDPAddr, error)
+	WriteToUDP(b []byte, addr *net.UDPAddr) (int, error)
+	LocalAddr() net.Addr
+	Close() error
+}
+
 // UDPServer gerencia toda a lógica de votação, clientes e comunicação UDP.
 type UDPServer struct {
-	conn *net.UDPConn // conexão UDP do servidor
+	conn      packetConn    // conexão UDP do servidor (atribuída no Start, com s.mu)
+	ready     chan struct{} // fechado quando conn está pronta (Ready)
+	batchSize int           // datagramas por syscall na leitura em lote (<2 = leitura simples)
+	reusePort int           // sockets na mesma porta com SO_REUSEPORT (<2 = um só)
+
+	// Datagramas lidos por socket (SocketPackets), contados sem o mutex
+	socketReads []atomic.Int64
+
+	// Passagem do socket a um novo processo (Handoff): os loops de leitura
+	// seguram readGate para ler, e cada pacote em tratamento conta em inflight
+	conns     []*net.UDPConn
+	readGate  sync.RWMutex
+	inflight  sync.WaitGroup
+	handedOff bool
+
+	// Pool de workers que trata os pacotes (SetWorkers); com a fila cheia,
+	// o pacote é descartado e contado em droppedPackets
+	workers        int
+	packets        chan packet
+	droppedPackets atomic.Int64
+
+	// Encerramento (Stop): done avisa os loops e workers; workerDone fecha
+	// quando o broadcast worker esvaziou a fila
+	stopped    bool
+	done       chan struct{}
+	workerDone chan struct{}
 
 	mu sync.Mutex // mutex para evitar race conditions (uso concorrente de maps)
 
 	// Armazena clientes conectados
-	// key = ClientID, value = endereço UDP do cliente
-	clients map[string]*net.UDPAddr
+	// key = ClientID, value = endereço UDP e atividade do cliente
+	clients map[string]*clientInfo
 
 	// Registro de votos individuais
 	// key = ClientID, value = opção votada
 	votes map[string]string
 
+	// Clientes que não querem receber broadcasts parciais
+	// key = ClientID (continuam registrados e com o voto contado)
+	muted map[string]bool
+
 	// Contagem total de votos por opção
 	// key = opção, value = quantidade de votos
-	voteCounts map[string]int
+	voteCounts map[string]int64
 
 	options []string
 
 	// Controle do estado da votação
-	votingState    VotingState // NotStarted / Active / Ended
-	votingDeadline time.Time   // hora em que a votação termina
+	votingState       VotingState      // NotStarted / Active / Ended
+	votingDeadline    time.Time        // hora em que a votação termina
+	votingOpened      time.Time        // hora em que a votação atual abriu
+	deadlineExclusive bool             // voto no instante do prazo é recusado (SetDeadlineInclusive)
+	openAt            time.Time        // abertura agendada (zero = sem agendamento)
+	pausedAt          time.Time        // início da pausa em andamento (PauseVoting)
+	pauses            int              // pausas feitas; invalida o timer de encerramento anterior
+	endTimer          *time.Timer      // encerramento automático agendado (scheduleEndLocked)
+	now               func() time.Time // relógio usado nas regras da votação (SetClock)
 
 	// Canal que bufferiza updates para broadcast (evita travar o servidor)
 	broadcastChan chan BroadcastUpdate
 	broadcastSeq  int // incrementa a cada broadcast para controlar versão
+
+	votesReceived     int // pacotes VOTE processados (aceitos ou recusados)
+	votesAccepted     int // votos e trocas contados no placar; não zera com o reset
+	broadcastsDropped int // broadcasts descartados por fila cheia (total)
+
+	// Modo degradado: quando a fila de broadcast transborda com frequência,
+	// os updates passam a ser agrupados em vez de enviados a cada voto
+	degraded      bool      // true enquanto o servidor está descartando carga
+	pendingUpdate bool      // há placar alterado aguardando o envio agrupado
+	dropCount     int       // descartes dentro da janela atual
+	dropWindow    time.Time // início da janela de contagem de descartes
+	lastDrop      time.Time // último descarte observado
+
+	// Auditoria: cadeia de hashes sobre os votos aceitos
+	auditLog  io.Writer // destino opcional dos registros (JSON por linha)
+	auditSeq  int       // número do último registro da cadeia
+	chainHash string    // hash do último registro (selo da apuração)
+
+	resultsFile string   // arquivo do resultado final (vazio = não exporta)
+	final       *Results // retrato tirado no encerramento
+	persisted   bool     // o retrato final foi gravado em resultsFile
+	stateFile   string   // estado regravado a cada mudança (SetStateFile)
+
+	// Liberação dos maps por cliente após o encerramento (SetCompactOnEnd)
+	compactOnEnd bool
+	compacted    bool
+
+	// Snapshots oficiais tirados durante a votação (SnapshotNow)
+	snapshots         []Results
+	snapshotSeq       int
+	snapshotRetention int
+	snapshotDir       string
+
+	// Mensagens grandes o bastante para sofrer fragmentação IP
+	fragmentThreshold int // bytes (0 = não verifica)
+	fragmentRisk      int // mensagens enviadas acima do limite
+
+	// Broadcast agrupado em intervalo fixo (SetBroadcastInterval)
+	broadcastInterval time.Duration
+	intervalStop      chan struct{}
+
+	// Heartbeats periódicos para o NAT (SetHeartbeat)
+	heartbeat     time.Duration
+	heartbeatStop chan struct{}
+
+	startedAt  time.Time // momento da criação do servidor (base do uptime)
+	reportLoad bool      // inclui uptime e carga nas respostas PONG
+
+	// Assinantes do stream TCP de resultados (e dos eventos SSE)
+	subscribers map[chan Message]struct{}
+	streamLn    net.Listener // listener do StartStream (nil = sem stream)
+
+	broadcastLog io.Writer // cópia de cada broadcast enviado (nil = desligado)
+	broadcastKey []byte    // chave HMAC dos placares (nil = sem assinatura)
+
+	// Broadcasts recentes, reenviados sob RESYNC
+	history []Message
+
+	// Orçamento de memória do histórico e dos snapshots (SetMemoryBudget)
+	memoryBudget    int // bytes (0 = sem limite)
+	historyBytes    int // tamanho atual do histórico
+	snapshotBytes   int // tamanho atual dos snapshots retidos
+	bufferEvictions int // entradas descartadas pelo orçamento
+	budgetWarned    bool
+
+	// Entrega de broadcasts por cliente, estimada pelos RESYNC
+	delivery         map[string]*clientDelivery // key = ClientID
+	serverStatsReply bool                       // responde a SERVER_STATS
+
+	// Filtro de tipos de mensagem aceitos (nil = todos os tipos conhecidos)
+	allowedTypes  map[string]bool
+	rejectedTypes int // pacotes recusados pelo filtro
+
+	// Admissão de clientes (SetRegistrationPolicy, SetMaxClients)
+	regPolicy        string
+	maxClients       int
+	minClientVersion int  // versão de protocolo mínima no REGISTER (SetMinClientVersion)
+	announceJoins    bool // JOIN aos demais clientes a cada registro (SetAnnounceJoins)
+
+	// Limite global de novos registros por segundo (nil = sem limite)
+	regLimiter   *tokenBucket
+	regThrottled int // registros recusados pelo limite de taxa
+
+	// Remoção de clientes inativos (SetIdleTimeout)
+	idleTimeout time.Duration
+	idleStop    chan struct{}
+	evicted     int
+
+	// Limite de pacotes por endereço de origem (SetSourceRate)
+	sourceRate      int
+	sourceBuckets   map[string]*tokenBucket
+	sourcePruned    time.Time
+	sourceThrottled int
+
+	// Prova de trabalho no REGISTER (dificuldade 0 = desativada)
+	powDifficulty int
+	powKey        []byte // chave que deriva os desafios
+
+	// Token por cliente derivado do segredo (SetClientSecret; nil = desligado)
+	clientSecret []byte
+	authFailures int
+
+	// Segredo do operador para ADMIN_END (SetAdminSecret; nil = desligado)
+	// e desafios já usados, com o momento do uso
+	adminSecret []byte
+	adminUsed   map[string]time.Time
+
+	decisionRule *DecisionRule // regra de aprovação (nil = sem decisão)
+
+	// Desempate: estratégia e momento do último voto de cada opção
+	tieBreak   string
+	lastChange map[string]time.Time
+
+	// ACKs de voto agrupados por endereço (SetAckCoalesce)
+	ackCoalesce time.Duration
+	pendingAcks map[string]*pendingAck // key = endereço do cliente
+
+	// Mesmo ID usado de dois endereços ao mesmo tempo (SetMultiHomePolicy)
+	multiHome       string
+	multiHomeWindow time.Duration
+	sources         map[string]map[string]*sourceSeen // ClientID → endereço → atividade
+	conflicts       map[string]bool                   // IDs com conflito em aberto
+	multiHomeCount  int                               // conflitos detectados
+
+	minDisplay int // opções com menos votos vão para "Outros" na exibição
+
+	// Resultados parciais ocultos: só observadores autenticados recebem
+	// os broadcasts durante a votação; o resultado final vai para todos
+	hideLive      bool
+	observerToken string
+	observers     map[string]bool // key = ClientID
+
+	// Espelhamento de votos para um servidor secundário (hot standby)
+	mirrorTarget   *net.UDPAddr // para onde enviar (nil = desligado)
+	mirrorSource   *net.UDPAddr // de onde aceitar (nil = não aceita)
+	mirrorSent     int
+	mirrorReceived int
+
+	// Delegação de voto: key = quem delegou, value = delegado
+	delegatedVoting bool
+	delegations     map[string]string
+	delegatedCount  int // votos contados por delegação
+
+	// Peso por participante (SetWeightedVoting); voteWeights guarda o peso
+	// dos votos contados com peso diferente de 1
+	weightedVoting bool
+	voteWeights    map[string]int64
+
+	// Troca de voto antes do prazo (SetAllowRevote)
+	allowRevote  bool
+	votesChanged int
+
+	// Opção do voto sem caixa nem espaços nas pontas (SetCaseInsensitiveOptions)
+	caseInsensitive bool
+
+	// Segundo turno automático em caso de empate (SetRunoff)
+	runoffSec int // duração de cada segundo turno (0 = desligado)
+	runoffMax int // segundos turnos seguidos permitidos
+	round     int // rodada atual (0 = votação original)
+	pollRound int // rodada em que a votação atual começou (ResetVoting)
+
+	quorum int // votos mínimos para o resultado valer (SetQuorum; 0 = sem quórum)
+
+	metrics Metrics // medições nos pontos de instrumentação (SetMetrics)
+
+	config Config // configuração usada na construção (NewUDPServerFromConfig)
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -43,14 +284,39 @@ type UDPServer struct {
 ///////////////////////////////////////////////////////////////////////////////
 
 // NewUDPServer cria uma instância do servidor com as opções disponíveis para votar.
-func NewUDPServer(options []string) *UDPServer {
+// Uma votação precisa de pelo menos duas opções distintas e não vazias.
+func NewUDPServer(options []string) (*UDPServer, error) {
+	if err := validateOptions(options); err != nil {
+		return nil, err
+	}
+
 	s := &UDPServer{
-		clients:       make(map[string]*net.UDPAddr),
+		clients:       make(map[string]*clientInfo),
 		votes:         make(map[string]string),
-		voteCounts:    make(map[string]int),
+		voteWeights:   make(map[string]int64),
+		muted:         make(map[string]bool),
+		observers:     make(map[string]bool),
+		subscribers:   make(map[chan Message]struct{}),
+		delivery:      make(map[string]*clientDelivery),
+		delegations:   make(map[string]string),
+		lastChange:    make(map[string]time.Time),
+		sources:       make(map[string]map[string]*sourceSeen),
+		conflicts:     make(map[string]bool),
+		pendingAcks:   make(map[string]*pendingAck),
+		voteCounts:    make(map[string]int64),
 		votingState:   VotingNotStarted,
 		broadcastChan: make(chan BroadcastUpdate, 200), // canal com buffer grande
 		options:       options,
+		startedAt:     time.Now(),
+		now:           time.Now,
+		ready:         make(chan struct{}),
+		done:          make(chan struct{}),
+		workers:       defaultWorkers,
+		workerDone:    make(chan struct{}),
+		metrics:       nopMetrics{},
+
+		snapshotRetention: defaultSnapshotRetention,
+		fragmentThreshold: defaultFragmentThreshold,
 	}
 
 	// Inicializa contadores das opções
@@ -61,163 +327,854 @@ func NewUDPServer(options []string) *UDPServer {
 	// Worker que envia broadcast sempre que houver evento novo
 	go s.broadcastWorker()
 
-	return s
+	// Worker que controla o modo degradado do broadcast
+	go s.degradeWorker()
+
+	return s, nil
+}
+
+// validateOptions recusa listas que tornariam a votação sem sentido
+func validateOptions(options []string) error {
+	seen := make(map[string]bool, len(options))
+	for _, op := range options {
+		if strings.TrimSpace(op) == "" {
+			return errors.New("opção de voto vazia")
+		}
+		if seen[op] {
+			return fmt.Errorf("opção de voto repetida %q", op)
+		}
+		seen[op] = true
+	}
+	if len(options) < 2 {
+		return fmt.Errorf("são necessárias pelo menos 2 opções de voto (recebidas %d)", len(options))
+	}
+	return nil
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // INICIAR SERVIDOR
 ///////////////////////////////////////////////////////////////////////////////
 
-// Start abre o socket UDP e começa a escutar mensagens
-func (s *UDPServer) Start(port string) {
-	addr, err := net.ResolveUDPAddr("udp", port) // resolve porta
+// Start abre o socket UDP (ou usa o herdado do systemd, via LISTEN_FDS) e
+// começa a escutar mensagens. Com SetReusePort(n), abre n sockets na mesma
+// porta, cada um com o próprio loop de leitura. Só retorna depois do Stop,
+// com nil, ou logo, com o erro, se o socket não puder ser aberto (endereço
+// inválido, porta em uso).
+func (s *UDPServer) Start(port string) error {
+	s.mu.Lock()
+	batch, reuse := s.batchSize, s.reusePort
+	s.mu.Unlock()
+
+	// Ativação por socket (systemd): usa o socket já aberto em vez de port
+	conn, err := inheritedConn()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	var conns []*net.UDPConn
+	if conn != nil {
+		log.Printf("Usando socket herdado do systemd (LISTEN_FDS)")
+		if reuse > 1 {
+			log.Printf("[WARN] Socket herdado: SO_REUSEPORT ignorado")
+		}
+		conns = []*net.UDPConn{conn}
+	} else {
+		addr, err := net.ResolveUDPAddr("udp", port) // resolve porta
+		if err != nil {
+			return err
+		}
 
-	s.conn, err = net.ListenUDP("udp", addr) // inicia servidor UDP
-	if err != nil {
-		log.Fatal(err)
+		if reuse > 1 && !reusePortSupported {
+			log.Printf("[WARN] SO_REUSEPORT indisponível nesta plataforma; usando um socket")
+			reuse = 1
+		}
+		if reuse > 1 {
+			conns, err = listenReusePort(addr, reuse)
+		} else {
+			conn, err = net.ListenUDP("udp", addr) // inicia servidor UDP
+			conns = []*net.UDPConn{conn}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+
+	// Os envios leem s.conn com o mutex travado: uma votação iniciada antes
+	// do bind só deixa de enviar (ainda não há clientes), sem escrita em nil.
+	// Todos os sockets têm a mesma porta, então as respostas saem do primeiro.
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil // Stop chamado antes do bind
+	}
+	s.conn = conns[0]
+	s.conns = conns
+	if s.packets == nil {
+		s.packets = make(chan packet, packetQueueSize)
+		for i := 0; i < s.workers; i++ {
+			go s.packetWorker(s.packets)
+		}
+	}
+	s.socketReads = make([]atomic.Int64, len(conns))
+	reads := s.socketReads
+	select {
+	case <-s.ready: // Start chamado de novo
+	default:
+		close(s.ready)
+	}
+	s.mu.Unlock()
+
+	log.Printf("Servidor UDP ouvindo em %s", conns[0].LocalAddr())
+	if len(conns) > 1 {
+		log.Printf("SO_REUSEPORT habilitado (%d sockets)", len(conns))
+	}
+	if batch > 1 && batchSupported {
+		log.Printf("Leitura em lote habilitada (%d datagramas por syscall)", batch)
 	}
-	defer s.conn.Close()
 
-	log.Printf("Servidor UDP ouvindo em %s", port)
+	// Um loop por socket; o último roda nesta goroutine
+	for i := len(conns) - 1; i >= 0; i-- {
+		loop := func(c *net.UDPConn, reads *atomic.Int64) {
+			if batch > 1 && batchSupported {
+				s.readLoopBatch(c, reads, batch)
+				return
+			}
+			s.readLoop(c, reads)
+		}
+		if i == 0 {
+			loop(conns[i], &reads[i])
+			break
+		}
+		go loop(conns[i], &reads[i])
+	}
 
-	buffer := make([]byte, 4096) // buffer para pacotes recebidos
+	// Depois do Stop, só retorna quando a fila de broadcast foi esvaziada
+	if s.stopping() {
+		<-s.workerDone
+		log.Println("Servidor encerrado")
+	}
+	return nil
+}
+
+// Stop encerra o servidor: os loops de leitura saem, os pacotes em
+// tratamento terminam, os broadcasts já na fila são enviados e só então os
+// sockets são fechados (e o Start retorna). Chamadas repetidas não fazem nada.
+func (s *UDPServer) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	close(s.done)
+	s.stopEndTimerLocked()
+	if s.heartbeatStop != nil {
+		close(s.heartbeatStop)
+		s.heartbeatStop = nil
+	}
+	if s.intervalStop != nil {
+		close(s.intervalStop)
+		s.intervalStop = nil
+	}
+	if s.idleStop != nil {
+		close(s.idleStop)
+		s.idleStop = nil
+	}
+	// Nada mais é publicado depois de stopped
+	s.closeStreamLocked()
+	conns := s.conns
+	s.mu.Unlock()
+
+	// Destrava as leituras bloqueadas; os loops veem done e retornam
+	for _, c := range conns {
+		c.SetReadDeadline(time.Now())
+	}
+	s.readGate.Lock()
+	s.inflight.Wait()
+	s.mu.Lock()
+	if s.packets != nil {
+		close(s.packets) // fila vazia: os workers saem
+	}
+	s.mu.Unlock()
+	s.readGate.Unlock()
+
+	// Nenhum broadcast novo entra na fila depois de stopped
+	s.mu.Lock()
+	close(s.broadcastChan)
+	s.mu.Unlock()
+	<-s.workerDone
+
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+// stopping diz se o Stop já foi chamado
+func (s *UDPServer) stopping() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// readsStopped diz se o socket já foi entregue a outro processo (Handoff)
+func (s *UDPServer) readsStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handedOff
+}
+
+// Ready é fechado quando o socket UDP está aberto e os envios já funcionam
+// (nunca, se o Start falhar)
+func (s *UDPServer) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Addr devolve o endereço UDP em que o servidor escuta (nil antes do Start);
+// útil com a porta ":0", escolhida pelo sistema
+func (s *UDPServer) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
+// readLoop lê um datagrama por syscall, somando as leituras em reads
+func (s *UDPServer) readLoop(conn packetConn, reads *atomic.Int64) {
+	buffer := make([]byte, readBufferSize) // buffer para pacotes recebidos
 
 	// Loop infinito ouvindo clientes
 	for {
-		n, clientAddr, err := s.conn.ReadFromUDP(buffer)
+		s.readGate.RLock()
+		n, clientAddr, err := conn.ReadFromUDP(buffer)
 		if err != nil {
+			s.readGate.RUnlock()
+			if s.stopping() || s.readsStopped() || errors.Is(err, net.ErrClosed) {
+				return
+			}
 			continue
 		}
 
+		reads.Add(1)
+
 		// Cria uma cópia do pacote recebido
 		data := make([]byte, n)
 		copy(data, buffer[:n])
-		go s.handlePacket(data, clientAddr)
+		s.dispatchPacket(data, clientAddr)
+		s.readGate.RUnlock()
+	}
+}
+
+// Datagrama recebido a caminho do pool de workers
+type packet struct {
+	data []byte
+	addr *net.UDPAddr
+}
+
+// dispatchPacket entrega o pacote ao pool, contado como em andamento até
+// terminar (o Handoff e o Stop esperam o estado assentar). Com a fila
+// cheia, o pacote é descartado em vez de abrir mais uma goroutine.
+func (s *UDPServer) dispatchPacket(data []byte, addr *net.UDPAddr) {
+	if s.stopping() {
+		return // a fila já pode estar fechada
+	}
+	s.inflight.Add(1)
+	select {
+	case s.packets <- packet{data: data, addr: addr}:
+	default:
+		s.inflight.Done()
+		s.droppedPackets.Add(1)
+	}
+}
+
+// packetWorker trata os pacotes da fila até ela ser fechada (Stop)
+func (s *UDPServer) packetWorker(packets chan packet) {
+	for p := range packets {
+		s.handlePacket(p.data, p.addr)
+		s.inflight.Done()
 	}
 }
 
+// SetWorkers define quantos workers tratam os pacotes recebidos (padrão
+// 64). Vale a partir do Start; valores menores que 1 usam o padrão.
+func (s *UDPServer) SetWorkers(n int) {
+	if n < 1 {
+		n = defaultWorkers
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workers = n
+}
+
+// DroppedPackets devolve quantos pacotes foram descartados com a fila dos
+// workers cheia
+func (s *UDPServer) DroppedPackets() int64 {
+	return s.droppedPackets.Load()
+}
+
+// SetBatchRead habilita a leitura de até n datagramas por syscall
+// (recvmmsg, apenas no Linux). Valores menores que 2 usam a leitura simples.
+func (s *UDPServer) SetBatchRead(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchSize = n
+}
+
+// SetReusePort faz o Start abrir n sockets na mesma porta com SO_REUSEPORT,
+// cada um com o próprio loop de leitura, para o kernel distribuir os
+// datagramas entre os núcleos (apenas Linux; valores menores que 2 usam um
+// socket). O estado compartilhado continua protegido por s.mu.
+func (s *UDPServer) SetReusePort(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reusePort = n
+}
+
+// SocketPackets devolve quantos datagramas cada socket leu desde o Start
+// (um elemento por socket; vazio antes do Start)
+func (s *UDPServer) SocketPackets() []int64 {
+	s.mu.Lock()
+	reads := s.socketReads
+	s.mu.Unlock()
+
+	counts := make([]int64, len(reads))
+	for i := range reads {
+		counts[i] = reads[i].Load()
+	}
+	return counts
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // ROTEAMENTO DE PACOTES
 ///////////////////////////////////////////////////////////////////////////////
 
 func (s *UDPServer) handlePacket(data []byte, addr *net.UDPAddr) {
+	s.mu.Lock()
+	s.metrics.Observe(MetricPacketBytes, float64(len(data)))
+	allowed := s.packetAllowedLocked(addr)
+	s.mu.Unlock()
+	if !allowed {
+		return // acima do limite da origem (SetSourceRate)
+	}
+
 	var msg Message
 	if json.Unmarshal(data, &msg) != nil {
 		return // ignora pacotes inválidos
 	}
 
+	if !s.typeAllowed(msg.Type, addr) {
+		return
+	}
+
+	// Abertura/encerramento agendados que já venceram
+	s.checkSchedule()
+
+	// A marca Mirror só vale em VOTE vindo do primário; qualquer outro
+	// pacote com ela é descartado, antes de pular o token e a origem
+	s.mu.Lock()
+	mirrored := s.mirroredVoteLocked(msg, addr)
+	s.mu.Unlock()
+	if msg.Mirror && !mirrored {
+		log.Printf("[MIRROR] %s espelhado de %s descartado (origem não autorizada ou tipo inválido)", msg.Type, addr)
+		return
+	}
+
+	// Mesmo ID ativo a partir de dois endereços (votos espelhados vêm do primário)
+	if !mirrored && !s.sourceAllowed(msg.ClientID, addr) {
+		return
+	}
+
+	// Token do cliente nos pacotes que agem em nome dele (SetClientSecret)
+	if !s.authorized(msg, addr) {
+		return
+	}
+
+	// Qualquer pacote do endereço registrado conta como sinal de vida
+	s.mu.Lock()
+	s.touchClientLocked(msg.ClientID, addr)
+	s.mu.Unlock()
+
 	// Roteia pela ação
 	switch msg.Type {
 	case "REGISTER":
-		s.registerClient(msg.ClientID, addr)
+		s.registerClient(msg, addr)
 	case "VOTE":
-		s.processVote(msg.ClientID, msg.VoteOption, addr)
+		if msg.Mirror {
+			s.processMirroredVote(msg.ClientID, msg.VoteOption, msg.Weight, addr)
+			break
+		}
+		s.processVote(msg.ClientID, msg.VoteOption, msg.RequestID, addr)
+	case "UNREGISTER":
+		s.unregisterClient(msg.ClientID, addr)
+	case "DELEGATE":
+		s.delegate(msg.ClientID, msg.Delegate, addr)
+	case "MUTE":
+		s.setMuted(msg.ClientID, true, addr)
+	case "UNMUTE":
+		s.setMuted(msg.ClientID, false, addr)
+	case "PING":
+		s.pong(msg.SeqNum, addr)
+	case "PONG":
+		// Resposta ao PING de SetIdleTimeout: a atividade já foi renovada
+	case "RESYNC":
+		s.resync(msg.ClientID, msg.SeqNum, msg.UpTo, addr)
+	case "GET_RESULTS":
+		s.getResults(msg.ClientID, addr)
+	case "TIME_LEFT":
+		s.timeLeft(addr)
+	case "SERVER_STATS":
+		s.replyServerStats(msg.ClientID, addr)
+	case "ADMIN_END":
+		s.adminEnd(msg, addr)
 	default:
 		log.Println("Mensagem desconhecida:", msg.Type)
 	}
 }
 
+// SetAllowedTypes restringe os tipos de mensagem aceitos pelo servidor
+// (ex.: desabilitar tipos administrativos na porta pública).
+// Lista vazia volta a aceitar todos os tipos.
+func (s *UDPServer) SetAllowedTypes(types []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(types) == 0 {
+		s.allowedTypes = nil
+		return
+	}
+	s.allowedTypes = make(map[string]bool, len(types))
+	for _, t := range types {
+		s.allowedTypes[t] = true
+	}
+}
+
+// typeAllowed aplica o filtro de tipos, respondendo ERROR aos recusados
+func (s *UDPServer) typeAllowed(t string, addr *net.UDPAddr) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.allowedTypes == nil || s.allowedTypes[t] {
+		return true
+	}
+	s.rejectedTypes++
+	s.send(addr, Message{Type: "ERROR", Message: "Operação não permitida"})
+	return false
+}
+
+// RejectedTypes devolve quantos pacotes foram recusados pelo filtro de tipos
+func (s *UDPServer) RejectedTypes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rejectedTypes
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // REGISTRO DE CLIENTE
 ///////////////////////////////////////////////////////////////////////////////
 
-func (s *UDPServer) registerClient(id string, addr *net.UDPAddr) {
+func (s *UDPServer) registerClient(req Message, addr *net.UDPAddr) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Não permite dois clientes com o mesmo ID
+	id, seq := req.ClientID, req.SeqNum
+
+	// ID vazio, longo demais ou com caracteres de controle
+	if !validClientID(id) {
+		s.send(addr, Message{Type: "ERROR", Message: "ID inválido", SeqNum: seq})
+		return
+	}
+
+	// Retransmissão do REGISTER já aceito (mesmo endereço): responde de novo
+	if prev, exists := s.clients[id]; exists && prev.addr.String() == addr.String() {
+		ack := s.registerAckLocked(seq)
+		ack.Auth = s.authTokenLocked(id)
+		s.send(addr, ack)
+		return
+	}
+
+	// Cliente com protocolo antigo demais
+	if req.Version < s.minClientVersion {
+		s.send(addr, s.versionErrorLocked(req.Version, seq))
+		return
+	}
+
+	// Peso do voto só com SetWeightedVoting, e sempre positivo
+	if reason := s.weightErrorLocked(req.Weight); reason != "" {
+		s.send(addr, Message{Type: "ERROR", Message: reason, SeqNum: seq})
+		return
+	}
+
+	// Prova de trabalho: sem resposta ao desafio atual, envia o desafio
+	if s.powDifficulty > 0 {
+		challenge := s.challengeLocked(id, addr)
+		if req.Nonce == "" || req.Challenge != challenge {
+			s.send(addr, Message{
+				Type:       "CHALLENGE",
+				ClientID:   id,
+				Challenge:  challenge,
+				Difficulty: s.powDifficulty,
+				SeqNum:     seq,
+			})
+			return
+		}
+		if !powValid(challenge, req.Nonce, s.powDifficulty) {
+			s.send(addr, Message{Type: "ERROR", Message: "Prova de trabalho inválida", SeqNum: seq})
+			return
+		}
+	}
+
+	// Não permite dois clientes com o mesmo ID, salvo troca de endereço
+	// depois de o antigo ficar ocioso
 	if _, exists := s.clients[id]; exists {
-		s.send(addr, Message{Type: "ERROR", Message: "ID já registrado"})
+		if !s.rebindLocked(id, addr, req.Auth) {
+			s.send(addr, Message{Type: "ERROR", Message: "ID já registrado", SeqNum: seq})
+			return
+		}
+		ack := s.registerAckLocked(seq)
+		ack.Auth = s.authTokenLocked(id)
+		s.send(addr, ack)
+		return
+	}
+
+	// Limite de clientes e de taxa de registros
+	if reason := s.admitLocked(); reason != "" {
+		s.send(addr, Message{Type: "ERROR", Message: reason, SeqNum: seq})
 		return
 	}
 
 	// Salva endereço do cliente
-	s.clients[id] = addr
-	log.Printf("[JOIN] %s (%s)", id, addr)
+	how := ""
+	if req.Weight != nil {
+		how = fmt.Sprintf(" peso %d", *req.Weight)
+	}
+	s.addClientLocked(id, addr, how)
+	if req.Weight != nil {
+		s.clients[id].weight = *req.Weight
+	}
 
+	ack := s.registerAckLocked(seq)
+	ack.Auth = s.authTokenLocked(id)
+	if s.isObserverToken(req.Token) {
+		s.observers[id] = true
+		ack.Message += " (observador)"
+		log.Printf("[OBSERVER] %s", id)
+	}
+	s.send(addr, ack)
+}
+
+// SetHideLiveResults oculta os parciais dos votantes (evita efeito manada).
+// Durante a votação, só quem registrou com o token de observador recebe os
+// broadcasts; o resultado final é enviado a todos.
+func (s *UDPServer) SetHideLiveResults(hide bool, observerToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hideLive = hide
+	s.observerToken = observerToken
+}
+
+// isObserverToken compara o token em tempo constante
+func (s *UDPServer) isObserverToken(token string) bool {
+	if s.observerToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.observerToken)) == 1
+}
+
+// registerAckLocked monta o ACK de registro conforme o estado da votação.
+// O SeqNum do REGISTER é ecoado para o cliente descartar ACKs de tentativas antigas.
+func (s *UDPServer) registerAckLocked(seq int) Message {
 	// Mensagem padrão
 	msg := Message{
 		Type:    "ACK",
 		Message: "Aguardando início da votação",
 		Options: s.options,
+		SeqNum:  seq,
+		Version: ProtocolVersion,
+	}
+	if s.votingState == VotingNotStarted && !s.openAt.IsZero() {
+		msg.Message = "Votação abre em " + s.openAt.Format(time.RFC3339)
 	}
 
 	// Se já estiver rolando votação, informa tempo restante
 	if s.votingState == VotingActive {
-		remaining := time.Until(s.votingDeadline).Truncate(time.Second)
+		remaining := s.votingDeadline.Sub(s.now()).Truncate(time.Second)
 		msg.Message = fmt.Sprintf("Votação ativa (%s restantes)", remaining)
 	}
+	if s.votingState == VotingPaused {
+		remaining := s.votingDeadline.Sub(s.pausedAt).Truncate(time.Second)
+		msg.Message = fmt.Sprintf("Votação pausada (%s restantes)", remaining)
+	}
 
 	// Se já acabou, manda resultado final
 	if s.votingState == VotingEnded {
-		msg.Message = fmt.Sprintf("Votação encerrada: %v", s.voteCounts)
+		shown, _ := s.displayLocked(s.voteCounts)
+		msg.Message = fmt.Sprintf("Votação encerrada: %v", shown)
 	}
 
-	s.send(addr, msg)
+	return msg
+}
+
+// SetRegistrationRate limita quantos registros novos são aceitos por segundo,
+// somando todos os clientes. Zero remove o limite.
+func (s *UDPServer) SetRegistrationRate(perSecond int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if perSecond <= 0 {
+		s.regLimiter = nil
+		return
+	}
+	s.regLimiter = newTokenBucket(perSecond, perSecond, s.now())
+}
+
+// ThrottledRegistrations devolve quantos registros foram recusados pelo limite de taxa
+func (s *UDPServer) ThrottledRegistrations() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.regThrottled
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // PROCESSAMENTO DE VOTO
 ///////////////////////////////////////////////////////////////////////////////
 
-func (s *UDPServer) processVote(id, option string, addr *net.UDPAddr) {
+// processVote valida e contabiliza o voto. `reqID` é o RequestID do VOTE,
+// devolvido sem alteração no ACK ou ERROR da resposta.
+func (s *UDPServer) processVote(id, option, reqID string, addr *net.UDPAddr) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Cliente precisa estar registrado
+	s.votesReceived++
+	s.metrics.Inc(MetricVotesReceived)
+
+	// Cliente precisa estar registrado (ou ser registrado agora, conforme a política)
 	if _, ok := s.clients[id]; !ok {
-		s.send(addr, Message{Type: "ERROR", Message: "Registre-se primeiro"})
+		if reason := s.autoRegisterLocked(id, addr); reason != "" {
+			s.rejectVoteLocked(addr, reqID, reason)
+			return
+		}
+	}
+
+	c, ok := s.clients[id]
+	if !ok {
+		s.rejectVoteLocked(addr, reqID, "Registre-se primeiro")
+		return
+	}
+
+	// Só o endereço registrado vota pelo ID
+	if c.addr.String() != addr.String() {
+		s.rejectVoteLocked(addr, reqID, "Endereço não corresponde")
+		return
+	}
+
+	// Cópia retransmitida de um voto já confirmado: só repete o ACK
+	if s.reackVoteLocked(id, reqID, addr) {
 		return
 	}
 
 	// Votação precisa estar ativa
-	if s.votingState != VotingActive || time.Now().After(s.votingDeadline) {
-		s.send(addr, Message{Type: "ERROR", Message: "Votação encerrada"})
+	if s.votingState == VotingNotStarted {
+		s.rejectVoteLocked(addr, reqID, s.notStartedMessageLocked())
+		return
+	}
+	// Prazo vencido antes do timer de encerramento: encerra aqui, para o
+	// estado e a resposta concordarem
+	if s.votingState == VotingActive && s.deadlinePassedLocked(s.now()) {
+		s.endVotingLocked()
+	}
+	if s.votingState == VotingPaused {
+		s.rejectVoteLocked(addr, reqID, "Votação pausada")
+		return
+	}
+	if s.votingState != VotingActive {
+		s.rejectVoteLocked(addr, reqID, "Votação encerrada")
 		return
 	}
 
-	// Não pode votar 2x
-	if _, ok := s.votes[id]; ok {
-		s.send(addr, Message{Type: "ERROR", Message: "Voto duplicado"})
+	// Forma configurada da opção (SetCaseInsensitiveOptions)
+	option = s.canonicalOptionLocked(option)
+
+	// Não pode votar 2x (com SetAllowRevote, pode trocar de opção)
+	prev, voted := s.votes[id]
+	if voted && (!s.allowRevote || prev == option) {
+		s.rejectVoteLocked(addr, reqID, "Voto duplicado")
 		return
 	}
 
 	// Opção precisa existir
 	if _, valid := s.voteCounts[option]; !valid {
-		s.send(addr, Message{Type: "ERROR", Message: "Opção inválida"})
+		s.rejectVoteLocked(addr, reqID, "Opção inválida")
+		return
+	}
+
+	// Contagem no limite do int64: recusa em vez de estourar (a troca de
+	// voto não muda o total)
+	if !voted && s.tallyFullLocked(s.clientWeightLocked(id)) {
+		s.rejectVoteLocked(addr, reqID, "Limite da contagem atingido")
 		return
 	}
 
 	// Registra voto
-	s.votes[id] = option
-	s.voteCounts[option]++
+	if voted {
+		s.changeVoteLocked(id, prev, option)
+	} else {
+		s.recordVoteLocked(id, option)
+	}
 
 	// Responde apenas ao votante
-	s.send(addr, Message{Type: "ACK", Message: "Voto registrado"})
+	s.ackVoteLocked(id, reqID, option, addr, voted)
+
+	// Quem delegou a este votante passa a ter o voto contado
+	s.applyDelegationsLocked()
 
 	// Broadcast para todos verem placar atualizado
 	// Agora protegido por mutex
 	s.broadcastUpdateLocked()
 }
 
+// rejectVoteLocked recusa o voto com a mensagem de erro `reason`
+func (s *UDPServer) rejectVoteLocked(addr *net.UDPAddr, reqID, reason string) {
+	s.metrics.Inc(MetricVotesRejected)
+	s.send(addr, Message{Type: "ERROR", Message: reason, RequestID: reqID})
+}
+
+// recordVoteLocked contabiliza um voto já validado
+func (s *UDPServer) recordVoteLocked(id, option string) {
+	w := s.clientWeightLocked(id)
+	s.votes[id] = option
+	s.voteCounts[option] += w
+	if w != 1 {
+		s.voteWeights[id] = w
+	}
+	s.votesAccepted++
+	s.metrics.Inc(MetricVotesAccepted)
+	s.touchOptionLocked(option)
+	s.appendAuditLocked(id, option, w)
+	s.mirrorVoteLocked(id, option, w)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PING / PONG
+///////////////////////////////////////////////////////////////////////////////
+
+// SetReportLoad habilita o envio de uptime e carga do servidor no PONG
+func (s *UDPServer) SetReportLoad(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reportLoad = enabled
+}
+
+// pong responde ao PING devolvendo o mesmo SeqNum (para o cliente medir o RTT).
+// Não exige registro: serve como verificação de saúde do servidor.
+func (s *UDPServer) pong(seq int, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := Message{Type: "PONG", SeqNum: seq}
+	if s.reportLoad {
+		msg.Uptime = time.Since(s.startedAt).Seconds()
+		msg.Goroutines = runtime.NumGoroutine()
+		msg.ClientCount = len(s.clients)
+	}
+	s.send(addr, msg)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// SILENCIAR BROADCASTS
+///////////////////////////////////////////////////////////////////////////////
+
+// setMuted tira (ou recoloca) o cliente da lista de destino dos broadcasts parciais.
+// O resultado final é entregue a todos, silenciados ou não.
+func (s *UDPServer) setMuted(id string, muted bool, addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.clients[id]
+	if !ok {
+		s.send(addr, Message{Type: "ERROR", Message: "Registre-se primeiro"})
+		return
+	}
+	// Só o endereço registrado silencia o próprio ID
+	if c.addr.String() != addr.String() {
+		s.send(addr, Message{Type: "ERROR", Message: "Endereço não corresponde"})
+		return
+	}
+
+	if muted {
+		s.muted[id] = true
+		s.send(addr, Message{Type: "ACK", Message: "Broadcasts silenciados"})
+		return
+	}
+	delete(s.muted, id)
+	s.send(addr, Message{Type: "ACK", Message: "Broadcasts reativados"})
+}
+
 // broadcastUpdateLocked deve ser chamado com o mutex já travado
 func (s *UDPServer) broadcastUpdateLocked() {
-	s.broadcastSeq++ // incrementa versão do broadcast
+	// Toda mudança no placar passa por aqui (SetStateFile)
+	defer s.saveStateLocked()
+
+	// No modo degradado (ou com SetBroadcastInterval) apenas marca o placar
+	// como pendente; o worker envia um snapshot agrupado a cada intervalo
+	if s.degraded || s.broadcastInterval > 0 {
+		s.pendingUpdate = true
+		return
+	}
+	s.enqueueBroadcastLocked(nil)
+}
 
-	// Cria snapshot seguro dos votos
-	snap := make(map[string]int)
-	for k, v := range s.voteCounts {
-		snap[k] = v
+// enqueueBroadcastLocked cria o snapshot e o coloca na fila do broadcast worker.
+// final só é preenchido no broadcast de encerramento.
+func (s *UDPServer) enqueueBroadcastLocked(final *FinalResult) {
+	if s.stopped {
+		return // servidor encerrado: a fila já foi fechada
 	}
+	s.broadcastSeq++ // incrementa versão do broadcast
+
+	// Cria snapshot seguro dos votos (como exibido, ver SetMinVotesToDisplay)
+	snap, ordered := s.displayLocked(s.voteCounts)
+
+	// Assinantes TCP recebem todo update, mesmo os que o UDP descartar;
+	// o histórico permite que clientes UDP recuperem o que perderam
+	msg := Message{Type: "BROADCAST", VoteCounts: snap, Results: ordered, SeqNum: s.broadcastSeq, Final: final, Message: final.notice()}
+	s.publishLocked(msg)
+	s.recordHistoryLocked(msg)
 
 	// Se o canal estiver cheio, descarta (evita travamento)
 	select {
-	case s.broadcastChan <- BroadcastUpdate{VoteCounts: snap, SeqNum: s.broadcastSeq}:
+	case s.broadcastChan <- BroadcastUpdate{VoteCounts: snap, Results: ordered, SeqNum: s.broadcastSeq, Final: final}:
 	default:
 		log.Println("[UDP] Broadcast descartado (fila cheia)")
+		s.recordDropLocked()
+	}
+}
+
+// recordDropLocked contabiliza um descarte e ativa o modo degradado
+// quando a taxa de descartes passa do limite dentro da janela
+func (s *UDPServer) recordDropLocked() {
+	now := time.Now()
+	s.broadcastsDropped++
+	s.metrics.Inc(MetricBroadcastsDropped)
+	s.lastDrop = now
+
+	if now.Sub(s.dropWindow) > degradeWindow {
+		s.dropWindow = now
+		s.dropCount = 0
+	}
+	s.dropCount++
+
+	if !s.degraded && s.dropCount >= degradeThreshold {
+		s.degraded = true
+		log.Printf("[WARN] Fila de broadcast saturada (%d descartes em %s): descartando carga, agrupando updates a cada %s",
+			s.dropCount, degradeWindow, degradedInterval)
 	}
 }
 
@@ -234,79 +1191,242 @@ func (s *UDPServer) broadcastUpdate() {
 
 // Worker rodando em goroutine que envia atualizações
 func (s *UDPServer) broadcastWorker() {
+	defer close(s.workerDone)
 	for update := range s.broadcastChan {
 		s.sendBroadcast(update)
 	}
 }
 
+// Worker que envia os updates agrupados durante o modo degradado
+// e restaura o modo normal quando a pressão na fila diminui
+func (s *UDPServer) degradeWorker() {
+	ticker := time.NewTicker(degradedInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		if s.degraded {
+			if s.pendingUpdate {
+				s.pendingUpdate = false
+				s.enqueueBroadcastLocked(nil)
+			}
+
+			// Uma janela inteira sem descartes e fila com folga: volta ao normal
+			if time.Since(s.lastDrop) > degradeWindow && len(s.broadcastChan) < cap(s.broadcastChan)/4 {
+				s.degraded = false
+				s.dropCount = 0
+				log.Println("[INFO] Pressão no broadcast normalizada: modo normal restaurado")
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
 // Envia update para todos os clientes
 func (s *UDPServer) sendBroadcast(update BroadcastUpdate) {
-	data, _ := json.Marshal(Message{
+	msg := Message{
 		Type:       "BROADCAST",
 		VoteCounts: update.VoteCounts,
+		Results:    update.Results,
 		SeqNum:     update.SeqNum,
-	})
+		Final:      update.Final,
+		Message:    update.Final.notice(),
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	for _, addr := range s.clients {
+	s.signLocked(&msg)
+	data, _ := json.Marshal(msg)
+	s.checkSizeLocked(data, "BROADCAST")
+	s.logBroadcastLocked(data)
+	if update.Final != nil {
+		// Depois do resultado final, o estado por cliente pode ser liberado
+		defer s.compactLocked()
+	}
+	fanout := 0
+	defer func() {
+		s.metrics.Inc(MetricBroadcastsSent)
+		s.metrics.Observe(MetricBroadcastFanout, float64(fanout))
+	}()
+	for id, c := range s.clients {
+		addr := c.addr
+		// Silenciados só recebem o resultado final
+		if s.muted[id] && update.Final == nil {
+			continue
+		}
+		// Com parciais ocultos, apenas observadores recebem antes do final
+		if s.hideLive && !s.observers[id] && update.Final == nil {
+			continue
+		}
 		// Protege contra escrita em conexão fechada
 		if s.conn != nil {
 			s.conn.WriteToUDP(data, addr)
+			s.deliveryLocked(id).sent++
+			fanout++
 		}
 	}
 }
 
+// Degraded informa se o servidor está em modo degradado de broadcast
+func (s *UDPServer) Degraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.degraded
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // FUNÇÃO DE ENVIO INDIVIDUAL
 ///////////////////////////////////////////////////////////////////////////////
 
 func (s *UDPServer) send(addr *net.UDPAddr, msg Message) {
+	s.signLocked(&msg)
 	data, _ := json.Marshal(msg)
+	s.checkSizeLocked(data, msg.Type)
 	// Protege contra escrita em conexão fechada
 	if s.conn != nil {
 		s.conn.WriteToUDP(data, addr)
 	}
 }
 
+// checkSizeLocked avisa quando uma mensagem passa do limite de fragmentação
+func (s *UDPServer) checkSizeLocked(data []byte, kind string) {
+	if s.fragmentThreshold <= 0 || len(data) <= s.fragmentThreshold {
+		return
+	}
+	s.fragmentRisk++
+	log.Printf("[WARN] %s de %d bytes excede %d bytes: risco de fragmentação IP e perda",
+		kind, len(data), s.fragmentThreshold)
+}
+
+// SetFragmentThreshold define o tamanho a partir do qual uma mensagem enviada
+// é contada como risco de fragmentação. Zero desliga a verificação.
+func (s *UDPServer) SetFragmentThreshold(bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fragmentThreshold = bytes
+}
+
+// FragmentRisk devolve quantas mensagens foram enviadas acima do limite
+func (s *UDPServer) FragmentRisk() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fragmentRisk
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // INICIAR/ENCERRAR VOTAÇÃO
 ///////////////////////////////////////////////////////////////////////////////
 
 func (s *UDPServer) StartVoting(sec int) {
 	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	// Só inicia se ainda não começou
 	if s.votingState != VotingNotStarted {
-		s.mu.Unlock()
 		return
 	}
+	s.startVotingLocked(sec)
+}
 
-	s.votingState = VotingActive
-	s.votingDeadline = time.Now().Add(time.Duration(sec) * time.Second)
-	s.mu.Unlock()
-
+// startVotingLocked abre a rodada atual por `sec` segundos
+func (s *UDPServer) startVotingLocked(sec int) {
 	log.Printf("Votação iniciada (%ds)", sec)
+	s.openVotingLocked(s.now().Add(time.Duration(sec) * time.Second))
+}
+
+// openVotingLocked abre a votação agora, até `deadline`. Toda abertura
+// passa por aqui (início manual, segundo turno, nova rodada, agendamento),
+// para a hora de abertura sair no resultado.
+func (s *UDPServer) openVotingLocked(deadline time.Time) {
+	now := s.now()
+	s.votingState = VotingActive
+	s.votingOpened = now
+	s.votingDeadline = deadline
 
 	// Anuncia para todos
-	s.broadcastUpdate()
+	s.broadcastUpdateLocked()
 
 	// Agendado encerramento automático
-	time.AfterFunc(time.Duration(sec)*time.Second, s.endVoting)
+	s.scheduleEndLocked(deadline.Sub(now))
+}
+
+// scheduleEndLocked agenda o encerramento da rodada atual para daqui a `d`,
+// no lugar do agendamento anterior
+func (s *UDPServer) scheduleEndLocked(d time.Duration) {
+	s.stopEndTimerLocked()
+	round, pauses := s.round, s.pauses
+	s.endTimer = time.AfterFunc(d, func() { s.endVoting(round, pauses) })
 }
 
-func (s *UDPServer) endVoting() {
+// stopEndTimerLocked cancela o encerramento agendado, se houver: a votação
+// encerrada antes do prazo, pausada ou descartada não deixa timer pendente
+func (s *UDPServer) stopEndTimerLocked() {
+	if s.endTimer != nil {
+		s.endTimer.Stop()
+		s.endTimer = nil
+	}
+}
+
+// endVoting encerra a votação se ela ainda estiver na rodada `round` e sem
+// pausa desde o agendamento: o timer de uma rodada encerrada antes (pelo
+// prazo conferido a cada pacote) não encerra o segundo turno aberto em
+// seguida, e o prazo estendido por uma pausa tem timer próprio. O Stop não
+// segura um timer que já disparou e espera s.mu; para ele, vale essa
+// conferência.
+func (s *UDPServer) endVoting(round, pauses int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.round != round || s.pauses != pauses {
+		return
+	}
+	s.endVotingLocked()
+}
 
+func (s *UDPServer) endVotingLocked() {
 	// Evita encerrar duas vezes
 	if s.votingState != VotingActive {
 		return
 	}
+	// Encerrada antes do prazo, o timer não tem mais o que fazer (e é
+	// parado antes de um eventual segundo turno agendar o próprio)
+	s.stopEndTimerLocked()
 
 	s.votingState = VotingEnded
-	log.Printf("Votação encerrada: %v", s.voteCounts)
+	final, _ := MarshalResults(s.options, s.voteCounts)
+	log.Printf("Votação encerrada: %s (selo %s)", final, s.chainHash)
+	shown, _ := s.displayLocked(s.voteCounts)
+	log.Printf("Apuração final:\n%s", resultfmt.Table(shown))
+	if n := s.pendingDelegationsLocked(); n > 0 {
+		log.Printf("[DELEGATE] %d delegações sem voto na cadeia (abstenção)", n)
+	}
+	r := s.resultsLocked()
+	if r.NonBinding {
+		log.Printf("[QUORUM] Quórum não atingido: %d de %d votos (resultado não vinculante)", r.TotalVotes, r.Quorum)
+	}
+	s.final = &r
+	s.persisted = s.writeResultsLocked(r)
 
-	// Envia resultado final para todos
-	s.broadcastUpdateLocked()
+	// Envia resultado final para todos, mesmo no modo degradado,
+	// com o hash final da cadeia de auditoria
+	s.pendingUpdate = false
+	s.enqueueBroadcastLocked(&FinalResult{
+		ChainHash:  r.ChainHash,
+		Decision:   r.Decision,
+		Winner:     r.Winner,
+		Tied:       r.Tied,
+		RunnerUp:   r.RunnerUp,
+		Margin:     r.Margin,
+		MarginPct:  r.MarginPct,
+		Quorum:     r.Quorum,
+		NonBinding: r.NonBinding,
+	})
+	s.saveStateLocked()
+
+	// Empate na liderança: segundo turno, se configurado
+	s.runoffLocked()
 }
@@ -0,0 +1,503 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// floodVoter é o votante i do flood: IDs de tamanhos diferentes, para um
+// buffer reaproveitado cedo demais corromper o JSON ou trocar os campos
+func floodVoter(i int) (id, option string) {
+	id = fmt.Sprintf("v%d-%s", i, strings.Repeat("x", i%37))
+	option = "A"
+	if i%3 == 0 {
+		option = "B"
+	}
+	return id, option
+}
+
+// Flood pelo readLoop e pelo pool de workers: cada datagrama é tratado com
+// o próprio conteúdo, nenhum pacote é perdido, trocado ou mal decodificado.
+// Rode com -race: o buffer de leitura é compartilhado entre leituras.
+func TestReadLoopFloodDecodesEveryPacket(t *testing.T) {
+	s, f := newFakeServer(t)
+	serveFake(s, f)
+
+	const voters, copies = 200, 3
+	for i := 1; i <= voters; i++ {
+		id, _ := floodVoter(i)
+		f.inject(testAddr(i), Message{Type: "REGISTER", ClientID: id})
+	}
+	for i := 1; i <= voters; i++ {
+		f.waitFor(t, testAddr(i), func(m Message) bool { return m.Type == "ACK" })
+	}
+	s.StartVoting(3600)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= voters; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, option := floodVoter(i)
+			for n := 0; n < copies; n++ {
+				f.inject(testAddr(i), Message{Type: "VOTE", ClientID: id, VoteOption: option, RequestID: id + "/r"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := map[string]int64{"A": 0, "B": 0}
+	for i := 1; i <= voters; i++ {
+		id, option := floodVoter(i)
+		want[option]++
+		addr := testAddr(i)
+		ack := f.waitFor(t, addr, func(m Message) bool { return m.Type == "ACK" && m.RequestID != "" })
+		if ack.RequestID != id+"/r" || ack.RecordedOption != option || len(ack.Acked) != 1 || ack.Acked[0] != id {
+			t.Fatalf("votante %d (%s em %s) recebeu %+v", i, id, option, ack)
+		}
+		if errs := f.ofType(addr, "ERROR"); len(errs) > 0 {
+			t.Fatalf("votante %d recebeu erro: %+v", i, errs[0])
+		}
+	}
+	stopFake(s, f)
+	if n := s.DroppedPackets(); n != 0 {
+		t.Fatalf("%d pacotes descartados pela fila", n)
+	}
+	wantTally(t, s, want)
+	if st := s.Stats(); st.VotesReceived != voters*copies || st.VotesAccepted != voters {
+		t.Fatalf("recebidos %d, aceitos %d; esperava %d e %d", st.VotesReceived, st.VotesAccepted, voters*copies, voters)
+	}
+}
+
+// O mesmo flood por sockets de verdade, na leitura simples e na leitura em
+// lote (recvmmsg no Linux)
+func TestSocketFloodDecodesEveryPacket(t *testing.T) {
+	for _, batch := range []int{0, 8} {
+		t.Run(fmt.Sprintf("lote %d", batch), func(t *testing.T) {
+			s, err := NewUDPServer([]string{"A", "B"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.SetBatchRead(batch)
+			startUDP(t, s)
+			s.StartVoting(3600)
+
+			const voters = 50
+			want := map[string]int64{"A": 0, "B": 0}
+			errc := make(chan error, voters)
+			for i := 1; i <= voters; i++ {
+				id, option := floodVoter(i)
+				want[option]++
+				go func() {
+					c, err := client.Dial(s.Addr().String(), id, client.Options{})
+					if err != nil {
+						errc <- err
+						return
+					}
+					defer c.Close()
+					if err := c.Register(); err != nil {
+						errc <- fmt.Errorf("%s: %v", id, err)
+						return
+					}
+					if _, err := c.Vote(option); err != nil {
+						errc <- fmt.Errorf("%s: %v", id, err)
+						return
+					}
+					if got := c.RecordedVote(); got != option {
+						errc <- fmt.Errorf("%s: opção registrada %q, esperava %q", id, got, option)
+						return
+					}
+					errc <- nil
+				}()
+			}
+			for i := 0; i < voters; i++ {
+				if err := <-errc; err != nil {
+					t.Fatal(err)
+				}
+			}
+			wantTally(t, s, want)
+		})
+	}
+}
+
+// Com SetReusePort(4), o kernel distribui os clientes (cada um com a própria
+// porta de origem) entre os sockets: todos leem pacotes e a contagem fecha
+func TestReusePortAllSocketsReceive(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT indisponível nesta plataforma")
+	}
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetReusePort(4)
+	startUDP(t, s)
+	s.StartVoting(3600)
+
+	const voters = 64
+	want := map[string]int64{"A": 0, "B": 0}
+	for i := 1; i <= voters; i++ {
+		id, option := floodVoter(i)
+		want[option]++
+		c, err := client.Dial(s.Addr().String(), id, client.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		if err := c.Register(); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if _, err := c.Vote(option); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+	}
+	wantTally(t, s, want)
+
+	reads := s.Stats().SocketPackets
+	if len(reads) != 4 {
+		t.Fatalf("%d sockets, esperava 4", len(reads))
+	}
+	for i, n := range reads {
+		if n == 0 {
+			t.Fatalf("socket %d não recebeu nenhum pacote: %v", i, reads)
+		}
+	}
+}
+
+// saturateBroadcast enche a fila de broadcast com o worker parado no mutex e
+// tenta mais `drops` broadcasts, todos descartados
+func saturateBroadcast(s *UDPServer, drops int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for full := false; !full; {
+		select {
+		case s.broadcastChan <- BroadcastUpdate{}:
+		default:
+			full = true
+		}
+	}
+	for i := 0; i < drops; i++ {
+		s.enqueueBroadcastLocked(nil)
+	}
+}
+
+// degradedGauge lê udpvote_server_degraded do endpoint de métricas
+func degradedGauge(t *testing.T, s *UDPServer) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.serveMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if v, ok := strings.CutPrefix(line, "udpvote_server_degraded "); ok {
+			return v
+		}
+	}
+	t.Fatalf("udpvote_server_degraded ausente:\n%s", rec.Body.String())
+	return ""
+}
+
+// Descartes abaixo do limite não mudam o modo
+func TestDegradedBelowThreshold(t *testing.T) {
+	s, _ := newFakeServer(t)
+	saturateBroadcast(s, degradeThreshold-1)
+	if s.Degraded() {
+		t.Fatalf("modo degradado com %d descartes (limite %d)", degradeThreshold-1, degradeThreshold)
+	}
+}
+
+// Transbordo contínuo da fila: o servidor entra no modo degradado, agrupa
+// os updates no intervalo longo e volta ao normal quando a fila esvazia
+func TestDegradedEntersAndExits(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia", "caio")
+	a := testAddr(1)
+
+	saturateBroadcast(s, degradeThreshold)
+	if !s.Degraded() || !s.Stats().Degraded {
+		t.Fatal("fila saturada sem modo degradado")
+	}
+	if got := degradedGauge(t, s); got != "1" {
+		t.Fatalf("udpvote_server_degraded = %s no modo degradado", got)
+	}
+	if n := s.Stats().BroadcastsDropped; n < degradeThreshold {
+		t.Fatalf("%d broadcasts descartados, esperava pelo menos %d", n, degradeThreshold)
+	}
+
+	// Os votos no modo degradado só marcam o placar; o update sai no
+	// intervalo do worker
+	vote(s, f, "ana", a, "A")
+	vote(s, f, "bia", testAddr(2), "B")
+	f.waitFor(t, a, func(m Message) bool {
+		return m.Type == "BROADCAST" && m.VoteCounts["A"] == 1 && m.VoteCounts["B"] == 1
+	})
+
+	// Uma janela sem descartes e a fila com folga: modo normal
+	deadline := time.Now().Add(degradeWindow + 4*degradedInterval)
+	for s.Degraded() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.Degraded() {
+		t.Fatal("modo degradado não terminou depois que a fila esvaziou")
+	}
+	if got := degradedGauge(t, s); got != "0" {
+		t.Fatalf("udpvote_server_degraded = %s depois de normalizar", got)
+	}
+
+	// De volta ao normal, cada voto gera o próprio update
+	vote(s, f, "caio", testAddr(3), "A")
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts["A"] == 2 })
+}
+
+// floodSocket dispara uma goroutine por CPU enviando data ao socket de s
+// sem parar, até stop ser chamado
+func floodSocket(b *testing.B, s *UDPServer, data []byte) (stop func()) {
+	b.Helper()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		conn, err := net.DialUDP("udp", nil, s.Addr().(*net.UDPAddr))
+		if err != nil {
+			b.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				conn.Write(data)
+			}
+		}()
+	}
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// socketReadsTotal soma os datagramas lidos em todos os sockets de s
+func socketReadsTotal(s *UDPServer) int64 {
+	s.mu.Lock()
+	reads := s.socketReads
+	s.mu.Unlock()
+	total := int64(0)
+	for i := range reads {
+		total += reads[i].Load()
+	}
+	return total
+}
+
+// BenchmarkSocketRead compara a vazão da leitura simples com a leitura em
+// lote (recvmmsg no Linux) e com vários sockets em SO_REUSEPORT sob flood:
+// ns/op é o tempo por datagrama lido
+func BenchmarkSocketRead(b *testing.B) {
+	ping, _ := json.Marshal(Message{Type: "PING"})
+	cases := []struct {
+		name         string
+		batch, reuse int
+	}{
+		{"lote 0", 0, 0},
+		{"lote 32", 32, 0},
+		{"reuseport 4", 0, 4},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			if tc.reuse > 1 && !reusePortSupported {
+				b.Skip("SO_REUSEPORT indisponível nesta plataforma")
+			}
+			s, err := NewUDPServer([]string{"A", "B"})
+			if err != nil {
+				b.Fatal(err)
+			}
+			s.SetBatchRead(tc.batch)
+			s.SetReusePort(tc.reuse)
+			startUDP(b, s)
+			stop := floodSocket(b, s, ping)
+			defer stop()
+
+			b.ResetTimer()
+			start := socketReadsTotal(s)
+			for socketReadsTotal(s)-start < int64(b.N) {
+				time.Sleep(100 * time.Microsecond)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(s.DroppedPackets())/float64(b.N), "drops/op")
+		})
+	}
+}
+
+// BenchmarkPacketFlood mostra que, sob flood contínuo, goroutines e heap
+// ficam limitados pelo pool de workers e pela fila: o excesso vira descarte
+// (drops/op) em vez de uma goroutine por datagrama
+func BenchmarkPacketFlood(b *testing.B) {
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	s.SetWorkers(4)
+	startUDP(b, s)
+	s.StartVoting(3600)
+	vote, _ := json.Marshal(Message{Type: "VOTE", ClientID: "intruso", VoteOption: "A"})
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	stop := floodSocket(b, s, vote)
+
+	peak := 0
+	b.ResetTimer()
+	start := socketReadsTotal(s)
+	for socketReadsTotal(s)-start < int64(b.N) {
+		if n := runtime.NumGoroutine(); n > peak {
+			peak = n
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+	b.StopTimer()
+	stop()
+
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(peak), "goroutines")
+	b.ReportMetric(float64(int64(after.HeapInuse)-int64(before.HeapInuse))/1024, "heap-KB")
+	b.ReportMetric(float64(s.DroppedPackets())/float64(b.N), "drops/op")
+}
+
+// Com SetAllowedTypes, um tipo administrativo fora da lista recebe ERROR e
+// conta em RejectedTypes, enquanto REGISTER e VOTE seguem funcionando
+func TestAllowedTypesRejectsAdmin(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetAdminSecret("segredo")
+	s.SetAllowedTypes([]string{"REGISTER", "VOTE"})
+	a, op := testAddr(1), testAddr(2)
+	register(t, s, f, "ana", a)
+	s.StartVoting(60)
+
+	deliver(s, op, Message{Type: "ADMIN_END", ClientID: "op"})
+	if got := f.last(op); got.Type != "ERROR" || got.Message != "Operação não permitida" {
+		t.Fatalf("ADMIN_END fora da lista = %+v", got)
+	}
+	if s.RejectedTypes() != 1 || s.Stats().RejectedTypes != 1 || s.State() != VotingActive {
+		t.Fatalf("recusados = %d, estado = %s", s.RejectedTypes(), s.State())
+	}
+	if got := vote(s, f, "ana", a, "A"); got.Type != "ACK" {
+		t.Fatalf("VOTE permitido = %+v", got)
+	}
+
+	// Lista vazia volta a aceitar todos os tipos
+	s.SetAllowedTypes(nil)
+	deliver(s, op, Message{Type: "ADMIN_END", ClientID: "op"})
+	if got := f.last(op); got.Message == "Operação não permitida" || s.RejectedTypes() != 1 {
+		t.Fatalf("ADMIN_END sem filtro = %+v (recusados %d)", got, s.RejectedTypes())
+	}
+}
+
+// Com parciais ocultos, só quem registrou com o token de observador recebe
+// os broadcasts durante a votação; o resultado final vai a todos
+func TestHideLiveResultsObservers(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetHideLiveResults(true, "tok")
+	obs, voter, guess := testAddr(1), testAddr(2), testAddr(3)
+	deliver(s, obs, Message{Type: "REGISTER", ClientID: "obs", Token: "tok"})
+	if got := f.last(obs); got.Type != "ACK" || !strings.Contains(got.Message, "observador") {
+		t.Fatalf("REGISTER com token = %+v", got)
+	}
+	register(t, s, f, "ana", voter)
+	deliver(s, guess, Message{Type: "REGISTER", ClientID: "bia", Token: "errado"})
+	s.StartVoting(60)
+
+	vote(s, f, "ana", voter, "A")
+	f.waitFor(t, obs, func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts["A"] == 1 })
+	for _, a := range []*net.UDPAddr{voter, guess} {
+		for _, m := range f.ofType(a, "BROADCAST") {
+			if len(m.VoteCounts) > 0 && m.Final == nil {
+				t.Fatalf("parcial enviado a %s sem token de observador: %+v", a, m)
+			}
+		}
+	}
+
+	s.EndVotingNow()
+	for _, a := range []*net.UDPAddr{obs, voter, guess} {
+		f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil && m.VoteCounts["A"] == 1 })
+	}
+}
+
+// syncBuffer recebe o log de várias goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Um broadcast acima do limite de fragmentação gera WARN e conta no
+// FragmentRisk; uma resposta pequena, não
+func TestFragmentRiskWarning(t *testing.T) {
+	var logs syncBuffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	options := make([]string, 80)
+	for i := range options {
+		options[i] = fmt.Sprintf("opção-com-nome-comprido-%02d", i)
+	}
+	s, f := newFakeServer(t, options...)
+	s.SetFragmentThreshold(1400)
+	register(t, s, f, "ana", testAddr(1)) // o ACK lista as opções: já passa do limite
+	before := s.FragmentRisk()
+
+	deliver(s, testAddr(1), Message{Type: "PING", ClientID: "ana", SeqNum: 1})
+	if m := f.last(testAddr(1)); m.Type != "PONG" {
+		t.Fatalf("PING respondido com %+v", m)
+	}
+	if n := s.FragmentRisk(); n != before {
+		t.Fatalf("resposta pequena contou como risco (%d → %d)", before, n)
+	}
+
+	s.StartVoting(3600)
+	m := f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" })
+	if data, _ := json.Marshal(m); len(data) <= 1400 {
+		t.Fatalf("broadcast de %d bytes não passa do limite", len(data))
+	}
+	if n := s.FragmentRisk(); n != before+1 || s.Stats().FragmentRisk != n {
+		t.Fatalf("FragmentRisk = %d (Stats %d), esperava %d", n, s.Stats().FragmentRisk, before+1)
+	}
+	if out := logs.String(); !strings.Contains(out, "[WARN] BROADCAST de") || !strings.Contains(out, "excede 1400 bytes") {
+		t.Fatalf("aviso de fragmentação ausente no log:\n%s", out)
+	}
+
+	// Zero desliga a verificação
+	s.SetFragmentThreshold(0)
+	s.mu.Lock()
+	s.broadcastUpdateLocked()
+	seq := s.broadcastSeq
+	s.mu.Unlock()
+	f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.SeqNum == seq })
+	if n := s.FragmentRisk(); n != before+1 {
+		t.Fatalf("FragmentRisk = %d com a verificação desligada", n)
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import "github.com/juander/udp-vote/pkg/broadcastsig"
+
+// ----------------------------------------------------------
+// Assinatura dos placares (HMAC com chave compartilhada)
+// ----------------------------------------------------------
+
+// SetBroadcastKey liga a assinatura de BROADCAST, RESYNC e SNAPSHOT com a
+// chave dada; clientes configurados com a mesma chave (-key) descartam
+// placares sem assinatura válida. Chave vazia desliga.
+func (s *UDPServer) SetBroadcastKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcastKey = []byte(key)
+	if key == "" {
+		s.broadcastKey = nil
+	}
+}
+
+// signLocked preenche Sig nas mensagens que carregam placar
+func (s *UDPServer) signLocked(msg *Message) {
+	if s.broadcastKey == nil {
+		return
+	}
+	switch msg.Type {
+	case "BROADCAST", "RESYNC", "SNAPSHOT":
+	default:
+		return
+	}
+	msg.Sig = broadcastsig.Sign(s.broadcastKey, sigFields(*msg))
+}
+
+// sigFields extrai de uma mensagem as partes assinadas
+func sigFields(msg Message) broadcastsig.Fields {
+	f := broadcastsig.Fields{Type: msg.Type, SeqNum: msg.SeqNum, VoteCounts: msg.VoteCounts}
+	if msg.Final != nil {
+		f.ChainHash, f.Decision, f.Winner = msg.Final.ChainHash, msg.Final.Decision, msg.Final.Winner
+		f.NonBinding, f.Tied = msg.Final.NonBinding, msg.Final.Tied
+	}
+	return f
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Ida e volta da assinatura: os campos que o servidor assina (sigFields)
+// são os mesmos que o pkg/client confere, inclusive no resultado final com
+// empate e abaixo do quórum
+func TestSignedBroadcastRoundTrip(t *testing.T) {
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetBroadcastKey("chave")
+	if err := s.SetQuorum(5); err != nil {
+		t.Fatal(err)
+	}
+	startUDP(t, s)
+	s.StartVoting(3600)
+
+	dial := func(name, key string) (*client.Client, chan client.Results, chan error) {
+		results, discarded := make(chan client.Results, 16), make(chan error, 16)
+		c, err := client.Dial(s.Addr().String(), name, client.Options{
+			Key: []byte(key),
+			OnDiscard: func(m client.Message, err error) {
+				if m.Type == "BROADCAST" {
+					discarded <- err
+				}
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		c.Subscribe(func(r client.Results) { results <- r })
+		if err := c.Register(); err != nil {
+			t.Fatal(err)
+		}
+		return c, results, discarded
+	}
+	ana, anaResults, anaDiscarded := dial("ana", "chave")
+	bia, _, _ := dial("bia", "chave")
+	_, _, forged := dial("caio", "outra")
+
+	if _, err := ana.Vote("A"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bia.Vote("B"); err != nil {
+		t.Fatal(err)
+	}
+	s.EndVotingNow()
+
+	deadline := time.After(waitTimeout)
+	for final := false; !final; {
+		select {
+		case r := <-anaResults:
+			if r.Final == nil {
+				continue
+			}
+			final = true
+			if !slices.Equal(r.Final.Tied, []string{"A", "B"}) || !r.Final.NonBinding || r.Final.ChainHash == "" {
+				t.Fatalf("resultado final = %+v", r.Final)
+			}
+		case err := <-anaDiscarded:
+			t.Fatalf("placar assinado pelo servidor recusado: %v", err)
+		case <-deadline:
+			t.Fatal("resultado final não chegou")
+		}
+	}
+
+	// Com outra chave, os mesmos placares são descartados
+	select {
+	case err := <-forged:
+		if !errors.Is(err, client.ErrUntrusted) {
+			t.Fatalf("descarte com erro %v, esperava ErrUntrusted", err)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("placar aceito com a chave errada")
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/client"
+)
+
+// Porta ocupada e endereço inválido voltam como erro do Start, sem
+// derrubar o processo
+func TestStartErrors(t *testing.T) {
+	busy, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	for _, addr := range []string{busy.LocalAddr().String(), "127.0.0.1:porta", "256.0.0.1:9000"} {
+		s, err := NewUDPServer([]string{"A", "B"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		errc := make(chan error, 1)
+		go func() { errc <- s.Start(addr) }()
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Fatalf("Start(%q) sem erro", addr)
+			}
+		case <-s.Ready():
+			s.Stop()
+			t.Fatalf("Start(%q) abriu o socket", addr)
+		case <-time.After(waitTimeout):
+			t.Fatalf("Start(%q) não retornou", addr)
+		}
+	}
+}
+
+// O servidor escuta num endereço IPv6 específico e o cliente fala com ele
+func TestStartIPv6Loopback(t *testing.T) {
+	probe, err := net.ListenUDP("udp6", &net.UDPAddr{IP: net.IPv6loopback})
+	if err != nil {
+		t.Skip("sem IPv6 de loopback:", err)
+	}
+	probe.Close()
+
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start("[::1]:0") }()
+	select {
+	case <-s.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+
+	addr := s.Addr().(*net.UDPAddr)
+	if !addr.IP.Equal(net.IPv6loopback) {
+		t.Fatalf("servidor em %s, esperava [::1]", addr)
+	}
+
+	c, err := client.Dial(addr.String(), "ana", client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Register(); err != nil {
+		t.Fatalf("registro por IPv6: %v", err)
+	}
+	s.StartVoting(60)
+	if _, err := c.Vote("A"); err != nil {
+		t.Fatalf("voto por IPv6: %v", err)
+	}
+	if got := s.Results()["A"]; got != 1 {
+		t.Fatalf("votos em A = %d, esperava 1", got)
+	}
+}
+
+// Depois de um voto, o Stop destrava a leitura e o Start retorna sem erro,
+// em cada modo de leitura; a porta fica livre e o placar, intacto
+func TestStopUnblocksStart(t *testing.T) {
+	cases := []struct {
+		name         string
+		batch, reuse int
+	}{
+		{"leitura simples", 0, 0},
+		{"leitura em lote", 8, 0},
+		{"SO_REUSEPORT", 0, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewUDPServer([]string{"A", "B"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.SetBatchRead(tc.batch)
+			s.SetReusePort(tc.reuse)
+			errc := make(chan error, 1)
+			go func() { errc <- s.Start("127.0.0.1:0") }()
+			select {
+			case <-s.Ready():
+			case err := <-errc:
+				t.Fatal(err)
+			}
+			addr := s.Addr().String()
+
+			c, err := client.Dial(addr, "ana", client.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if err := c.Register(); err != nil {
+				t.Fatal(err)
+			}
+			s.StartVoting(60)
+			if _, err := c.Vote("A"); err != nil {
+				t.Fatal(err)
+			}
+
+			stopped := make(chan struct{})
+			go func() { s.Stop(); close(stopped) }()
+			select {
+			case err := <-errc:
+				if err != nil {
+					t.Fatalf("Start depois do Stop: %v", err)
+				}
+			case <-time.After(waitTimeout):
+				t.Fatal("Start não retornou depois do Stop")
+			}
+			select {
+			case <-stopped:
+			case <-time.After(waitTimeout):
+				t.Fatal("Stop não retornou")
+			}
+			s.Stop() // segunda chamada não trava nem entra em pânico
+
+			ln, err := net.ListenPacket("udp", addr)
+			if err != nil {
+				t.Fatalf("porta continua presa depois do Stop: %v", err)
+			}
+			ln.Close()
+			if got := s.Results()["A"]; got != 1 {
+				t.Fatalf("votos em A = %d depois do Stop, esperava 1", got)
+			}
+		})
+	}
+}
+
+// Votação aberta (e broadcasts enviados) enquanto o Start ainda abre o
+// socket, ou sem Start nenhum: nenhuma escrita em conexão nil. Rode com
+// -race.
+func TestStartVotingRacesStart(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		s, err := NewUDPServer([]string{"A", "B"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.SetHeartbeat(time.Millisecond)
+		errc := make(chan error, 1)
+		go func() { errc <- s.Start("127.0.0.1:0") }()
+		s.StartVoting(60)
+		s.SnapshotNow()
+		select {
+		case <-s.Ready():
+		case err := <-errc:
+			t.Fatal(err)
+		}
+		if err := s.EndVotingNow(); err != nil {
+			t.Fatal(err)
+		}
+		s.Stop()
+		if err := <-errc; err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	}
+
+	// Sem socket: abrir, encerrar e parar não escrevem em lugar nenhum
+	s, err := NewUDPServer([]string{"A", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.StartVoting(60)
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+	if got := s.Stats().BroadcastSeq; got < 2 {
+		t.Fatalf("BroadcastSeq = %d, esperava a abertura e o encerramento", got)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// ----------------------------------------------------------
+// Estado da votação em arquivo (sobrevive a quedas do processo)
+// ----------------------------------------------------------
+//
+// Com SetStateFile, o servidor regrava o arquivo a cada mudança no placar
+// (voto aceito, delegação, abertura) e no encerramento, com o mesmo retrato
+// usado pelo Handoff. Ao subir de novo com o mesmo arquivo, a votação
+// continua de onde parou: votos, clientes registrados, prazo e cadeia de
+// auditoria. Se o prazo venceu enquanto o servidor estava fora, a votação já
+// volta encerrada. A gravação passa por um arquivo temporário renomeado
+// por cima do anterior, para uma queda no meio nunca deixar o estado pela
+// metade.
+
+// SetStateFile passa a gravar o estado em path; se o arquivo já existir,
+// carrega antes a votação gravada nele. Deve ser chamado antes do Start.
+func (s *UDPServer) SetStateFile(path string) error {
+	if path != "" {
+		if err := s.LoadState(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateFile = path
+	return nil
+}
+
+// SaveState grava o estado atual da votação em path
+func (s *UDPServer) SaveState(path string) error {
+	s.mu.Lock()
+	st := s.handoffStateLocked()
+	s.mu.Unlock()
+	return writeStateFile(path, st)
+}
+
+// LoadState substitui o estado da votação pelo gravado em path
+func (s *UDPServer) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var st HandoffState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("arquivo de estado %s inválido: %w", path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.restoreLocked(st); err != nil {
+		return err
+	}
+	log.Printf("[STATE] Estado carregado de %s: %d clientes, %d votos, votação %s",
+		path, len(s.clients), len(s.votes), s.votingState)
+	s.resumeVotingLocked()
+	return nil
+}
+
+// saveStateLocked regrava o arquivo de estado, se configurado
+func (s *UDPServer) saveStateLocked() {
+	if s.stateFile == "" {
+		return
+	}
+	if err := writeStateFile(s.stateFile, s.handoffStateLocked()); err != nil {
+		log.Println("[STATE] Erro ao gravar estado:", err)
+	}
+}
+
+// writeStateFile grava st em um temporário no mesmo diretório e o renomeia
+// para path
+func writeStateFile(path string, st HandoffState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// writeFileAtomic grava data em um temporário no mesmo diretório e o
+// renomeia para path: quem lê o arquivo vê o conteúdo antigo ou o novo,
+// nunca um pela metade
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// SaveState e LoadState em outro servidor: placar, clientes, votos já dados
+// e prazo continuam valendo
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "estado.json")
+	s1, f1 := votingServer(t, false, "ana", "bia", "caio")
+	vote(s1, f1, "ana", testAddr(1), "A")
+	vote(s1, f1, "bia", testAddr(2), "B")
+	if err := s1.SaveState(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s2, f2 := newFakeServer(t)
+	if err := s2.LoadState(path); err != nil {
+		t.Fatal(err)
+	}
+	if s2.State() != VotingActive || clientCount(s2) != 3 {
+		t.Fatalf("estado %v com %d clientes, esperava ativa com 3", s2.State(), clientCount(s2))
+	}
+	wantTally(t, s2, map[string]int64{"A": 1, "B": 1})
+	if got := vote(s2, f2, "ana", testAddr(1), "B"); got.Type != "ERROR" || got.Message != "Voto duplicado" {
+		t.Fatalf("ana votou de novo depois da carga: %+v", got)
+	}
+	if got := vote(s2, f2, "caio", testAddr(3), "A"); got.Type != "ACK" {
+		t.Fatalf("voto de caio depois da carga = %+v", got)
+	}
+	wantTally(t, s2, map[string]int64{"A": 2, "B": 1})
+}
+
+// Prazo vencido enquanto o servidor estava fora: a votação volta encerrada,
+// com o placar gravado
+func TestStateLoadPastDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "estado.json")
+	clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := func() time.Time { return clock }
+
+	s1, f1 := newFakeServer(t)
+	s1.SetClock(now)
+	register(t, s1, f1, "ana", testAddr(1))
+	s1.StartVoting(60)
+	vote(s1, f1, "ana", testAddr(1), "A")
+	if err := s1.SaveState(path); err != nil {
+		t.Fatal(err)
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	s2, f2 := newFakeServer(t)
+	s2.SetClock(now)
+	if err := s2.LoadState(path); err != nil {
+		t.Fatal(err)
+	}
+	if s2.State() != VotingEnded {
+		t.Fatalf("estado depois do prazo = %v, esperava encerrada", s2.State())
+	}
+	wantTally(t, s2, map[string]int64{"A": 1, "B": 0})
+	if got := vote(s2, f2, "ana", testAddr(1), "B"); got.Type != "ERROR" || got.Message != "Votação encerrada" {
+		t.Fatalf("voto depois do prazo = %+v", got)
+	}
+}
+
+// Com SetStateFile o arquivo acompanha cada voto, e o servidor seguinte
+// carrega o que estiver nele; sem arquivo, começa do zero
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "estado.json")
+	s1, f1 := newFakeServer(t)
+	if err := s1.SetStateFile(path); err != nil {
+		t.Fatalf("arquivo ainda inexistente: %v", err)
+	}
+	register(t, s1, f1, "ana", testAddr(1))
+	s1.StartVoting(3600)
+	vote(s1, f1, "ana", testAddr(1), "B")
+
+	s2, _ := newFakeServer(t)
+	if err := s2.SetStateFile(path); err != nil {
+		t.Fatal(err)
+	}
+	wantTally(t, s2, map[string]int64{"A": 0, "B": 1})
+}
+
+func TestStateLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "estado.json")
+	if err := os.WriteFile(path, []byte("{meio arquivo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := newFakeServer(t)
+	if err := s.LoadState(path); err == nil {
+		t.Fatal("arquivo corrompido aceito")
+	}
+	if err := s.SetStateFile(path); err == nil {
+		t.Fatal("SetStateFile aceitou arquivo corrompido")
+	}
+	if s.State() != VotingNotStarted {
+		t.Fatalf("estado = %v depois da carga recusada", s.State())
+	}
+}
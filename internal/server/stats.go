@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Contadores internos
+// ----------------------------------------------------------
+//
+// Todos os contadores do servidor são alterados com s.mu travado. Stats os
+// lê em uma única seção crítica, então os valores são coerentes entre si
+// (ex.: VotesAccepted nunca passa de VotesReceived).
+
+// Stats é um retrato de todos os contadores do servidor
+type Stats struct {
+	State         VotingState `json:"state"`
+	Clients       int         `json:"clients"`        // clientes registrados
+	VotesReceived int         `json:"votes_received"` // VOTE recebidos, aceitos ou não
+	VotesAccepted int         `json:"votes_accepted"` // votos e trocas aceitos, sem contar pesos
+	BroadcastSeq  int         `json:"broadcast_seq"`  // último SeqNum emitido
+
+	BroadcastsDropped int  `json:"broadcasts_dropped"` // descartados por fila cheia
+	Degraded          bool `json:"degraded"`
+	Subscribers       int  `json:"subscribers"` // assinantes do stream TCP
+
+	RejectedTypes          int `json:"rejected_types"`
+	ThrottledRegistrations int `json:"throttled_registrations"`
+	ThrottledPackets       int `json:"throttled_packets"` // acima do limite por origem (SetSourceRate)
+	AuthFailures           int `json:"auth_failures"`     // token de cliente ausente ou errado (SetClientSecret)
+	DroppedPackets         int `json:"dropped_packets"`   // descartados com a fila dos workers cheia
+	EvictedClients         int `json:"evicted_clients"`   // removidos por inatividade (SetIdleTimeout)
+	FragmentRisk           int `json:"fragment_risk"`
+	DelegatedVotes         int `json:"delegated_votes"`
+	VotesChanged           int `json:"votes_changed"` // trocas de voto (SetAllowRevote)
+	MirrorSent             int `json:"mirror_sent"`
+	MirrorReceived         int `json:"mirror_received"`
+	MultiHomeConflicts     int `json:"multi_home_conflicts"` // IDs vistos em dois endereços ao mesmo tempo
+	Snapshots              int `json:"snapshots"`            // snapshots tirados (SnapshotNow)
+
+	BufferBytes     int `json:"buffer_bytes"`     // histórico de broadcasts + snapshots retidos
+	BufferEvictions int `json:"buffer_evictions"` // entradas descartadas pelo orçamento de memória
+
+	SocketPackets []int64 `json:"socket_packets,omitempty"` // datagramas lidos por socket (SetReusePort)
+
+	Uptime     float64 `json:"uptime_s"`   // segundos desde a criação do servidor
+	Goroutines int     `json:"goroutines"` // goroutines do processo no momento da leitura
+}
+
+// Stats devolve todos os contadores lidos de uma só vez
+func (s *UDPServer) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reads := make([]int64, len(s.socketReads))
+	for i := range s.socketReads {
+		reads[i] = s.socketReads[i].Load()
+	}
+
+	return Stats{
+		State:                  s.votingState,
+		Clients:                len(s.clients),
+		VotesReceived:          s.votesReceived,
+		VotesAccepted:          s.votesAccepted,
+		BroadcastSeq:           s.broadcastSeq,
+		BroadcastsDropped:      s.broadcastsDropped,
+		Degraded:               s.degraded,
+		Subscribers:            len(s.subscribers),
+		RejectedTypes:          s.rejectedTypes,
+		ThrottledRegistrations: s.regThrottled,
+		ThrottledPackets:       s.sourceThrottled,
+		AuthFailures:           s.authFailures,
+		DroppedPackets:         int(s.droppedPackets.Load()),
+		EvictedClients:         s.evicted,
+		FragmentRisk:           s.fragmentRisk,
+		DelegatedVotes:         s.delegatedCount,
+		VotesChanged:           s.votesChanged,
+		MirrorSent:             s.mirrorSent,
+		MirrorReceived:         s.mirrorReceived,
+		MultiHomeConflicts:     s.multiHomeCount,
+		Snapshots:              s.snapshotSeq,
+		BufferBytes:            s.historyBytes + s.snapshotBytes,
+		BufferEvictions:        s.bufferEvictions,
+		SocketPackets:          reads,
+		Uptime:                 time.Since(s.startedAt).Seconds(),
+		Goroutines:             runtime.NumGoroutine(),
+	}
+}
+
+// Contador ou medidor do Stats na exposição de StartMetrics
+type statMetric struct {
+	name, kind string
+	value      float64
+}
+
+// boolGauge exporta um estado ligado/desligado como 1 ou 0
+func boolGauge(on bool) float64 {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// WriteText grava os contadores no formato de texto do Prometheus
+func (st Stats) WriteText(w io.Writer) error {
+	metrics := []statMetric{
+		{"udpvote_server_clients", "gauge", float64(st.Clients)},
+		{"udpvote_server_votes_received_total", "counter", float64(st.VotesReceived)},
+		{"udpvote_server_votes_accepted_total", "counter", float64(st.VotesAccepted)},
+		{"udpvote_server_broadcast_seq", "gauge", float64(st.BroadcastSeq)},
+		{"udpvote_server_broadcasts_dropped_total", "counter", float64(st.BroadcastsDropped)},
+		{"udpvote_server_packets_dropped_total", "counter", float64(st.DroppedPackets)},
+		{"udpvote_server_packets_throttled_total", "counter", float64(st.ThrottledPackets)},
+		{"udpvote_server_auth_failures_total", "counter", float64(st.AuthFailures)},
+		{"udpvote_server_subscribers", "gauge", float64(st.Subscribers)},
+		{"udpvote_server_degraded", "gauge", boolGauge(st.Degraded)},
+		{"udpvote_uptime_seconds", "gauge", st.Uptime},
+		{"udpvote_goroutines", "gauge", float64(st.Goroutines)},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n%s %g\n", m.name, m.kind, m.name, m.value); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "# TYPE udpvote_server_voting_state gauge\nudpvote_server_voting_state{state=%q} 1\n", st.State)
+	return err
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPongReportsLoad(t *testing.T) {
+	s, f := newFakeServer(t)
+	for i := 1; i <= 3; i++ {
+		register(t, s, f, "v"+strconv.Itoa(i), testAddr(i))
+	}
+	probe := testAddr(9)
+
+	// Desligado, o PONG só ecoa o SeqNum
+	deliver(s, probe, Message{Type: "PING", SeqNum: 7})
+	if got := f.last(probe); got.Type != "PONG" || got.SeqNum != 7 || got.Uptime != 0 || got.Goroutines != 0 {
+		t.Fatalf("PONG sem SetReportLoad = %+v", got)
+	}
+
+	s.SetReportLoad(true)
+	time.Sleep(10 * time.Millisecond)
+	deliver(s, probe, Message{Type: "PING", SeqNum: 8})
+	got := f.last(probe)
+	if got.Type != "PONG" || got.SeqNum != 8 {
+		t.Fatalf("PONG = %+v", got)
+	}
+	if got.Uptime < 0.01 {
+		t.Fatalf("uptime = %gs, esperava pelo menos 10ms", got.Uptime)
+	}
+	if got.Goroutines < 1 || got.ClientCount != 3 {
+		t.Fatalf("goroutines = %d, clientes = %d, esperava >= 1 e 3", got.Goroutines, got.ClientCount)
+	}
+}
+
+func TestMetricsExportLoadGauges(t *testing.T) {
+	s, f := newFakeServer(t)
+	register(t, s, f, "ana", testAddr(1))
+	time.Sleep(10 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	s.serveMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	gauge := func(name string) float64 {
+		t.Helper()
+		if !strings.Contains(body, "# TYPE "+name+" gauge\n") {
+			t.Fatalf("%s ausente ou sem TYPE gauge:\n%s", name, body)
+		}
+		for _, line := range strings.Split(body, "\n") {
+			if v, ok := strings.CutPrefix(line, name+" "); ok {
+				n, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					t.Fatalf("%s = %q", name, v)
+				}
+				return n
+			}
+		}
+		t.Fatalf("%s sem valor:\n%s", name, body)
+		return 0
+	}
+	if up := gauge("udpvote_uptime_seconds"); up < 0.01 {
+		t.Fatalf("udpvote_uptime_seconds = %g, esperava pelo menos 10ms", up)
+	}
+	if n := gauge("udpvote_goroutines"); n < 1 {
+		t.Fatalf("udpvote_goroutines = %g", n)
+	}
+	if n := gauge("udpvote_server_clients"); n != 1 {
+		t.Fatalf("udpvote_server_clients = %g, esperava 1", n)
+	}
+}
+
+// udpvote_server_votes_accepted_total só cresce: conta votos e trocas (não
+// pesos) e não volta a zero com uma nova votação
+func TestVotesAcceptedMonotonic(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetWeightedVoting(true)
+	s.SetAllowRevote(true)
+	a, b := testAddr(1), testAddr(2)
+	deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", Weight: weight(5)})
+	register(t, s, f, "bia", b)
+	s.StartVoting(60)
+
+	vote(s, f, "ana", a, "A")
+	vote(s, f, "bia", b, "B")
+	vote(s, f, "bia", b, "A") // troca
+	if got := s.Stats().VotesAccepted; got != 3 {
+		t.Fatalf("aceitos = %d, esperava 3 (o peso 5 conta uma vez)", got)
+	}
+
+	s.EndVotingNow()
+	if err := s.ResetVoting([]string{"X", "Y"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Stats().VotesAccepted; got != 3 {
+		t.Fatalf("aceitos depois do reset = %d, esperava 3", got)
+	}
+	s.StartVoting(60)
+	vote(s, f, "ana", a, "X")
+
+	var buf strings.Builder
+	if err := s.Stats().WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\nudpvote_server_votes_accepted_total 4\n") {
+		t.Fatalf("exposição sem votes_accepted_total 4:\n%s", buf.String())
+	}
+}
+
+// Rode com -race: Stats lido sem parar enquanto os workers votam. Cada
+// retrato é coerente (aceitos ≤ recebidos) e os contadores só crescem.
+func TestStatsConcurrentWithVoting(t *testing.T) {
+	s, f := newFakeServer(t)
+	serveFake(s, f)
+
+	const voters = 300
+	for i := 1; i <= voters; i++ {
+		f.inject(testAddr(i), Message{Type: "REGISTER", ClientID: "v" + strconv.Itoa(i)})
+	}
+	for i := 1; i <= voters; i++ {
+		f.waitFor(t, testAddr(i), func(m Message) bool { return m.Type == "ACK" })
+	}
+	s.StartVoting(3600)
+
+	done := make(chan struct{})
+	errc := make(chan string, 4)
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			var prev Stats
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				st := s.Stats()
+				switch {
+				case st.VotesAccepted > st.VotesReceived:
+					errc <- "aceitos " + strconv.Itoa(st.VotesAccepted) + " > recebidos " + strconv.Itoa(st.VotesReceived)
+					return
+				case st.VotesReceived < prev.VotesReceived || st.VotesAccepted < prev.VotesAccepted || st.BroadcastSeq < prev.BroadcastSeq:
+					errc <- "contador voltou atrás"
+					return
+				case st.Clients != voters:
+					errc <- "clientes = " + strconv.Itoa(st.Clients)
+					return
+				}
+				prev = st
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= voters; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := "v" + strconv.Itoa(i)
+			for n := 0; n < 2; n++ { // a segunda cópia é duplicata
+				f.inject(testAddr(i), Message{Type: "VOTE", ClientID: id, VoteOption: "A"})
+			}
+		}()
+	}
+	wg.Wait()
+	deadline := time.Now().Add(waitTimeout)
+	for s.Stats().VotesReceived < 2*voters && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	readers.Wait()
+
+	select {
+	case msg := <-errc:
+		t.Fatal(msg)
+	default:
+	}
+	if st := s.Stats(); st.VotesReceived != 2*voters || st.VotesAccepted != voters {
+		t.Fatalf("recebidos %d, aceitos %d; esperava %d e %d", st.VotesReceived, st.VotesAccepted, 2*voters, voters)
+	}
+}
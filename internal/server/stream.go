@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Stream confiável de resultados (TCP, JSON por linha)
+// ----------------------------------------------------------
+
+// Tamanho da fila de cada assinante. Um assinante que não acompanha
+// o ritmo é desconectado (pode reconectar e receber um novo snapshot),
+// pois descartar updates quebraria a garantia de ordem sem perda.
+const subscriberBuffer = 256
+
+// StartStream abre um listener TCP para placares oficiais: ao conectar, o
+// assinante recebe um SNAPSHOT com o placar atual e depois todos os
+// BROADCAST, em ordem e sem perda. Convive com os broadcasts UDP. O Stop
+// fecha o listener e encerra as conexões depois do último update.
+func (s *UDPServer) StartStream(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		ln.Close()
+		return errors.New("servidor encerrado")
+	}
+	if s.streamLn != nil {
+		s.mu.Unlock()
+		ln.Close()
+		return errors.New("stream TCP já aberto")
+	}
+	s.streamLn = ln
+	s.mu.Unlock()
+	log.Printf("Stream TCP de resultados em %s", ln.Addr())
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveStream(conn)
+		}
+	}()
+	return nil
+}
+
+func (s *UDPServer) serveStream(conn net.Conn) {
+	defer conn.Close()
+
+	snap, updates := s.subscribe()
+	defer s.unsubscribe(updates)
+
+	enc := json.NewEncoder(conn) // Encode termina cada mensagem com \n
+	if enc.Encode(snap) != nil {
+		return
+	}
+	for msg := range updates {
+		// Com parciais ocultos (SetHideLiveResults), só o resultado final
+		// sai pelo stream, como no SSE
+		if msg.Final == nil && s.liveHidden() {
+			continue
+		}
+		if enc.Encode(msg) != nil {
+			return
+		}
+	}
+}
+
+// subscribe registra um assinante e devolve o snapshot atual.
+// Os updates recebidos pelo canal têm SeqNum maior que o do snapshot.
+// Com parciais ocultos e a votação em andamento, o snapshot vai sem placar.
+func (s *UDPServer) subscribe() (Message, chan Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := s.addSubscriberLocked()
+	msg := Message{Type: "SNAPSHOT", SeqNum: s.broadcastSeq, Options: s.options}
+	if !s.hideLive || s.votingState == VotingEnded {
+		msg.VoteCounts, msg.Results = s.displayLocked(s.voteCounts)
+	}
+	return msg, ch
+}
+
+// addSubscriberLocked cria o canal de um novo assinante. Depois do Stop o
+// canal já vem fechado: o assinante recebe o snapshot e sai.
+func (s *UDPServer) addSubscriberLocked() chan Message {
+	ch := make(chan Message, subscriberBuffer)
+	if s.stopped {
+		close(ch)
+		return ch
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// closeStreamLocked fecha o listener do stream e os canais dos assinantes,
+// que ainda entregam o que já estava na fila antes de a conexão fechar
+func (s *UDPServer) closeStreamLocked() {
+	if s.streamLn != nil {
+		s.streamLn.Close()
+		s.streamLn = nil
+	}
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// unsubscribe remove o assinante (se ainda não foi removido por lentidão)
+func (s *UDPServer) unsubscribe(ch chan Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publishLocked entrega o update a todos os assinantes; deve ser chamado
+// com o mutex travado, o que garante a ordem dos SeqNum
+func (s *UDPServer) publishLocked(msg Message) {
+	for ch := range s.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			log.Println("[STREAM] Assinante lento desconectado")
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// ----------------------------------------------------------
+// Log de broadcasts (JSON por linha, para acompanhar com tail -f)
+// ----------------------------------------------------------
+
+// BroadcastRecord é uma linha do log de broadcasts: o payload exato
+// enviado aos clientes e o momento do envio
+type BroadcastRecord struct {
+	Time    time.Time       `json:"time"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// SetBroadcastLog define onde cada broadcast enviado é registrado, uma vez
+// e na ordem de envio. Diferente do log de auditoria (votos), este guarda
+// o que os clientes receberam.
+func (s *UDPServer) SetBroadcastLog(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcastLog = w
+}
+
+// logBroadcastLocked grava o payload de um broadcast que acabou de ser enviado
+func (s *UDPServer) logBroadcastLocked(data []byte) {
+	if s.broadcastLog == nil {
+		return
+	}
+	line, _ := json.Marshal(BroadcastRecord{Time: s.now(), Payload: data})
+	if _, err := s.broadcastLog.Write(append(line, '\n')); err != nil {
+		log.Println("[STREAM] Erro ao gravar log de broadcasts:", err)
+	}
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startStream abre o stream TCP numa porta livre e devolve o endereço
+func startStream(t *testing.T, s *UDPServer) string {
+	t.Helper()
+	if err := s.StartStream("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streamLn.Addr().String()
+}
+
+// waitSubscribers espera o accept do stream registrar n assinantes
+func waitSubscribers(t *testing.T, s *UDPServer, n int) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		s.mu.Lock()
+		got := len(s.subscribers)
+		s.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d assinantes, esperava %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// readStream lê as mensagens do stream até a conexão fechar
+func readStream(t *testing.T, conn net.Conn) []Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(waitTimeout))
+	var msgs []Message
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var m Message
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("linha inválida no stream: %q", sc.Text())
+		}
+		msgs = append(msgs, m)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("stream não fechou depois do Stop: %v", err)
+	}
+	return msgs
+}
+
+// O assinante recebe o snapshot e depois todos os updates, em ordem e sem
+// buracos, até o resultado final; o Stop fecha a conexão
+func TestStreamOrderedWithoutLoss(t *testing.T) {
+	s, f := newFakeServer(t)
+	addr := startStream(t, s)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	waitSubscribers(t, s, 1)
+
+	const voters = 40
+	for i := 0; i < voters; i++ {
+		register(t, s, f, fmt.Sprintf("v%02d", i), testAddr(i+1))
+	}
+	s.StartVoting(3600)
+	for i := 0; i < voters; i++ {
+		option := "A"
+		if i%4 == 0 {
+			option = "B"
+		}
+		if got := vote(s, f, fmt.Sprintf("v%02d", i), testAddr(i+1), option); got.Type != "ACK" {
+			t.Fatalf("voto %d = %+v", i, got)
+		}
+	}
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	stopFake(s, f)
+
+	msgs := readStream(t, conn)
+	if len(msgs) == 0 || msgs[0].Type != "SNAPSHOT" {
+		t.Fatalf("stream não começou com SNAPSHOT: %+v", msgs)
+	}
+	seq := msgs[0].SeqNum
+	for _, m := range msgs[1:] {
+		if m.Type != "BROADCAST" || m.SeqNum != seq+1 {
+			t.Fatalf("depois do #%d veio %s #%d", seq, m.Type, m.SeqNum)
+		}
+		seq = m.SeqNum
+	}
+	// abertura, um parcial por voto e o final
+	if n := len(msgs) - 1; n != voters+2 {
+		t.Fatalf("%d updates, esperava %d", n, voters+2)
+	}
+	last := msgs[len(msgs)-1]
+	if last.Final == nil {
+		t.Fatalf("último update não é o final: %+v", last)
+	}
+	if last.VoteCounts["A"] != 30 || last.VoteCounts["B"] != 10 {
+		t.Fatalf("placar final no stream = %v, esperava A=30 B=10", last.VoteCounts)
+	}
+}
+
+// O Stop libera a porta do stream (a reinicialização sem queda reabre o
+// mesmo endereço) e recusa StartStream depois de encerrado
+func TestStreamClosedOnStop(t *testing.T) {
+	s, _ := newFakeServer(t)
+	addr := startStream(t, s)
+	s.Stop()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("porta do stream continua presa depois do Stop: %v", err)
+	}
+	ln.Close()
+	if err := s.StartStream("127.0.0.1:0"); err == nil {
+		t.Fatal("StartStream aceito depois do Stop")
+	}
+}
+
+// Com parciais ocultos, o stream não vaza o placar: snapshot sem contagem
+// durante a votação e, dos updates, só o resultado final
+func TestStreamHidesLiveResults(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetHideLiveResults(true, "segredo")
+	addr := startStream(t, s)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	waitSubscribers(t, s, 1)
+
+	register(t, s, f, "ana", testAddr(1))
+	register(t, s, f, "bia", testAddr(2))
+	s.StartVoting(3600)
+	vote(s, f, "ana", testAddr(1), "A")
+	vote(s, f, "bia", testAddr(2), "B")
+	if err := s.EndVotingNow(); err != nil {
+		t.Fatal(err)
+	}
+	stopFake(s, f)
+
+	msgs := readStream(t, conn)
+	if len(msgs) != 2 {
+		t.Fatalf("stream entregou %d mensagens, esperava o snapshot e o final: %+v", len(msgs), msgs)
+	}
+	if snap := msgs[0]; snap.Type != "SNAPSHOT" || len(snap.VoteCounts) != 0 || len(snap.Results) != 0 {
+		t.Fatalf("snapshot com os parciais ocultos = %+v", snap)
+	}
+	final := msgs[1]
+	if final.Final == nil || final.VoteCounts["A"] != 1 || final.VoteCounts["B"] != 1 {
+		t.Fatalf("resultado final no stream = %+v", final)
+	}
+
+	// Depois do encerramento, quem assina já recebe o placar
+	s2, _ := newFakeServer(t)
+	s2.SetHideLiveResults(true, "segredo")
+	s2.StartVoting(60)
+	s2.EndVotingNow()
+	if snap, _ := s2.subscribe(); snap.VoteCounts == nil {
+		t.Fatalf("snapshot depois do encerramento sem placar: %+v", snap)
+	}
+}
+
+// Cada broadcast enviado aparece uma vez no log, na ordem, com o mesmo
+// payload que o cliente recebeu
+func TestBroadcastLogOnceInOrder(t *testing.T) {
+	var out syncBuffer
+	s, f := newFakeServer(t)
+	s.SetBroadcastLog(&out)
+	a := testAddr(1)
+	register(t, s, f, "ana", a)
+	register(t, s, f, "bia", testAddr(2))
+	s.StartVoting(3600)
+	vote(s, f, "ana", a, "A")
+	vote(s, f, "bia", testAddr(2), "B")
+	s.EndVotingNow()
+	f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+	stopFake(s, f)
+
+	sent := f.ofType(a, "BROADCAST")
+	sc := bufio.NewScanner(strings.NewReader(out.String()))
+	var records []BroadcastRecord
+	for sc.Scan() {
+		var rec BroadcastRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("linha inválida %q: %v", sc.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != len(sent) || len(sent) < 3 {
+		t.Fatalf("%d linhas no log para %d broadcasts enviados", len(records), len(sent))
+	}
+	prev := 0
+	for i, rec := range records {
+		var m Message
+		if err := json.Unmarshal(rec.Payload, &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.SeqNum != sent[i].SeqNum || fmt.Sprint(m.VoteCounts) != fmt.Sprint(sent[i].VoteCounts) {
+			t.Fatalf("linha %d = #%d %v, cliente recebeu #%d %v", i, m.SeqNum, m.VoteCounts, sent[i].SeqNum, sent[i].VoteCounts)
+		}
+		if m.SeqNum <= prev || rec.Time.IsZero() || (i > 0 && rec.Time.Before(records[i-1].Time)) {
+			t.Fatalf("linha %d fora de ordem ou sem horário: %+v", i, rec)
+		}
+		prev = m.SeqNum
+	}
+	if last := sent[len(sent)-1]; last.Final == nil {
+		t.Fatal("último broadcast do log não é o resultado final")
+	}
+}
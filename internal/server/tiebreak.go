@@ -0,0 +1,117 @@
+package server
+
+import "errors"
+
+// ----------------------------------------------------------
+// Vencedor e desempate
+// ----------------------------------------------------------
+
+// Estratégias de desempate (SetTieBreak)
+const (
+	TieBreakNone         = ""             // empate fica sem vencedor
+	TieBreakAlphabetical = "alphabetical" // vence o menor nome
+	TieBreakEarliest     = "earliest"     // vence quem chegou primeiro à contagem final
+)
+
+// SetTieBreak escolhe como o vencedor é decidido em caso de empate
+func (s *UDPServer) SetTieBreak(strategy string) error {
+	switch strategy {
+	case TieBreakNone, TieBreakAlphabetical, TieBreakEarliest:
+	default:
+		return errors.New("estratégia de desempate desconhecida: " + strategy)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tieBreak = strategy
+	return nil
+}
+
+// touchOptionLocked anota o momento do último voto recebido pela opção
+func (s *UDPServer) touchOptionLocked(option string) {
+	s.lastChange[option] = s.now()
+}
+
+// leadersLocked devolve as opções com mais votos fora `exclude`, na ordem
+// declarada, e a contagem delas (nenhuma sem votos)
+func (s *UDPServer) leadersLocked(exclude string) ([]string, int64) {
+	best := int64(0)
+	var leaders []string
+	for _, op := range OrderedResults(s.options, s.voteCounts) {
+		if op.Option == exclude {
+			continue
+		}
+		switch {
+		case op.Votes > best:
+			best, leaders = op.Votes, []string{op.Option}
+		case op.Votes == best && best > 0:
+			leaders = append(leaders, op.Option)
+		}
+	}
+	return leaders, best
+}
+
+// winnerLocked devolve a opção mais votada, aplicando o desempate
+// configurado ("" sem votos ou em empate sem estratégia)
+func (s *UDPServer) winnerLocked() string {
+	winner, _ := s.leaderLocked("")
+	return winner
+}
+
+// tiedLocked devolve as opções empatadas na liderança quando o empate fica
+// sem vencedor (nil sem votos, com líder único ou com desempate aplicado)
+func (s *UDPServer) tiedLocked() []string {
+	leaders, _ := s.leadersLocked("")
+	if len(leaders) < 2 || s.winnerLocked() != "" {
+		return nil
+	}
+	return leaders
+}
+
+// marginLocked devolve o segundo colocado e a vantagem do vencedor sobre
+// ele, em votos e em pontos percentuais do total. Sem segundo colocado com
+// votos (opção única ou votos em uma só opção), a vantagem é o total do
+// vencedor; sem vencedor definido, é zero.
+func (s *UDPServer) marginLocked() (runnerUp string, margin int64, pct float64) {
+	winner, best := s.leaderLocked("")
+	if winner == "" {
+		return "", 0, 0
+	}
+	runnerUp, second := s.leaderLocked(winner)
+
+	margin = best - second
+	return runnerUp, margin, float64(margin) / float64(totalVotes(s.voteCounts)) * 100
+}
+
+// leaderLocked devolve a opção mais votada fora `exclude` e a sua contagem,
+// aplicando o desempate. Em empate sem estratégia a opção fica vazia, mas a
+// contagem ainda é a dos empatados.
+func (s *UDPServer) leaderLocked(exclude string) (string, int64) {
+	leaders, best := s.leadersLocked(exclude)
+	switch {
+	case len(leaders) == 0:
+		return "", 0
+	case len(leaders) == 1:
+		return leaders[0], best
+	}
+
+	winner := leaders[0]
+	switch s.tieBreak {
+	case TieBreakAlphabetical:
+		for _, op := range leaders[1:] {
+			if op < winner {
+				winner = op
+			}
+		}
+	case TieBreakEarliest:
+		// Quem recebeu o último voto antes atingiu a contagem final primeiro
+		for _, op := range leaders[1:] {
+			if s.lastChange[op].Before(s.lastChange[winner]) {
+				winner = op
+			}
+		}
+	default:
+		return "", best
+	}
+	return winner, best
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// Empate em 2 x 2 onde B chegou aos dois votos antes de A: "earliest"
+// escolhe B, "alphabetical" escolhe A, e sem estratégia não há vencedor
+func TestTieBreakStrategies(t *testing.T) {
+	cases := []struct {
+		strategy string
+		winner   string
+		tied     []string
+	}{
+		{TieBreakEarliest, "B", nil},
+		{TieBreakAlphabetical, "A", nil},
+		{TieBreakNone, "", []string{"A", "B"}},
+	}
+	for _, tc := range cases {
+		s, f := votingServer(t, false, "ana", "bia", "caio", "duda")
+		if err := s.SetTieBreak(tc.strategy); err != nil {
+			t.Fatal(err)
+		}
+		clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		s.SetClock(func() time.Time { return clock })
+		for i, option := range []string{"B", "A", "B", "A"} {
+			clock = clock.Add(time.Second)
+			vote(s, f, []string{"ana", "bia", "caio", "duda"}[i], testAddr(i+1), option)
+		}
+
+		if err := s.EndVotingNow(); err != nil {
+			t.Fatal(err)
+		}
+		m := f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+		if m.Final.Winner != tc.winner || !slices.Equal(m.Final.Tied, tc.tied) {
+			t.Errorf("%q: vencedor %q, empate %v; esperava %q, %v",
+				tc.strategy, m.Final.Winner, m.Final.Tied, tc.winner, tc.tied)
+		}
+	}
+}
+
+// A ordem de chegada conta pelo último voto: A chega antes a 1, mas B
+// chega antes à contagem final
+func TestTieBreakEarliestUsesLastIncrement(t *testing.T) {
+	s, f := votingServer(t, false, "ana", "bia", "caio", "duda")
+	s.SetTieBreak(TieBreakEarliest)
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return clock })
+	for i, option := range []string{"A", "B", "B", "A"} {
+		clock = clock.Add(time.Second)
+		vote(s, f, []string{"ana", "bia", "caio", "duda"}[i], testAddr(i+1), option)
+	}
+	s.mu.Lock()
+	winner := s.winnerLocked()
+	s.mu.Unlock()
+	if winner != "B" {
+		t.Fatalf("vencedor = %q, esperava B", winner)
+	}
+}
+
+func TestTieBreakUnknown(t *testing.T) {
+	s, _ := newFakeServer(t)
+	if err := s.SetTieBreak("sorteio"); err == nil {
+		t.Fatal("estratégia desconhecida aceita")
+	}
+}
+
+// Vencedor claro sai sem empate; sem nenhum voto, não há vencedor nem
+// empate (o empate em si está em TestTieBreakStrategies)
+func TestFinalWinnerAnnouncement(t *testing.T) {
+	cases := []struct {
+		name   string
+		votes  []string
+		winner string
+	}{
+		{"vencedor claro", []string{"B", "A", "B"}, "B"},
+		{"sem votos", nil, ""},
+	}
+	for _, tc := range cases {
+		s, f := votingServer(t, false, "ana", "bia", "caio")
+		for i, option := range tc.votes {
+			vote(s, f, []string{"ana", "bia", "caio"}[i], testAddr(i+1), option)
+		}
+		if err := s.EndVotingNow(); err != nil {
+			t.Fatal(err)
+		}
+		m := f.waitFor(t, testAddr(1), func(m Message) bool { return m.Type == "BROADCAST" && m.Final != nil })
+		if m.Final.Winner != tc.winner || m.Final.Tied != nil {
+			t.Errorf("%s: vencedor %q, empate %v; esperava %q sem empate", tc.name, m.Final.Winner, m.Final.Tied, tc.winner)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"time"
+)
+
+// ----------------------------------------------------------
+// Tempo restante sob demanda (TIME_LEFT)
+// ----------------------------------------------------------
+//
+// Quem entra depois da abertura só sabe o prazo pelo ACK de registro. Um
+// TIME_LEFT pode ser enviado a qualquer momento, sem registro, e volta com
+// o estado da votação (state) e os segundos que faltam (seconds_left,
+// arredondados para cima): congelados durante a pausa e 0 antes da
+// abertura e depois do encerramento.
+
+// timeLeft responde ao TIME_LEFT
+func (s *UDPServer) timeLeft(addr *net.UDPAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Prazo vencido antes do timer: encerra aqui, como no voto
+	now := s.now()
+	if s.votingState == VotingActive && s.deadlinePassedLocked(now) {
+		s.endVotingLocked()
+	}
+
+	msg := Message{Type: "TIME_LEFT", State: s.votingState}
+	switch s.votingState {
+	case VotingNotStarted:
+		msg.Message = s.notStartedMessageLocked()
+	case VotingActive:
+		msg.TimeLeft = ceilSeconds(s.votingDeadline.Sub(now))
+		msg.Message = fmt.Sprintf("Votação ativa (%ds restantes)", msg.TimeLeft)
+	case VotingPaused:
+		msg.TimeLeft = ceilSeconds(s.votingDeadline.Sub(s.pausedAt))
+		msg.Message = fmt.Sprintf("Votação pausada (%ds restantes)", msg.TimeLeft)
+	default:
+		msg.Message = "Votação encerrada"
+	}
+	s.send(addr, msg)
+}
+
+// ceilSeconds arredonda d para cima, em segundos (nunca negativo)
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// TIME_LEFT em cada estado: 0 antes da abertura, segundos arredondados
+// para cima com a votação ativa, congelados na pausa e 0 depois do
+// encerramento (inclusive pelo prazo vencido antes do timer)
+func TestTimeLeft(t *testing.T) {
+	s, f := newFakeServer(t)
+	clock := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return clock })
+	a := testAddr(1) // sem registro: TIME_LEFT não exige
+	ask := func() Message {
+		t.Helper()
+		deliver(s, a, Message{Type: "TIME_LEFT"})
+		got := f.last(a)
+		if got.Type != "TIME_LEFT" {
+			t.Fatalf("resposta ao TIME_LEFT = %+v", got)
+		}
+		return got
+	}
+	check := func(step string, state VotingState, left int) {
+		t.Helper()
+		if got := ask(); got.State != state || got.TimeLeft != left {
+			t.Fatalf("%s: %s com %ds (%q), esperava %s com %ds", step, got.State, got.TimeLeft, got.Message, state, left)
+		}
+	}
+
+	check("não iniciada", VotingNotStarted, 0)
+
+	s.StartVoting(60)
+	clock = clock.Add(10*time.Second + 500*time.Millisecond)
+	check("ativa", VotingActive, 50)
+
+	if err := s.PauseVoting(); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(time.Hour)
+	check("pausada", VotingPaused, 50)
+	if err := s.ResumeVoting(); err != nil {
+		t.Fatal(err)
+	}
+	check("retomada", VotingActive, 50)
+
+	clock = clock.Add(51 * time.Second)
+	check("prazo vencido", VotingEnded, 0)
+	if got := ask(); got.Message != "Votação encerrada" {
+		t.Fatalf("mensagem depois do encerramento = %q", got.Message)
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// Encerrada, pausada ou descartada antes do prazo, a votação não deixa
+// timer pendente, e passado o prazo original nenhum broadcast sai dele
+func TestCancelledEndTimerEmitsNothing(t *testing.T) {
+	cases := []struct {
+		name   string
+		cancel func(t *testing.T, s *UDPServer)
+		state  VotingState
+	}{
+		{"encerramento antecipado", func(t *testing.T, s *UDPServer) {
+			if err := s.EndVotingNow(); err != nil {
+				t.Fatal(err)
+			}
+		}, VotingEnded},
+		{"nova votação", func(t *testing.T, s *UDPServer) {
+			if err := s.EndVotingNow(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.ResetVoting([]string{"X", "Y"}); err != nil {
+				t.Fatal(err)
+			}
+		}, VotingNotStarted},
+		{"pausa", func(t *testing.T, s *UDPServer) {
+			if err := s.PauseVoting(); err != nil {
+				t.Fatal(err)
+			}
+		}, VotingPaused},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, f := newFakeServer(t)
+			a := testAddr(1)
+			register(t, s, f, "ana", a)
+			s.StartVoting(1)
+			tc.cancel(t, s)
+
+			s.mu.Lock()
+			pending := s.endTimer != nil
+			seq := s.broadcastSeq
+			s.mu.Unlock()
+			if pending {
+				t.Fatal("timer de encerramento continua agendado")
+			}
+
+			time.Sleep(1500 * time.Millisecond) // passa do prazo original
+			s.mu.Lock()
+			after := s.broadcastSeq
+			s.mu.Unlock()
+			if after != seq {
+				t.Fatalf("%d broadcasts depois do cancelamento, esperava nenhum", after-seq)
+			}
+			if s.State() != tc.state {
+				t.Fatalf("estado = %s, esperava %s", s.State(), tc.state)
+			}
+			stopFake(s, f)
+			if n := finalBroadcasts(f, a); n > 1 {
+				t.Fatalf("%d broadcasts finais, esperava no máximo 1", n)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ type VotingState string
 const (
 	VotingNotStarted VotingState = "NOT_STARTED"
 	VotingActive     VotingState = "ACTIVE"
+	VotingPaused     VotingState = "PAUSED"
 	VotingEnded      VotingState = "ENDED"
 )
 
@@ -17,13 +18,62 @@ const (
 // ----------------------------------------------------------
 
 type Message struct {
-	Type       string         `json:"type"`                  // REGISTER | VOTE | BROADCAST | ACK | ERROR
-	ClientID   string         `json:"client_id"`             // Identificador único do cliente
-	VoteOption string         `json:"vote,omitempty"`        // Enviado em VOTE
-	Message    string         `json:"message,omitempty"`     // Respostas do servidor (ACK/ERROR)
-	VoteCounts map[string]int `json:"vote_counts,omitempty"` // Usado apenas em BROADCAST
-	SeqNum     int            `json:"seq_num,omitempty"`     // Para rastrear perda UDP
-	Options    []string       `json:"options,omitempty"`     // Para enviar opções
+	Type       string           `json:"type"`                  // REGISTER | VOTE | BROADCAST | ACK | ERROR | PING | PONG | CHALLENGE | DELEGATE | RESYNC | SNAPSHOT | SERVER_STATS | OPTIONS | HEARTBEAT | RUNOFF | NEW_ROUND | PAUSE | RESUME | JOIN | UNREGISTER | GET_RESULTS | TIME_LEFT | ADMIN_END | ADMIN_CHALLENGE
+	ClientID   string           `json:"client_id"`             // Identificador único do cliente
+	VoteOption string           `json:"vote,omitempty"`        // Enviado em VOTE
+	Message    string           `json:"message,omitempty"`     // Respostas do servidor (ACK/ERROR)
+	VoteCounts map[string]int64 `json:"vote_counts,omitempty"` // Usado apenas em BROADCAST
+	Results    []OptionCount    `json:"results,omitempty"`     // VoteCounts na ordem declarada das opções
+	SeqNum     int              `json:"seq_num,omitempty"`     // Para rastrear perda UDP
+	Options    []string         `json:"options,omitempty"`     // Para enviar opções
+	Final      *FinalResult     `json:"final,omitempty"`       // Presente apenas no broadcast de encerramento
+	Mirror     bool             `json:"mirror,omitempty"`      // VOTE reenviado pelo primário (nunca é reenviado de novo)
+	Delegate   string           `json:"delegate,omitempty"`    // DELEGATE: cliente que recebe o voto
+	Round      int              `json:"round,omitempty"`       // RUNOFF / NEW_ROUND / ADMIN_CHALLENGE: número da rodada
+	UpTo       int              `json:"up_to,omitempty"`       // RESYNC: SeqNum recebido logo após o buraco
+	Stats      *ServerStats     `json:"stats,omitempty"`       // Resposta a SERVER_STATS
+	Acked      []string         `json:"acked,omitempty"`       // ACK de voto: ClientIDs confirmados (vários com SetAckCoalesce)
+	Sig        string           `json:"sig,omitempty"`         // HMAC do placar (SetBroadcastKey)
+	Version    int              `json:"version,omitempty"`     // REGISTER e ACK de registro: versão do protocolo
+	Weight     *int64           `json:"weight,omitempty"`      // REGISTER: peso do voto (SetWeightedVoting); VOTE espelhado: peso contado
+	RequestID  string           `json:"request_id,omitempty"`  // VOTE: identificador do pedido, ecoado no ACK/ERROR da resposta
+
+	RecordedOption string `json:"recorded_option,omitempty"` // ACK de um voto: opção registrada pelo servidor
+
+	Token string `json:"token,omitempty"` // Token de observador enviado no REGISTER
+	Auth  string `json:"auth,omitempty"`  // Token do cliente (SetClientSecret): no ACK de registro e em VOTE/DELEGATE/UNREGISTER/MUTE/UNMUTE; ADMIN_END: token do operador para o desafio (SetAdminSecret)
+
+	// Prova de trabalho do REGISTER (CHALLENGE do servidor e resposta do cliente);
+	// Challenge também leva o desafio do ADMIN_CHALLENGE e volta no ADMIN_END
+	Challenge  string `json:"challenge,omitempty"`
+	Difficulty int    `json:"difficulty,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+
+	// Campos do PONG (apenas com SetReportLoad habilitado)
+	Uptime      float64 `json:"uptime_s,omitempty"`     // segundos desde a criação do servidor
+	Goroutines  int     `json:"goroutines,omitempty"`   // goroutines ativas no servidor
+	ClientCount int     `json:"client_count,omitempty"` // clientes registrados (também no JOIN)
+
+	// Resposta ao TIME_LEFT
+	State    VotingState `json:"state,omitempty"`        // estado da votação
+	TimeLeft int         `json:"seconds_left,omitempty"` // segundos até o encerramento, arredondados para cima
+}
+
+// ----------------------------------------------------------
+// Dados anexados ao broadcast de encerramento
+// ----------------------------------------------------------
+
+type FinalResult struct {
+	ChainHash string   `json:"chain_hash,omitempty"` // Selo: hash do último registro de auditoria
+	Decision  string   `json:"decision,omitempty"`   // approved | rejected (com DecisionRule)
+	Winner    string   `json:"winner,omitempty"`     // opção vencedora (vazio sem votos ou em empate não resolvido)
+	Tied      []string `json:"tied,omitempty"`       // empate não resolvido: opções empatadas na liderança
+	RunnerUp  string   `json:"runner_up,omitempty"`  // segundo colocado (após o desempate)
+	Margin    int64    `json:"margin"`               // votos do vencedor menos os do segundo
+	MarginPct float64  `json:"margin_pct"`           // mesma vantagem, em % do total de votos
+
+	Quorum     int  `json:"quorum,omitempty"`      // votos mínimos exigidos (SetQuorum)
+	NonBinding bool `json:"non_binding,omitempty"` // abaixo do quórum: resultado não vinculante
 }
 
 // ----------------------------------------------------------
@@ -31,6 +81,8 @@ type Message struct {
 // ----------------------------------------------------------
 
 type BroadcastUpdate struct {
-	VoteCounts map[string]int // snapshot no momento do voto
-	SeqNum     int            // número incremental
+	VoteCounts map[string]int64 // snapshot no momento do voto
+	Results    []OptionCount    // mesmo snapshot, na ordem declarada
+	SeqNum     int              // número incremental
+	Final      *FinalResult     // preenchido apenas no encerramento
 }
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ----------------------------------------------------------
+// Versão do protocolo
+// ----------------------------------------------------------
+//
+// O cliente informa a versão do protocolo que fala no REGISTER, e o servidor
+// responde com a sua no ACK de registro. Com SetMinClientVersion, clientes
+// abaixo do mínimo são recusados já no registro com "Versão incompatível";
+// do outro lado, o cliente pode exigir uma versão mínima do servidor
+// (pkg/client, Options.MinServerVersion). Clientes e servidores anteriores
+// a essa troca não mandam o campo e contam como versão 0.
+
+// ProtocolVersion é a versão do protocolo falada por este servidor.
+// Incrementada a cada mudança que clientes antigos não entendem.
+const ProtocolVersion = 1
+
+// SetMinClientVersion recusa o registro de clientes com versão de protocolo
+// abaixo de v (0 = aceita todos). O registro automático no voto fica
+// desligado com mínimo definido, pois a versão vai no REGISTER.
+func (s *UDPServer) SetMinClientVersion(v int) error {
+	if v < 0 {
+		return errors.New("versão mínima do cliente não pode ser negativa")
+	}
+	if v > ProtocolVersion {
+		return fmt.Errorf("versão mínima do cliente %d acima da do servidor (%d)", v, ProtocolVersion)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minClientVersion = v
+	return nil
+}
+
+// versionErrorLocked monta a recusa de um cliente com versão `v`, com as
+// versões dos dois lados
+func (s *UDPServer) versionErrorLocked(v, seq int) Message {
+	return Message{
+		Type:    "ERROR",
+		Message: fmt.Sprintf("Versão incompatível: cliente %d, mínimo %d (servidor %d)", v, s.minClientVersion, ProtocolVersion),
+		Version: ProtocolVersion,
+		SeqNum:  seq,
+	}
+}
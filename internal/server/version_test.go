@@ -0,0 +1,36 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+// Com versão mínima, o cliente antigo (ou sem o campo) é recusado com as
+// duas versões na mensagem; o compatível recebe no ACK a versão do servidor
+func TestMinClientVersion(t *testing.T) {
+	s, f := newFakeServer(t)
+	if err := s.SetMinClientVersion(1); err != nil {
+		t.Fatal(err)
+	}
+	old, cur := testAddr(1), testAddr(2)
+
+	deliver(s, old, Message{Type: "REGISTER", ClientID: "ana", SeqNum: 1})
+	got := f.last(old)
+	if got.Type != "ERROR" || !strings.HasPrefix(got.Message, "Versão incompatível") ||
+		!strings.Contains(got.Message, "cliente 0, mínimo 1 (servidor 1)") || got.Version != ProtocolVersion || got.SeqNum != 1 {
+		t.Fatalf("cliente antigo = %+v", got)
+	}
+	deliver(s, cur, Message{Type: "REGISTER", ClientID: "bia", Version: ProtocolVersion, SeqNum: 1})
+	if got := f.last(cur); got.Type != "ACK" || got.Version != ProtocolVersion {
+		t.Fatalf("cliente compatível = %+v", got)
+	}
+	if clientCount(s) != 1 {
+		t.Fatalf("%d clientes registrados, esperava só bia", clientCount(s))
+	}
+
+	for _, v := range []int{-1, ProtocolVersion + 1} {
+		if err := s.SetMinClientVersion(v); err == nil {
+			t.Fatalf("versão mínima %d aceita", v)
+		}
+	}
+}
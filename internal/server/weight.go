@@ -0,0 +1,58 @@
+package server
+
+import "math"
+
+// ----------------------------------------------------------
+// Votação com peso por participante
+// ----------------------------------------------------------
+//
+// Em votações de acionistas cada participante pode representar um número
+// diferente de cotas. Com SetWeightedVoting, o REGISTER pode trazer um peso
+// (`weight`, padrão 1) e o voto do cliente soma esse peso à opção em vez de
+// 1. O peso fica gravado no registro de auditoria quando é diferente de 1,
+// e a apuração (ReplayAudit) o usa. O servidor confia no peso informado:
+// combine com a lista de IDs autorizados (token) ou restrinja a porta.
+
+// SetWeightedVoting aceita o peso informado no REGISTER. Desligado, um
+// REGISTER com peso é recusado.
+func (s *UDPServer) SetWeightedVoting(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weightedVoting = enabled
+}
+
+// weightErrorLocked valida o peso pedido no REGISTER ("" = aceito)
+func (s *UDPServer) weightErrorLocked(w *int64) string {
+	switch {
+	case w == nil:
+		return ""
+	case !s.weightedVoting:
+		return "Votação sem pesos"
+	case *w <= 0:
+		return "Peso inválido"
+	}
+	return ""
+}
+
+// clientWeightLocked devolve o peso do cliente registrado (1 sem peso)
+func (s *UDPServer) clientWeightLocked(id string) int64 {
+	if c, ok := s.clients[id]; ok && c.weight > 0 {
+		return c.weight
+	}
+	return 1
+}
+
+// voteWeightLocked devolve o peso com que o voto de `id` foi contado
+func (s *UDPServer) voteWeightLocked(id string) int64 {
+	if w, ok := s.voteWeights[id]; ok {
+		return w
+	}
+	return 1
+}
+
+// tallyFullLocked diz se somar `w` votos levaria o total além de
+// math.MaxInt64; nenhum voto a mais é contado, então nem as opções nem o
+// total estouram
+func (s *UDPServer) tallyFullLocked(w int64) bool {
+	return totalVotes(s.voteCounts) > math.MaxInt64-w
+}
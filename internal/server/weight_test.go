@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func weight(n int64) *int64 { return &n }
+
+// O peso do REGISTER vira o valor do voto; sem SetWeightedVoting, ou com
+// peso não positivo, o REGISTER é recusado
+func TestWeightedVoting(t *testing.T) {
+	cases := []struct {
+		name     string
+		enabled  bool
+		weight   *int64
+		wantErr  string
+		wantVote int64
+	}{
+		{"padrão", true, nil, "", 1},
+		{"peso informado", true, weight(5), "", 5},
+		{"peso um", true, weight(1), "", 1},
+		{"peso zero", true, weight(0), "Peso inválido", 0},
+		{"peso negativo", true, weight(-3), "Peso inválido", 0},
+		{"desligado, sem peso", false, nil, "", 1},
+		{"desligado, com peso", false, weight(5), "Votação sem pesos", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f := newFakeServer(t)
+			s.SetWeightedVoting(tc.enabled)
+			a := testAddr(1)
+			deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", Weight: tc.weight, SeqNum: 3})
+			got := f.last(a)
+			if tc.wantErr != "" {
+				if got.Type != "ERROR" || got.Message != tc.wantErr || got.SeqNum != 3 {
+					t.Fatalf("REGISTER = %+v, esperava ERROR %q", got, tc.wantErr)
+				}
+				if clientCount(s) != 0 {
+					t.Fatal("REGISTER recusado deixou o cliente registrado")
+				}
+				return
+			}
+			if got.Type != "ACK" {
+				t.Fatalf("REGISTER = %+v", got)
+			}
+			s.StartVoting(60)
+			if got := vote(s, f, "ana", a, "A"); got.Type != "ACK" {
+				t.Fatalf("voto = %+v", got)
+			}
+			wantTally(t, s, map[string]int64{"A": tc.wantVote, "B": 0})
+		})
+	}
+}
+
+// A troca de voto leva o peso inteiro para a nova opção, e a auditoria
+// apura o mesmo placar
+func TestWeightedRevoteAndAudit(t *testing.T) {
+	var audit bytes.Buffer
+	s, f := newFakeServer(t)
+	s.SetWeightedVoting(true)
+	s.SetAllowRevote(true)
+	s.SetAuditLog(&audit)
+	a, b := testAddr(1), testAddr(2)
+	deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", Weight: weight(7)})
+	register(t, s, f, "bia", b)
+	s.StartVoting(60)
+
+	vote(s, f, "ana", a, "A")
+	vote(s, f, "bia", b, "A")
+	wantTally(t, s, map[string]int64{"A": 8, "B": 0})
+	if got := vote(s, f, "ana", a, "B"); got.Message != "Voto alterado" {
+		t.Fatalf("troca = %+v", got)
+	}
+	wantTally(t, s, map[string]int64{"A": 1, "B": 7})
+
+	records, err := ReadAuditLog(&audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ReplayAudit(records); got["A"] != 1 || got["B"] != 7 {
+		t.Fatalf("auditoria = %v, esperava A=1 B=7", got)
+	}
+}
+
+// Pesos que estourariam o int64 são recusados em vez de virar negativos
+func TestWeightedTallyLimit(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetWeightedVoting(true)
+	a, b := testAddr(1), testAddr(2)
+	deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", Weight: weight(math.MaxInt64)})
+	deliver(s, b, Message{Type: "REGISTER", ClientID: "bia", Weight: weight(1)})
+	s.StartVoting(60)
+
+	if got := vote(s, f, "ana", a, "A"); got.Type != "ACK" {
+		t.Fatalf("voto de ana = %+v", got)
+	}
+	if got := vote(s, f, "bia", b, "B"); got.Type != "ERROR" || got.Message != "Limite da contagem atingido" {
+		t.Fatalf("voto de bia = %+v, esperava o limite da contagem", got)
+	}
+	wantTally(t, s, map[string]int64{"A": math.MaxInt64, "B": 0})
+}
+
+// Contagem no limite do int64: o total pode chegar exatamente a
+// MaxInt64, sem estourar, e sai no JSON como inteiro exato
+func TestTallyAtInt64Boundary(t *testing.T) {
+	s, f := newFakeServer(t)
+	s.SetWeightedVoting(true)
+	a, b, c := testAddr(1), testAddr(2), testAddr(3)
+	deliver(s, a, Message{Type: "REGISTER", ClientID: "ana", Weight: weight(math.MaxInt64 - 1)})
+	deliver(s, b, Message{Type: "REGISTER", ClientID: "bia", Weight: weight(1)})
+	deliver(s, c, Message{Type: "REGISTER", ClientID: "caio", Weight: weight(1)})
+	s.StartVoting(60)
+
+	vote(s, f, "ana", a, "A")
+	if got := vote(s, f, "bia", b, "B"); got.Type != "ACK" {
+		t.Fatalf("voto que leva o total a MaxInt64 = %+v", got)
+	}
+	if got := vote(s, f, "caio", c, "B"); got.Type != "ERROR" || got.Message != "Limite da contagem atingido" {
+		t.Fatalf("voto além de MaxInt64 = %+v", got)
+	}
+
+	r := s.GetResults()
+	if r.TotalVotes != math.MaxInt64 || r.VoteCounts["A"] != math.MaxInt64-1 || r.VoteCounts["B"] != 1 {
+		t.Fatalf("apuração = %v (total %d)", r.VoteCounts, r.TotalVotes)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"A":9223372036854775806`, `"total_votes":9223372036854775807`} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("JSON sem %s: %s", want, data)
+		}
+	}
+	m := f.waitFor(t, a, func(m Message) bool { return m.Type == "BROADCAST" && m.VoteCounts["B"] == 1 })
+	if m.VoteCounts["A"] != math.MaxInt64-1 {
+		t.Fatalf("broadcast com A = %d, esperava %d", m.VoteCounts["A"], int64(math.MaxInt64-1))
+	}
+}
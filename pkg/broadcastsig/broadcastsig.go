@@ -0,0 +1,64 @@
+// Package broadcastsig assina e confere os placares enviados pelo servidor,
+// para que clientes em uma rede compartilhada não aceitem broadcasts
+// forjados com o endereço do servidor.
+package broadcastsig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Fields são as partes assinadas de uma mensagem com placar. O SeqNum
+// entra na assinatura: um placar antigo reenviado por um atacante continua
+// válido, mas o cliente o descarta por não ser mais novo que o último visto.
+type Fields struct {
+	Type       string
+	SeqNum     int
+	VoteCounts map[string]int64
+
+	// Resultado final (vazios nos parciais)
+	ChainHash  string
+	Decision   string
+	Winner     string
+	NonBinding bool     // só entra na assinatura quando verdadeiro
+	Tied       []string // só entra na assinatura quando há empate
+}
+
+// Sign devolve o HMAC-SHA256 dos campos, em hexadecimal
+func Sign(key []byte, f Fields) string {
+	mac := hmac.New(sha256.New, key)
+	write(mac, f)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify confere a assinatura em tempo constante
+func Verify(key []byte, f Fields, sig string) bool {
+	got, err := hex.DecodeString(sig)
+	if err != nil || len(got) != sha256.Size {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	write(mac, f)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// write serializa os campos de forma canônica: o JSON de um map sai com as
+// chaves ordenadas, e map vazio e ausente são equivalentes (omitempty)
+func write(h hash.Hash, f Fields) {
+	counts := []byte("{}")
+	if len(f.VoteCounts) > 0 {
+		counts, _ = json.Marshal(f.VoteCounts)
+	}
+	fmt.Fprintf(h, "%s|%d|%s|%s|%s|%s", f.Type, f.SeqNum, counts, f.ChainHash, f.Decision, f.Winner)
+	if f.NonBinding {
+		fmt.Fprint(h, "|nonbinding")
+	}
+	if len(f.Tied) > 0 {
+		fmt.Fprintf(h, "|tied:%s", strings.Join(f.Tied, ","))
+	}
+}
@@ -0,0 +1,83 @@
+package broadcastsig
+
+import (
+	"strings"
+	"testing"
+)
+
+var key = []byte("chave")
+
+// Resultado final com todos os campos assinados preenchidos
+func finalFields() Fields {
+	return Fields{
+		Type:       "BROADCAST",
+		SeqNum:     7,
+		VoteCounts: map[string]int64{"A": 2, "B": 2},
+		ChainHash:  "abc",
+		Decision:   "rejected",
+		NonBinding: true,
+		Tied:       []string{"A", "B"},
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	f := finalFields()
+	sig := Sign(key, f)
+	if len(sig) != 64 || strings.Trim(sig, "0123456789abcdef") != "" {
+		t.Fatalf("assinatura %q não é HMAC-SHA256 em hexadecimal", sig)
+	}
+	if !Verify(key, f, sig) {
+		t.Fatal("assinatura válida recusada")
+	}
+	if Verify([]byte("outra"), f, sig) {
+		t.Fatal("assinatura aceita com outra chave")
+	}
+	for _, bad := range []string{"", "zz", sig[:62], sig + "00", strings.ToUpper(sig[:2]) + "x" + sig[3:]} {
+		if Verify(key, f, bad) {
+			t.Fatalf("assinatura malformada %q aceita", bad)
+		}
+	}
+}
+
+// Cada campo assinado, alterado, invalida a assinatura
+func TestVerifyTamperedField(t *testing.T) {
+	sig := Sign(key, finalFields())
+	cases := []struct {
+		name   string
+		tamper func(f *Fields)
+	}{
+		{"tipo", func(f *Fields) { f.Type = "RESYNC" }},
+		{"SeqNum", func(f *Fields) { f.SeqNum++ }},
+		{"contagem", func(f *Fields) { f.VoteCounts["A"]++ }},
+		{"opção a mais", func(f *Fields) { f.VoteCounts["C"] = 0 }},
+		{"selo", func(f *Fields) { f.ChainHash = "abd" }},
+		{"decisão", func(f *Fields) { f.Decision = "approved" }},
+		{"vencedor", func(f *Fields) { f.Winner = "A" }},
+		{"quórum", func(f *Fields) { f.NonBinding = false }},
+		{"empate", func(f *Fields) { f.Tied = []string{"A"} }},
+		{"sem empate", func(f *Fields) { f.Tied = nil }},
+	}
+	for _, tc := range cases {
+		f := finalFields()
+		tc.tamper(&f)
+		if Verify(key, f, sig) {
+			t.Errorf("%s alterado: assinatura aceita", tc.name)
+		}
+	}
+}
+
+// A serialização é canônica: a ordem do map não importa, e map vazio, map
+// ausente, NonBinding falso e empate vazio assinam igual à ausência
+func TestSignCanonical(t *testing.T) {
+	a := Fields{Type: "BROADCAST", SeqNum: 1, VoteCounts: map[string]int64{"A": 1, "B": 2, "C": 3}}
+	b := Fields{Type: "BROADCAST", SeqNum: 1, VoteCounts: map[string]int64{"C": 3, "A": 1, "B": 2}}
+	if Sign(key, a) != Sign(key, b) {
+		t.Fatal("ordem das chaves mudou a assinatura")
+	}
+
+	empty := Fields{Type: "SNAPSHOT", SeqNum: 2, VoteCounts: map[string]int64{}, Tied: []string{}}
+	absent := Fields{Type: "SNAPSHOT", SeqNum: 2}
+	if Sign(key, empty) != Sign(key, absent) {
+		t.Fatal("map vazio e ausente assinam diferente")
+	}
+}
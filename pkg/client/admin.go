@@ -0,0 +1,42 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// AdminToken deriva o token de uma mensagem ADMIN_* (campo Auth) para o
+// desafio e a rodada recebidos no ADMIN_CHALLENGE (mesma regra do servidor)
+func AdminToken(secret, msgType string, round int, challenge string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(msgType + "|" + strconv.Itoa(round) + "|" + challenge))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EndVoting pede ao servidor o encerramento antecipado da votação com o
+// segredo do operador (admin_secret no servidor). O desafio do servidor é
+// respondido sozinho; o resultado chega como ACK ou ERROR no OnMessage.
+func (c *Client) EndVoting(secret string) error {
+	c.m.Lock()
+	c.adminSecret = secret
+	c.m.Unlock()
+	return c.Send(Message{Type: "ADMIN_END"})
+}
+
+// answerAdmin responde ao ADMIN_CHALLENGE do EndVoting em andamento
+func (c *Client) answerAdmin(msg Message) {
+	c.m.Lock()
+	secret := c.adminSecret
+	c.adminSecret = ""
+	c.m.Unlock()
+	if secret == "" {
+		return // desafio não pedido (ou já respondido)
+	}
+	c.Send(Message{
+		Type:      "ADMIN_END",
+		Challenge: msg.Challenge,
+		Auth:      AdminToken(secret, "ADMIN_END", msg.Round, msg.Challenge),
+	})
+}
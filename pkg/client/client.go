@@ -0,0 +1,296 @@
+// Package client fala o protocolo de votação UDP, para que outros programas
+// Go (ferramentas, testes, o próprio cliente de terminal) possam se
+// registrar, votar e acompanhar o placar sem reimplementar o protocolo.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/broadcastsig"
+)
+
+// Maior payload possível em um datagrama UDP sobre IPv4.
+// Broadcasts com muitas opções não cabem em buffers menores e seriam truncados.
+const MaxMessageSize = 65507
+
+// Versão do protocolo falada por este pacote, enviada no REGISTER
+// (mesmo valor de server.ProtocolVersion)
+const ProtocolVersion = 1
+
+// ErrUntrusted indica um placar descartado por assinatura inválida ou por
+// ser um broadcast antigo reenviado (só com Options.Key)
+var ErrUntrusted = errors.New("assinatura inválida ou reenvio")
+
+// ErrNoReply indica que o servidor não respondeu a tempo
+var ErrNoReply = errors.New("sem resposta do servidor")
+
+// ErrNotRegistered indica um voto antes do ACK de registro
+var ErrNotRegistered = errors.New("cliente não registrado")
+
+// ErrIncompatible indica um servidor abaixo de Options.MinServerVersion
+var ErrIncompatible = errors.New("Versão incompatível")
+
+// Options ajusta o comportamento do Client. As funções são chamadas pela
+// goroutine de leitura, na ordem de chegada dos pacotes, e não devem
+// bloquear.
+type Options struct {
+	Token       string        // token de observador enviado no REGISTER
+	Key         []byte        // chave dos placares assinados (nil = não confere)
+	ReadTimeout time.Duration // prazo de cada leitura do socket (0 = sem prazo)
+
+	// Retransmissão do VOTE sem resposta
+	VoteTimeout time.Duration // espera pela resposta de cada envio (0 = 500ms)
+	VoteRetries int           // reenvios antes de desistir (0 = 3; negativo = nenhum)
+
+	// Versão de protocolo mínima do servidor, conferida no ACK de registro
+	// (0 = aceita qualquer uma; servidores sem o campo contam como 0)
+	MinServerVersion int
+
+	// Peso do voto pedido no REGISTER (0 = sem peso; o servidor precisa
+	// de weighted_voting)
+	Weight int64
+
+	OnMessage   func(Message)                    // toda mensagem aceita, inclusive as tratadas pelo Client
+	OnVoteRetry func(option string, attempt int) // a cada reenvio do VOTE (attempt começa em 1)
+	OnDiscard   func(msg Message, err error)     // pacotes inválidos ou não confiáveis
+	OnError     func(error)                      // leitura interrompida (o Client para de receber)
+}
+
+// Client é um votante ligado a um servidor. Register, Vote e Send podem ser
+// chamados de várias goroutines.
+type Client struct {
+	conn net.Conn
+	name string
+	opts Options
+
+	done      chan struct{} // fechado no Close
+	closeOnce sync.Once
+
+	reg registration
+
+	voting  sync.Mutex // um VOTE aguardando resposta por vez
+	m       sync.Mutex
+	voteSeq int          // número do último VOTE enviado
+	pending *pendingVote // VOTE aguardando resposta (nil = nenhum)
+	echoes  int          // respostas ainda esperadas a cópias do último VOTE já respondido
+
+	recorded string // opção registrada no último voto confirmado (RecordedVote)
+
+	adminSecret string // segredo do EndVoting à espera do ADMIN_CHALLENGE ("" = nenhum)
+
+	subscribers []func(Results)
+
+	lastSeq int // último BROADCAST aceito (só a goroutine de leitura usa)
+
+	refused atomic.Int64 // leituras recusadas pelo SO (servidor fora do ar)
+}
+
+// Dial abre o socket para o servidor e começa a receber. O cliente usa
+// `name` como ClientID; chame Register antes de votar.
+func Dial(server, name string, opts Options) (*Client, error) {
+	conn, err := net.Dial("udp", server)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn: conn,
+		name: name,
+		opts: opts,
+		done: make(chan struct{}),
+		reg:  registration{result: make(chan error, 1), left: make(chan struct{}, 1)},
+	}
+	go c.listen()
+	return c, nil
+}
+
+// Name devolve o ClientID do cliente
+func (c *Client) Name() string { return c.name }
+
+// LocalAddr devolve o endereço local do socket
+func (c *Client) LocalAddr() net.Addr { return c.conn.LocalAddr() }
+
+// RemoteAddr devolve o endereço do servidor
+func (c *Client) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// Refused conta as leituras recusadas pelo SO (ICMP port unreachable): sem
+// conexão a refazer, é o sinal de que o servidor esteve fora do ar
+func (c *Client) Refused() int64 { return c.refused.Load() }
+
+// Close fecha o socket; esperas em andamento terminam com net.ErrClosed
+func (c *Client) Close() error {
+	err := net.ErrClosed
+	c.closeOnce.Do(func() {
+		close(c.done)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// Subscribe registra fn para receber cada placar aceito: broadcasts,
+// reenvios sob RESYNC e SNAPSHOT
+func (c *Client) Subscribe(fn func(Results)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.subscribers = append(c.subscribers, fn)
+}
+
+// Send envia uma mensagem ao servidor; ClientID vazio vira o do cliente,
+// e o token do registro vai junto quando o servidor exige autenticação
+func (c *Client) Send(msg Message) error {
+	if msg.ClientID == "" {
+		msg.ClientID = c.name
+	}
+	if msg.Auth == "" {
+		c.reg.m.Lock()
+		msg.Auth = c.reg.auth
+		c.reg.m.Unlock()
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(data)
+	return err
+}
+
+// ----------------------------------------------------------
+// Recepção
+// ----------------------------------------------------------
+
+// listen lê e despacha os pacotes até o socket ser fechado
+func (c *Client) listen() {
+	buf := make([]byte, MaxMessageSize)
+	for {
+		if c.opts.ReadTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.opts.ReadTimeout))
+		} else {
+			c.conn.SetReadDeadline(time.Time{}) // sem prazo: bloqueia até chegar dado
+		}
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			// Prazo vencido em período ocioso, ou servidor ainda fora do ar
+			// (ICMP port unreachable): continua ouvindo
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			if errors.Is(err, syscall.ECONNREFUSED) {
+				c.refused.Add(1)
+				continue
+			}
+			select {
+			case <-c.done: // Close
+			default:
+				if c.opts.OnError != nil {
+					c.opts.OnError(err)
+				}
+			}
+			return
+		}
+
+		var msg Message
+		if err := json.Unmarshal(buf[:n], &msg); err != nil {
+			// Buffer cheio: o datagrama pode ter sido truncado pelo SO
+			if n == len(buf) {
+				err = fmt.Errorf("pacote de %d bytes possivelmente truncado: %v", n, err)
+			} else {
+				err = fmt.Errorf("pacote inválido (%d bytes): %v", n, err)
+			}
+			c.discard(msg, err)
+			continue
+		}
+		// Com chave, placar sem assinatura válida (ou broadcast antigo
+		// reenviado) é descartado
+		if c.opts.Key != nil && !c.trusted(msg) {
+			c.discard(msg, ErrUntrusted)
+			continue
+		}
+		c.dispatch(msg)
+	}
+}
+
+// dispatch avisa a aplicação e aplica a mensagem ao estado do cliente
+func (c *Client) dispatch(msg Message) {
+	// Antes do registro, ACK/ERROR são respostas ao REGISTER
+	registering := (msg.Type == "ACK" || msg.Type == "ERROR") && !c.reg.done.Load()
+	if registering && !c.reg.current(msg) {
+		return // resposta de uma tentativa anterior
+	}
+	if !registering && (msg.Type == "ACK" || msg.Type == "ERROR") {
+		var echo bool
+		if msg, echo = c.voteReply(msg); echo {
+			return // resposta a uma cópia reenviada de um voto já respondido
+		}
+	}
+	if c.opts.OnMessage != nil {
+		c.opts.OnMessage(msg)
+	}
+
+	switch msg.Type {
+	case "ACK", "ERROR":
+		switch {
+		case registering:
+			c.reg.accept(msg, c.opts.MinServerVersion)
+		case msg.Type == "ACK" && msg.Message == unregistered:
+			select {
+			case c.reg.left <- struct{}{}:
+			default:
+			}
+		}
+	case "CHALLENGE":
+		// Prova de trabalho exigida pelo servidor antes do registro
+		go c.solve(msg)
+	case "ADMIN_CHALLENGE":
+		// Desafio do encerramento antecipado pedido com EndVoting
+		c.answerAdmin(msg)
+	case "PING":
+		// Sonda de inatividade do servidor: responder mantém o registro
+		c.Send(Message{Type: "PONG", SeqNum: msg.SeqNum})
+	case "BROADCAST", "RESYNC", "SNAPSHOT":
+		if msg.Type == "BROADCAST" && msg.SeqNum > c.lastSeq {
+			c.lastSeq = msg.SeqNum
+		}
+		c.publish(Results{Kind: msg.Type, SeqNum: msg.SeqNum, VoteCounts: msg.VoteCounts, Final: msg.Final})
+	}
+}
+
+func (c *Client) publish(r Results) {
+	c.m.Lock()
+	subs := c.subscribers
+	c.m.Unlock()
+	for _, fn := range subs {
+		fn(r)
+	}
+}
+
+func (c *Client) discard(msg Message, err error) {
+	if c.opts.OnDiscard != nil {
+		c.opts.OnDiscard(msg, err)
+	}
+}
+
+// trusted confere a assinatura das mensagens com placar; um BROADCAST
+// também precisa ser mais novo que o último visto
+func (c *Client) trusted(msg Message) bool {
+	switch msg.Type {
+	case "BROADCAST", "RESYNC", "SNAPSHOT":
+	default:
+		return true
+	}
+	f := broadcastsig.Fields{Type: msg.Type, SeqNum: msg.SeqNum, VoteCounts: msg.VoteCounts}
+	if msg.Final != nil {
+		f.ChainHash, f.Decision, f.Winner = msg.Final.ChainHash, msg.Final.Decision, msg.Final.Winner
+		f.NonBinding, f.Tied = msg.Final.NonBinding, msg.Final.Tied
+	}
+	if !broadcastsig.Verify(c.opts.Key, f, msg.Sig) {
+		return false
+	}
+	return msg.Type != "BROADCAST" || msg.SeqNum > c.lastSeq
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/pkg/broadcastsig"
+)
+
+// Espera máxima por um pacote ou evento nos testes
+const waitTimeout = 2 * time.Second
+
+// peer faz o papel do servidor num socket local: os testes leem o que o
+// Client envia e respondem o que quiserem
+type peer struct {
+	t    *testing.T
+	conn *net.UDPConn
+}
+
+func newPeer(t *testing.T) *peer {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &peer{t: t, conn: conn}
+}
+
+// dial liga um Client ao peer
+func (p *peer) dial(name string, opts Options) *Client {
+	p.t.Helper()
+	c, err := Dial(p.conn.LocalAddr().String(), name, opts)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	p.t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// recv devolve a próxima mensagem enviada por algum Client
+func (p *peer) recv() (Message, *net.UDPAddr) {
+	p.t.Helper()
+	buf := make([]byte, MaxMessageSize)
+	p.conn.SetReadDeadline(time.Now().Add(waitTimeout))
+	n, addr, err := p.conn.ReadFromUDP(buf)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	var msg Message
+	if err := json.Unmarshal(buf[:n], &msg); err != nil {
+		p.t.Fatal(err)
+	}
+	return msg, addr
+}
+
+// send entrega msg ao Client c
+func (p *peer) send(c *Client, msg Message) {
+	p.t.Helper()
+	data, err := json.Marshal(msg)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if _, err := p.conn.WriteToUDP(data, c.LocalAddr().(*net.UDPAddr)); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// signed assina msg como o servidor faz com SetBroadcastKey
+func signed(key []byte, msg Message) Message {
+	f := broadcastsig.Fields{Type: msg.Type, SeqNum: msg.SeqNum, VoteCounts: msg.VoteCounts}
+	if msg.Final != nil {
+		f.ChainHash, f.Decision, f.Winner = msg.Final.ChainHash, msg.Final.Decision, msg.Final.Winner
+		f.NonBinding, f.Tied = msg.Final.NonBinding, msg.Final.Tied
+	}
+	msg.Sig = broadcastsig.Sign(key, f)
+	return msg
+}
+
+// Com Key, o Client só aceita placares assinados com ela, e um BROADCAST
+// precisa ser mais novo que o último aceito
+func TestClientSignedScoreboards(t *testing.T) {
+	key := []byte("chave")
+	p := newPeer(t)
+	type event struct {
+		accepted bool
+		seq      int
+		err      error
+	}
+	events := make(chan event, 16)
+	c := p.dial("ana", Options{
+		Key: key,
+		OnDiscard: func(m Message, err error) {
+			events <- event{false, m.SeqNum, err}
+		},
+	})
+	c.Subscribe(func(r Results) { events <- event{true, r.SeqNum, nil} })
+
+	counts := map[string]int64{"A": 1}
+	tampered := signed(key, Message{Type: "BROADCAST", SeqNum: 4, VoteCounts: counts})
+	tampered.VoteCounts = map[string]int64{"A": 9}
+	cases := []struct {
+		name   string
+		msg    Message
+		accept bool
+	}{
+		{"assinado", signed(key, Message{Type: "BROADCAST", SeqNum: 2, VoteCounts: counts}), true},
+		{"sem assinatura", Message{Type: "BROADCAST", SeqNum: 3, VoteCounts: counts}, false},
+		{"reenviado", signed(key, Message{Type: "BROADCAST", SeqNum: 2, VoteCounts: counts}), false},
+		{"outra chave", signed([]byte("outra"), Message{Type: "BROADCAST", SeqNum: 5, VoteCounts: counts}), false},
+		{"adulterado", tampered, false},
+		{"RESYNC antigo", signed(key, Message{Type: "RESYNC", SeqNum: 1, VoteCounts: counts}), true},
+		{"final", signed(key, Message{Type: "BROADCAST", SeqNum: 6, VoteCounts: counts,
+			Final: &FinalResult{ChainHash: "abc", Winner: "A", Margin: 1}}), true},
+		{"final forjado", func() Message {
+			m := signed(key, Message{Type: "BROADCAST", SeqNum: 7, VoteCounts: counts, Final: &FinalResult{Winner: "A"}})
+			m.Final.Winner = "B"
+			return m
+		}(), false},
+	}
+	for _, tc := range cases {
+		p.send(c, tc.msg)
+		select {
+		case ev := <-events:
+			if ev.accepted != tc.accept || ev.seq != tc.msg.SeqNum {
+				t.Fatalf("%s: evento = %+v, esperava aceito=%v", tc.name, ev, tc.accept)
+			}
+			if !ev.accepted && !errors.Is(ev.err, ErrUntrusted) {
+				t.Fatalf("%s: descarte com erro %v, esperava ErrUntrusted", tc.name, ev.err)
+			}
+		case <-time.After(waitTimeout):
+			t.Fatalf("%s: pacote não chegou", tc.name)
+		}
+	}
+}
+
+// Sem Key, nada é conferido
+func TestClientUnsignedWithoutKey(t *testing.T) {
+	p := newPeer(t)
+	results := make(chan Results, 1)
+	c := p.dial("ana", Options{})
+	c.Subscribe(func(r Results) { results <- r })
+
+	p.send(c, Message{Type: "BROADCAST", SeqNum: 3, VoteCounts: map[string]int64{"A": 1}})
+	select {
+	case r := <-results:
+		if r.SeqNum != 3 || r.VoteCounts["A"] != 1 {
+			t.Fatalf("placar = %+v", r)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("placar sem assinatura descartado sem Key")
+	}
+}
+
+// Um placar maior que o antigo buffer de 4096 bytes chega inteiro; um
+// datagrama que enche o buffer é descartado como possível truncamento
+func TestClientLargeBroadcast(t *testing.T) {
+	p := newPeer(t)
+	results := make(chan Results, 1)
+	discarded := make(chan error, 1)
+	c := p.dial("ana", Options{OnDiscard: func(_ Message, err error) { discarded <- err }})
+	c.Subscribe(func(r Results) { results <- r })
+
+	counts := make(map[string]int64)
+	for i := 0; i < 400; i++ {
+		counts[fmt.Sprintf("opção-%03d", i)] = int64(i)
+	}
+	big := Message{Type: "BROADCAST", SeqNum: 1, VoteCounts: counts}
+	if data, _ := json.Marshal(big); len(data) <= 4096 {
+		t.Fatalf("placar de %d bytes não passa do buffer antigo", len(data))
+	}
+	p.send(c, big)
+	select {
+	case r := <-results:
+		if len(r.VoteCounts) != 400 || r.VoteCounts["opção-399"] != 399 {
+			t.Fatalf("placar grande chegou com %d opções", len(r.VoteCounts))
+		}
+	case err := <-discarded:
+		t.Fatalf("placar grande descartado: %v", err)
+	case <-time.After(waitTimeout):
+		t.Fatal("placar grande não chegou")
+	}
+
+	full := bytes.Repeat([]byte("x"), MaxMessageSize)
+	if _, err := p.conn.WriteToUDP(full, c.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Skip("datagrama do tamanho máximo não enviado:", err)
+	}
+	select {
+	case err := <-discarded:
+		if !strings.Contains(err.Error(), "possivelmente truncado") {
+			t.Fatalf("descarte = %v, esperava aviso de truncamento", err)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("datagrama que enche o buffer não foi descartado")
+	}
+}
+
+// Prazos de leitura vencendo em período ocioso não derrubam a recepção;
+// com ReadTimeout 0 a leitura bloqueia até chegar dado
+func TestClientReadTimeoutIdle(t *testing.T) {
+	for _, timeout := range []time.Duration{10 * time.Millisecond, 0} {
+		p := newPeer(t)
+		results := make(chan Results, 2)
+		failed := make(chan error, 1)
+		c := p.dial("ana", Options{ReadTimeout: timeout, OnError: func(err error) { failed <- err }})
+		c.Subscribe(func(r Results) { results <- r })
+
+		for seq := 1; seq <= 2; seq++ {
+			time.Sleep(100 * time.Millisecond) // vários prazos vencem sem pacote
+			p.send(c, Message{Type: "BROADCAST", SeqNum: seq})
+			select {
+			case r := <-results:
+				if r.SeqNum != seq {
+					t.Fatalf("timeout %s: placar #%d, esperava #%d", timeout, r.SeqNum, seq)
+				}
+			case err := <-failed:
+				t.Fatalf("timeout %s: recepção encerrada: %v", timeout, err)
+			case <-time.After(waitTimeout):
+				t.Fatalf("timeout %s: placar #%d não chegou depois do período ocioso", timeout, seq)
+			}
+		}
+
+		// Fechar encerra a leitura sem reportar erro
+		c.Close()
+		select {
+		case err := <-failed:
+			t.Fatalf("timeout %s: Close reportou %v", timeout, err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Contagens no limite do int64 chegam exatas, sem passar por float64
+func TestClientInt64Counts(t *testing.T) {
+	p := newPeer(t)
+	results := make(chan Results, 1)
+	c := p.dial("ana", Options{})
+	c.Subscribe(func(r Results) { results <- r })
+
+	if _, err := p.conn.WriteToUDP([]byte(`{"type":"BROADCAST","seq_num":1,"vote_counts":{"A":9223372036854775807,"B":9223372036854775806}}`),
+		c.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-results:
+		if r.VoteCounts["A"] != math.MaxInt64 || r.VoteCounts["B"] != math.MaxInt64-1 {
+			t.Fatalf("placar = %v", r.VoteCounts)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("placar não chegou")
+	}
+}
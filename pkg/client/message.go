@@ -0,0 +1,72 @@
+package client
+
+// Formato JSON trocado com o servidor
+type Message struct {
+	Type       string           `json:"type"`
+	ClientID   string           `json:"client_id"`
+	VoteOption string           `json:"vote,omitempty"`
+	Message    string           `json:"message,omitempty"`
+	VoteCounts map[string]int64 `json:"vote_counts,omitempty"`
+	SeqNum     int              `json:"seq_num,omitempty"`
+	Options    []string         `json:"options,omitempty"`
+	Final      *FinalResult     `json:"final,omitempty"`
+	Delegate   string           `json:"delegate,omitempty"`
+	UpTo       int              `json:"up_to,omitempty"`
+	Round      int              `json:"round,omitempty"`
+	Stats      *ServerStats     `json:"stats,omitempty"`
+	Sig        string           `json:"sig,omitempty"`
+	Acked      []string         `json:"acked,omitempty"`
+	Version    int              `json:"version,omitempty"`
+	Weight     *int64           `json:"weight,omitempty"`
+	RequestID  string           `json:"request_id,omitempty"` // VOTE, ecoado no ACK/ERROR da resposta
+
+	RecordedOption string `json:"recorded_option,omitempty"` // ACK de voto: opção registrada pelo servidor
+
+	Token      string `json:"token,omitempty"`
+	Auth       string `json:"auth,omitempty"` // token do cliente devolvido no ACK de registro
+	Challenge  string `json:"challenge,omitempty"`
+	Difficulty int    `json:"difficulty,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+
+	Uptime      float64 `json:"uptime_s,omitempty"`
+	Goroutines  int     `json:"goroutines,omitempty"`
+	ClientCount int     `json:"client_count,omitempty"`
+
+	State    string `json:"state,omitempty"`        // TIME_LEFT
+	TimeLeft int    `json:"seconds_left,omitempty"` // TIME_LEFT
+}
+
+// Dados enviados junto com o broadcast de encerramento
+type FinalResult struct {
+	ChainHash string   `json:"chain_hash,omitempty"`
+	Decision  string   `json:"decision,omitempty"`
+	Winner    string   `json:"winner,omitempty"`
+	Tied      []string `json:"tied,omitempty"` // empate sem vencedor
+	RunnerUp  string   `json:"runner_up,omitempty"`
+	Margin    int64    `json:"margin"`
+	MarginPct float64  `json:"margin_pct"`
+
+	Quorum     int  `json:"quorum,omitempty"`
+	NonBinding bool `json:"non_binding,omitempty"` // abaixo do quórum
+}
+
+// Estimativa de entrega de broadcasts feita pelo servidor (SERVER_STATS)
+type ServerStats struct {
+	BroadcastsSent   int     `json:"broadcasts_sent"`
+	BroadcastsMissed int     `json:"broadcasts_missed"`
+	DeliveryRatio    float64 `json:"delivery_ratio"`
+	Clients          []struct {
+		ClientID  string  `json:"client_id"`
+		Sent      int     `json:"sent"`
+		Missed    int     `json:"missed"`
+		LossRatio float64 `json:"loss_ratio"`
+	} `json:"clients"`
+}
+
+// Results é um placar recebido do servidor
+type Results struct {
+	Kind       string           // BROADCAST, RESYNC (reenviado a pedido) ou SNAPSHOT
+	SeqNum     int              // número do broadcast
+	VoteCounts map[string]int64 // nil em SNAPSHOT com parciais ocultos
+	Final      *FinalResult     // só no resultado final
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"crypto/sha256"
+	"math/bits"
+	"strconv"
+)
+
+// SolveChallenge procura um nonce tal que sha256(challenge + ":" + nonce)
+// comece com `difficulty` bits zero (mesma regra do servidor)
+func SolveChallenge(challenge string, difficulty int) string {
+	for n := 0; ; n++ {
+		nonce := strconv.Itoa(n)
+		sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+		if leadingZeroBits(sum[:]) >= difficulty {
+			return nonce
+		}
+	}
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c != 0 {
+			return n + bits.LeadingZeros8(c)
+		}
+		n += 8
+	}
+	return n
+}
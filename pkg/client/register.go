@@ -0,0 +1,139 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Registro confiável: o REGISTER é retransmitido até chegar a resposta da
+// tentativa atual. Cada tentativa leva um SeqNum próprio, ecoado pelo
+// servidor, e respostas atrasadas de tentativas anteriores são descartadas.
+const (
+	RegisterAttempts = 5
+	RegisterTimeout  = time.Second
+)
+
+type registration struct {
+	seq    atomic.Int64  // tentativa em andamento
+	done   atomic.Bool   // true após o ACK de registro
+	result chan error    // resposta da tentativa atual (nil = ACK)
+	left   chan struct{} // confirmação do UNREGISTER
+
+	m         sync.Mutex
+	challenge string // desafio de prova de trabalho já resolvido
+	nonce     string
+	auth      string // token do ACK de registro, repetido nos pacotes seguintes
+}
+
+// Registered diz se o servidor já confirmou o registro
+func (c *Client) Registered() bool { return c.reg.done.Load() }
+
+// RegisterTries devolve a tentativa de registro em andamento ou a que foi
+// confirmada (0 = Register não foi chamado)
+func (c *Client) RegisterTries() int { return int(c.reg.seq.Load()) }
+
+// Register envia o REGISTER e retransmite até obter resposta da tentativa
+// atual. Um CHALLENGE do servidor é resolvido automaticamente.
+func (c *Client) Register() error {
+	for attempt := 1; attempt <= RegisterAttempts; attempt++ {
+		c.reg.seq.Store(int64(attempt))
+		if err := c.sendRegister(); err != nil {
+			return err
+		}
+
+		select {
+		case err := <-c.reg.result:
+			return err
+		case <-time.After(RegisterTimeout):
+		case <-c.done:
+			return net.ErrClosed
+		}
+	}
+	return fmt.Errorf("%w após %d tentativas", ErrNoReply, RegisterAttempts)
+}
+
+// Resposta do servidor ao UNREGISTER
+const unregistered = "Registro cancelado"
+
+// Unregister avisa o servidor que o cliente está saindo, para ele parar de
+// enviar broadcasts a este endereço, e espera a confirmação por até
+// RegisterTimeout. O voto já dado continua contado.
+func (c *Client) Unregister() error {
+	if !c.reg.done.Load() {
+		return ErrNotRegistered
+	}
+	if err := c.Send(Message{Type: "UNREGISTER"}); err != nil {
+		return err
+	}
+	select {
+	case <-c.reg.left:
+		c.reg.done.Store(false)
+		return nil
+	case <-time.After(RegisterTimeout):
+		return ErrNoReply
+	case <-c.done:
+		return net.ErrClosed
+	}
+}
+
+// current diz se a resposta é da tentativa atual. SeqNum zero (servidor
+// sem suporte ao eco) é sempre aceito.
+func (r *registration) current(msg Message) bool {
+	return msg.SeqNum == 0 || int64(msg.SeqNum) == r.seq.Load()
+}
+
+// accept entrega ao Register a resposta da tentativa atual. Um ACK de
+// servidor abaixo de `minVersion` vira erro e o cliente não fica registrado.
+func (r *registration) accept(msg Message, minVersion int) {
+	var err error
+	switch {
+	case msg.Type == "ERROR":
+		err = errors.New(msg.Message)
+	case msg.Version < minVersion:
+		err = fmt.Errorf("%w: servidor %d, mínimo %d (cliente %d)", ErrIncompatible, msg.Version, minVersion, ProtocolVersion)
+	default:
+		r.m.Lock()
+		r.auth = msg.Auth
+		r.m.Unlock()
+		r.done.Store(true)
+	}
+
+	select {
+	case r.result <- err:
+	default:
+	}
+}
+
+// solve resolve o CHALLENGE do servidor e reenvia o REGISTER com a prova.
+// As retransmissões seguintes também levam a prova.
+func (c *Client) solve(msg Message) {
+	nonce := SolveChallenge(msg.Challenge, msg.Difficulty)
+
+	c.reg.m.Lock()
+	c.reg.challenge, c.reg.nonce = msg.Challenge, nonce
+	c.reg.m.Unlock()
+
+	c.sendRegister()
+}
+
+// sendRegister transmite o REGISTER da tentativa atual
+func (c *Client) sendRegister() error {
+	c.reg.m.Lock()
+	msg := Message{
+		Type:      "REGISTER",
+		SeqNum:    int(c.reg.seq.Load()),
+		Token:     c.opts.Token,
+		Challenge: c.reg.challenge,
+		Nonce:     c.reg.nonce,
+		Version:   ProtocolVersion,
+	}
+	if w := c.opts.Weight; w != 0 {
+		msg.Weight = &w
+	}
+	c.reg.m.Unlock()
+	return c.Send(msg)
+}
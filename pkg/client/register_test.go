@@ -0,0 +1,68 @@
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+// Depois de um reenvio do REGISTER, a resposta atrasada da tentativa
+// anterior (SeqNum antigo) é ignorada em favor da atual
+func TestRegisterIgnoresStaleAck(t *testing.T) {
+	p := newPeer(t)
+	c := p.dial("ana", Options{})
+	errc := make(chan error, 1)
+	go func() { errc <- c.Register() }()
+
+	first, _ := p.recv()
+	second, _ := p.recv() // sem resposta em RegisterTimeout: nova tentativa
+	if first.Type != "REGISTER" || second.Type != "REGISTER" || first.SeqNum != 1 || second.SeqNum != 2 {
+		t.Fatalf("tentativas = %+v e %+v, esperava REGISTER 1 e 2", first, second)
+	}
+
+	p.send(c, Message{Type: "ERROR", Message: "Tente novamente", SeqNum: first.SeqNum})
+	p.send(c, Message{Type: "ACK", Message: "Registrado", SeqNum: second.SeqNum, Auth: "tok"})
+	if err := <-errc; err != nil {
+		t.Fatalf("Register = %v: a resposta da tentativa 1 valeu para a 2", err)
+	}
+	if !c.Registered() || c.RegisterTries() != 2 {
+		t.Fatalf("registrado = %v, tentativas = %d", c.Registered(), c.RegisterTries())
+	}
+}
+
+// Sem SeqNum na resposta (servidor antigo), qualquer resposta vale
+func TestRegisterLegacyAck(t *testing.T) {
+	p := newPeer(t)
+	c := p.dial("ana", Options{})
+	errc := make(chan error, 1)
+	go func() { errc <- c.Register() }()
+
+	p.recv()
+	p.send(c, Message{Type: "ACK", Message: "Registrado"})
+	if err := <-errc; err != nil || !c.Registered() {
+		t.Fatalf("Register = %v com ACK sem SeqNum", err)
+	}
+}
+
+// Com MinServerVersion, o ACK de um servidor mais antigo vira
+// ErrIncompatible e o cliente não fica registrado
+func TestRegisterMinServerVersion(t *testing.T) {
+	for _, tc := range []struct {
+		version int
+		ok      bool
+	}{{0, false}, {1, true}} {
+		p := newPeer(t)
+		c := p.dial("ana", Options{MinServerVersion: 1})
+		errc := make(chan error, 1)
+		go func() { errc <- c.Register() }()
+
+		msg, _ := p.recv()
+		if msg.Version != ProtocolVersion {
+			t.Fatalf("REGISTER com versão %d, esperava %d", msg.Version, ProtocolVersion)
+		}
+		p.send(c, Message{Type: "ACK", Message: "Registrado", SeqNum: msg.SeqNum, Version: tc.version})
+		err := <-errc
+		if tc.ok != (err == nil) || tc.ok != c.Registered() || (!tc.ok && !errors.Is(err, ErrIncompatible)) {
+			t.Fatalf("servidor %d: Register = %v, registrado = %v", tc.version, err, c.Registered())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juander/udp-vote/internal/server"
+)
+
+// startServer sobe um servidor de verdade numa porta livre de 127.0.0.1
+func startServer(t *testing.T, options ...string) *server.UDPServer {
+	t.Helper()
+	s, err := server.NewUDPServer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start("127.0.0.1:0") }()
+	select {
+	case <-s.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// O caminho completo contra o servidor: registro, voto confirmado e o
+// broadcast com o placar atualizado
+func TestClientAgainstServer(t *testing.T) {
+	s := startServer(t, "A", "B")
+	c, err := Dial(s.Addr().String(), "ana", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	results := make(chan Results, 8)
+	c.Subscribe(func(r Results) { results <- r })
+
+	if _, err := c.Vote("A"); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("Vote antes do registro = %v", err)
+	}
+	if err := c.Register(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Registered() {
+		t.Fatal("Registered() falso depois do ACK")
+	}
+	if _, err := c.Vote("A"); err == nil || err.Error() != "Votação não iniciada" {
+		t.Fatalf("Vote antes da abertura = %v", err)
+	}
+
+	s.StartVoting(3600)
+	seq, err := c.Vote("A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq < 1 || c.RecordedVote() != "A" {
+		t.Fatalf("Vote = #%d, registrado %q", seq, c.RecordedVote())
+	}
+	for deadline := time.After(waitTimeout); ; {
+		select {
+		case r := <-results:
+			if r.Kind == "BROADCAST" && r.VoteCounts["A"] == 1 {
+				return
+			}
+		case <-deadline:
+			t.Fatal("broadcast com o voto não chegou")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Voto confiável: sem resposta em VoteTimeout, o VOTE é reenviado até
+// VoteRetries vezes. Cada voto leva um RequestID próprio (o mesmo em todas
+// as cópias), que o servidor ecoa na resposta: uma resposta com RequestID de
+// outro voto é sobra de um voto anterior e não encerra o atual. O servidor
+// conta só a primeira cópia que chega e repete o ACK às seguintes (um
+// servidor antigo responde "Voto duplicado", que depois de um reenvio
+// também confirma o voto); as respostas que sobram das outras cópias não
+// chegam à aplicação.
+const (
+	defaultVoteTimeout = 500 * time.Millisecond
+	defaultVoteRetries = 3
+)
+
+// Resposta do servidor a uma cópia de voto já contada
+const duplicateVote = "Voto duplicado"
+
+// Voto aguardando resposta
+type pendingVote struct {
+	msg     Message
+	timer   *time.Timer // próximo reenvio
+	retries int         // reenvios feitos
+	reply   chan error  // resposta (nil = ACK)
+}
+
+// finish entrega a resposta ao Vote (só a primeira conta)
+func (p *pendingVote) finish(err error) {
+	select {
+	case p.reply <- err:
+	default:
+	}
+}
+
+func (c *Client) voteTimeout() time.Duration {
+	if c.opts.VoteTimeout > 0 {
+		return c.opts.VoteTimeout
+	}
+	return defaultVoteTimeout
+}
+
+func (c *Client) voteRetries() int {
+	switch {
+	case c.opts.VoteRetries < 0:
+		return 0
+	case c.opts.VoteRetries == 0:
+		return defaultVoteRetries
+	}
+	return c.opts.VoteRetries
+}
+
+// Vote envia o voto e espera a resposta do servidor, reenviando enquanto
+// ela não chega. Devolve o número do voto (SeqNum do VOTE, que começa em 1)
+// e o erro do servidor, se recusado, ou ErrNoReply se nenhum envio for
+// respondido.
+func (c *Client) Vote(option string) (ackSeq int, err error) {
+	if !c.reg.done.Load() {
+		return 0, ErrNotRegistered
+	}
+	c.voting.Lock()
+	defer c.voting.Unlock()
+
+	c.m.Lock()
+	c.voteSeq++
+	seq := c.voteSeq
+	p := &pendingVote{
+		msg:   Message{Type: "VOTE", VoteOption: option, SeqNum: seq, RequestID: c.name + "-" + strconv.Itoa(seq)},
+		reply: make(chan error, 1),
+	}
+	p.timer = time.AfterFunc(c.voteTimeout(), func() { c.retransmit(p) })
+	c.pending, c.echoes = p, 0
+	c.m.Unlock()
+	defer func() {
+		c.m.Lock()
+		p.timer.Stop()
+		if c.pending == p {
+			c.pending = nil
+		}
+		c.m.Unlock()
+	}()
+
+	if err := c.Send(p.msg); err != nil {
+		return seq, err
+	}
+	select {
+	case err := <-p.reply:
+		return seq, err
+	case <-c.done:
+		return seq, net.ErrClosed
+	}
+}
+
+// RecordedVote devolve a opção que o servidor registrou no último voto
+// confirmado, como ele a ecoou no ACK (recorded_option). Vazio antes de um
+// voto confirmado ou com servidor que não envia o campo.
+func (c *Client) RecordedVote() string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.recorded
+}
+
+// retransmit reenvia o voto sem resposta, ou desiste após VoteRetries
+func (c *Client) retransmit(p *pendingVote) {
+	c.m.Lock()
+	if c.pending != p {
+		c.m.Unlock()
+		return // já respondido
+	}
+	if p.retries >= c.voteRetries() {
+		c.pending = nil
+		c.m.Unlock()
+		p.finish(ErrNoReply)
+		return
+	}
+	p.retries++
+	attempt := p.retries
+	p.timer.Reset(c.voteTimeout())
+	c.m.Unlock()
+
+	if c.opts.OnVoteRetry != nil {
+		c.opts.OnVoteRetry(p.msg.VoteOption, attempt)
+	}
+	c.Send(p.msg)
+}
+
+// voteReply entrega ao Vote em andamento o ACK ou ERROR recebido. Devolve a
+// mensagem a repassar à aplicação ("Voto duplicado" depois de um reenvio
+// vira a confirmação) e se ela é só o eco de uma cópia reenviada.
+func (c *Client) voteReply(msg Message) (Message, bool) {
+	confirm := slices.Contains(msg.Acked, c.name) ||
+		msg.Type == "ACK" && (msg.Message == "Voto registrado" || msg.Message == "Voto alterado")
+	duplicate := msg.Type == "ERROR" && msg.Message == duplicateVote
+
+	c.m.Lock()
+	p := c.pending
+	// Resposta a um voto anterior, já encerrado (RequestID vazio: servidor
+	// antigo, que não ecoa o campo)
+	if p != nil && msg.RequestID != "" && msg.RequestID != p.msg.RequestID {
+		c.m.Unlock()
+		return msg, true
+	}
+	if p == nil {
+		// Sobra das cópias do último voto
+		echo := c.echoes > 0 && (confirm || duplicate)
+		if echo {
+			c.echoes--
+		}
+		c.m.Unlock()
+		return msg, echo
+	}
+	if !confirm && msg.Type != "ERROR" {
+		c.m.Unlock()
+		return msg, false // ACK de outro comando
+	}
+	c.pending, c.echoes = nil, p.retries
+	if confirm && msg.RecordedOption != "" {
+		c.recorded = msg.RecordedOption
+	}
+	p.timer.Stop()
+	c.m.Unlock()
+
+	var err error
+	switch {
+	case duplicate && p.retries > 0:
+		// Uma cópia anterior foi contada e só a resposta a ela se perdeu
+		msg = Message{Type: "ACK", Message: "Voto registrado (confirmado no reenvio)"}
+	case msg.Type == "ERROR":
+		err = errors.New(msg.Message)
+	}
+	p.finish(err)
+	return msg, false
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// registered liga um Client ao peer e conclui o registro
+func (p *peer) registered(name string, opts Options) *Client {
+	p.t.Helper()
+	c := p.dial(name, opts)
+	errc := make(chan error, 1)
+	go func() { errc <- c.Register() }()
+	msg, _ := p.recv()
+	if msg.Type != "REGISTER" {
+		p.t.Fatalf("esperava REGISTER, chegou %+v", msg)
+	}
+	p.send(c, Message{Type: "ACK", Message: "Registrado", SeqNum: msg.SeqNum})
+	if err := <-errc; err != nil {
+		p.t.Fatal(err)
+	}
+	return c
+}
+
+// recvVote devolve o próximo VOTE recebido
+func (p *peer) recvVote() Message {
+	p.t.Helper()
+	msg, _ := p.recv()
+	if msg.Type != "VOTE" {
+		p.t.Fatalf("esperava VOTE, chegou %+v", msg)
+	}
+	return msg
+}
+
+// voteAsync chama c.Vote numa goroutine
+func voteAsync(c *Client, option string) chan error {
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Vote(option)
+		errc <- err
+	}()
+	return errc
+}
+
+func waitVote(t *testing.T, errc chan error) error {
+	t.Helper()
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(waitTimeout):
+		t.Fatal("Vote não retornou")
+		return nil
+	}
+}
+
+// Sem resposta, o VOTE é reenviado com o mesmo RequestID; a resposta que
+// sobra da cópia extra não chega à aplicação
+func TestVoteRetransmitAndEchoSuppression(t *testing.T) {
+	p := newPeer(t)
+	retries := make(chan int, 8)
+	acks := make(chan Message, 8)
+	c := p.registered("ana", Options{
+		VoteTimeout: 50 * time.Millisecond,
+		OnVoteRetry: func(_ string, attempt int) { retries <- attempt },
+		OnMessage: func(m Message) {
+			if m.RequestID != "" || m.Type == "BROADCAST" {
+				acks <- m
+			}
+		},
+	})
+
+	errc := voteAsync(c, "A")
+	first := p.recvVote()
+	second := p.recvVote() // reenvio: a primeira cópia "se perdeu"
+	if second.RequestID == "" || second.RequestID != first.RequestID || second.SeqNum != first.SeqNum {
+		t.Fatalf("reenvio %+v não repete o voto %+v", second, first)
+	}
+	if got := <-retries; got != 1 {
+		t.Fatalf("OnVoteRetry attempt = %d, esperava 1", got)
+	}
+
+	ack := Message{Type: "ACK", Message: "Voto registrado", RequestID: first.RequestID, RecordedOption: "A"}
+	p.send(c, ack)
+	if err := waitVote(t, errc); err != nil {
+		t.Fatal(err)
+	}
+	if c.RecordedVote() != "A" {
+		t.Fatalf("RecordedVote = %q", c.RecordedVote())
+	}
+
+	// O ACK repetido da segunda cópia é engolido; o BROADCAST seguinte passa
+	p.send(c, ack)
+	p.send(c, Message{Type: "BROADCAST", SeqNum: 1})
+	if m := <-acks; m.Type != "ACK" || m.Message != "Voto registrado" {
+		t.Fatalf("primeira mensagem = %+v, esperava o ACK do voto", m)
+	}
+	if m := <-acks; m.Type != "BROADCAST" {
+		t.Fatalf("eco da cópia reenviada chegou à aplicação: %+v", m)
+	}
+}
+
+// Sem nenhuma resposta, Vote desiste depois de VoteRetries reenvios
+func TestVoteNoReply(t *testing.T) {
+	p := newPeer(t)
+	c := p.registered("ana", Options{VoteTimeout: 10 * time.Millisecond, VoteRetries: 2})
+
+	errc := voteAsync(c, "A")
+	for i := 0; i < 3; i++ {
+		p.recvVote()
+	}
+	if err := waitVote(t, errc); !errors.Is(err, ErrNoReply) {
+		t.Fatalf("Vote = %v, esperava ErrNoReply", err)
+	}
+}
+
+// "Voto duplicado" depois de um reenvio quer dizer que a cópia anterior foi
+// contada: o voto é confirmado
+func TestVoteDuplicateAfterRetry(t *testing.T) {
+	p := newPeer(t)
+	replies := make(chan Message, 8)
+	c := p.registered("ana", Options{
+		VoteTimeout: 50 * time.Millisecond,
+		OnMessage: func(m Message) {
+			if m.Message != "Registrado" && (m.Type == "ACK" || m.Type == "ERROR") {
+				replies <- m
+			}
+		},
+	})
+
+	errc := voteAsync(c, "A")
+	p.recvVote()
+	msg := p.recvVote()
+	p.send(c, Message{Type: "ERROR", Message: duplicateVote, RequestID: msg.RequestID})
+	if err := waitVote(t, errc); err != nil {
+		t.Fatalf("Vote = %v, esperava confirmação", err)
+	}
+	if m := <-replies; m.Type != "ACK" {
+		t.Fatalf("aplicação recebeu %+v, esperava o ACK de confirmação", m)
+	}
+
+	// Sem reenvio, "Voto duplicado" é uma recusa de verdade
+	errc = voteAsync(c, "B")
+	msg = p.recvVote()
+	p.send(c, Message{Type: "ERROR", Message: duplicateVote, RequestID: msg.RequestID})
+	if err := waitVote(t, errc); err == nil || err.Error() != duplicateVote {
+		t.Fatalf("Vote = %v, esperava %q", err, duplicateVote)
+	}
+}
+
+// Resposta com RequestID de um voto anterior não encerra o voto atual
+func TestVoteIgnoresStaleReply(t *testing.T) {
+	p := newPeer(t)
+	c := p.registered("ana", Options{VoteTimeout: time.Second})
+
+	errc := voteAsync(c, "A")
+	first := p.recvVote()
+	p.send(c, Message{Type: "ACK", Message: "Voto registrado", RequestID: first.RequestID})
+	if err := waitVote(t, errc); err != nil {
+		t.Fatal(err)
+	}
+
+	errc = voteAsync(c, "B")
+	second := p.recvVote()
+	if second.RequestID == first.RequestID {
+		t.Fatalf("votos diferentes com o mesmo RequestID %q", first.RequestID)
+	}
+	p.send(c, Message{Type: "ERROR", Message: "Votação encerrada", RequestID: first.RequestID})
+	p.send(c, Message{Type: "ACK", Message: "Voto alterado", RequestID: second.RequestID})
+	if err := waitVote(t, errc); err != nil {
+		t.Fatalf("Vote = %v: resposta do voto anterior encerrou o atual", err)
+	}
+}
+
+func TestVoteBeforeRegister(t *testing.T) {
+	c := newPeer(t).dial("ana", Options{})
+	if _, err := c.Vote("A"); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("Vote = %v, esperava ErrNotRegistered", err)
+	}
+}
+
+// ACK agrupado (SetAckCoalesce no servidor): sem RequestID, o voto é
+// confirmado pelo nome na lista acked
+func TestVoteCombinedAck(t *testing.T) {
+	p := newPeer(t)
+	c := p.registered("ana", Options{VoteTimeout: time.Second})
+
+	errc := voteAsync(c, "A")
+	p.recvVote()
+	p.send(c, Message{Type: "ACK", Message: "Votos registrados (2)", Acked: []string{"bia"}})
+	select {
+	case err := <-errc:
+		t.Fatalf("ACK agrupado sem ana encerrou o voto: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	p.send(c, Message{Type: "ACK", Message: "Votos registrados (3)", Acked: []string{"bia", "ana", "caio"}})
+	if err := waitVote(t, errc); err != nil {
+		t.Fatalf("Vote = %v, esperava confirmação pelo ACK agrupado", err)
+	}
+}
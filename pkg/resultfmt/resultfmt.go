@@ -0,0 +1,117 @@
+// Package resultfmt formata placares de votação de forma consistente
+// para o cliente de terminal, os relatórios do servidor e exportações.
+package resultfmt
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Entry é uma linha do placar já ordenado
+type Entry struct {
+	Option  string
+	Votes   int64
+	Percent float64 // 0 a 100; 0 quando não há votos
+}
+
+// Sorted ordena o placar por votos (decrescente) e, no empate, por nome
+func Sorted(counts map[string]int64) []Entry {
+	// Total em float64: a soma de contagens perto do limite do int64 não estoura
+	total := 0.0
+	for _, n := range counts {
+		total += float64(n)
+	}
+
+	entries := make([]Entry, 0, len(counts))
+	for op, n := range counts {
+		e := Entry{Option: op, Votes: n}
+		if total > 0 {
+			e.Percent = float64(n) / total * 100
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Votes != entries[j].Votes {
+			return entries[i].Votes > entries[j].Votes
+		}
+		return entries[i].Option < entries[j].Option
+	})
+	return entries
+}
+
+// Breakdown devolve o placar em uma linha: "A 3 (60.0%), B 2 (40.0%)"
+func Breakdown(counts map[string]int64) string {
+	parts := make([]string, 0, len(counts))
+	for _, e := range Sorted(counts) {
+		parts = append(parts, fmt.Sprintf("%s %d (%.1f%%)", e.Option, e.Votes, e.Percent))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Table devolve o placar como tabela alinhada, com linha de total
+func Table(counts map[string]int64) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	total := int64(0)
+	fmt.Fprintln(w, "Opção\tVotos\t%\t")
+	for _, e := range Sorted(counts) {
+		total += e.Votes
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t\n", e.Option, e.Votes, e.Percent)
+	}
+	fmt.Fprintf(w, "Total\t%d\t\t\n", total)
+
+	w.Flush()
+	return buf.String()
+}
+
+// Bars devolve um gráfico de barras em texto; a opção mais votada
+// ocupa `width` caracteres e as demais são proporcionais a ela
+func Bars(counts map[string]int64, width int) string {
+	entries := Sorted(counts)
+
+	max, label := int64(0), 0
+	for _, e := range entries {
+		if e.Votes > max {
+			max = e.Votes
+		}
+		if len(e.Option) > label {
+			label = len(e.Option)
+		}
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		size := 0
+		if max > 0 {
+			size = int(float64(e.Votes) / float64(max) * float64(width))
+		}
+		fmt.Fprintf(&b, "%-*s | %s %d (%.1f%%)\n",
+			label, e.Option, strings.Repeat("█", size), e.Votes, e.Percent)
+	}
+	return b.String()
+}
+
+// CSV devolve o placar ordenado com cabeçalho option,votes,percent
+func CSV(counts map[string]int64) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"option", "votes", "percent"})
+	for _, e := range Sorted(counts) {
+		w.Write([]string{
+			e.Option,
+			strconv.FormatInt(e.Votes, 10),
+			strconv.FormatFloat(e.Percent, 'f', 2, 64),
+		})
+	}
+
+	w.Flush()
+	return buf.String()
+}
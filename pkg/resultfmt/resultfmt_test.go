@@ -0,0 +1,126 @@
+package resultfmt
+
+import (
+	"math"
+	"testing"
+)
+
+// Placares das tabelas abaixo: vitória simples, empate com opção zerada e
+// votação sem nenhum voto
+var (
+	simple = map[string]int64{"B": 2, "A": 3}
+	tied   = map[string]int64{"C": 0, "B": 1, "A": 1}
+	empty  = map[string]int64{"A": 0, "B": 0}
+)
+
+func TestSorted(t *testing.T) {
+	got := Sorted(tied)
+	want := []Entry{{"A", 1, 50}, {"B", 1, 50}, {"C", 0, 0}}
+	if len(got) != len(want) {
+		t.Fatalf("Sorted = %v, esperava %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Sorted[%d] = %+v, esperava %+v", i, got[i], want[i])
+		}
+	}
+
+	// A soma perto do limite do int64 não estoura o percentual
+	big := Sorted(map[string]int64{"A": math.MaxInt64, "B": math.MaxInt64})
+	if big[0].Percent != 50 || big[1].Percent != 50 {
+		t.Fatalf("percentuais com contagens no limite: %+v", big)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	cases := []struct {
+		name   string
+		counts map[string]int64
+		want   string
+	}{
+		{"simples", simple, "A 3 (60.0%), B 2 (40.0%)"},
+		{"empate", tied, "A 1 (50.0%), B 1 (50.0%), C 0 (0.0%)"},
+		{"sem votos", empty, "A 0 (0.0%), B 0 (0.0%)"},
+		{"sem opções", nil, ""},
+	}
+	for _, tc := range cases {
+		if got := Breakdown(tc.counts); got != tc.want {
+			t.Errorf("%s: Breakdown = %q, esperava %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	cases := []struct {
+		name   string
+		counts map[string]int64
+		want   string
+	}{
+		{"simples", simple, "" +
+			"  Opção  Votos     %\n" +
+			"      A      3  60.0\n" +
+			"      B      2  40.0\n" +
+			"  Total      5      \n"},
+		{"empate", tied, "" +
+			"  Opção  Votos     %\n" +
+			"      A      1  50.0\n" +
+			"      B      1  50.0\n" +
+			"      C      0   0.0\n" +
+			"  Total      2      \n"},
+		{"sem votos", empty, "" +
+			"  Opção  Votos    %\n" +
+			"      A      0  0.0\n" +
+			"      B      0  0.0\n" +
+			"  Total      0     \n"},
+	}
+	for _, tc := range cases {
+		if got := Table(tc.counts); got != tc.want {
+			t.Errorf("%s: Table =\n%s\nesperava\n%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBars(t *testing.T) {
+	cases := []struct {
+		name   string
+		counts map[string]int64
+		want   string
+	}{
+		{"simples", simple, "" +
+			"A | ██████████ 3 (60.0%)\n" +
+			"B | ██████ 2 (40.0%)\n"},
+		{"empate", tied, "" +
+			"A | ██████████ 1 (50.0%)\n" +
+			"B | ██████████ 1 (50.0%)\n" +
+			"C |  0 (0.0%)\n"},
+		{"sem votos", empty, "" +
+			"A |  0 (0.0%)\n" +
+			"B |  0 (0.0%)\n"},
+		{"rótulos alinhados", map[string]int64{"Sim": 1, "X": 1}, "" +
+			"Sim | ██████████ 1 (50.0%)\n" +
+			"X   | ██████████ 1 (50.0%)\n"},
+	}
+	for _, tc := range cases {
+		if got := Bars(tc.counts, 10); got != tc.want {
+			t.Errorf("%s: Bars =\n%s\nesperava\n%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	cases := []struct {
+		name   string
+		counts map[string]int64
+		want   string
+	}{
+		{"simples", simple, "option,votes,percent\nA,3,60.00\nB,2,40.00\n"},
+		{"empate", tied, "option,votes,percent\nA,1,50.00\nB,1,50.00\nC,0,0.00\n"},
+		{"sem votos", empty, "option,votes,percent\nA,0,0.00\nB,0,0.00\n"},
+		{"opção com vírgula", map[string]int64{"Sim, com ressalvas": 1}, "option,votes,percent\n\"Sim, com ressalvas\",1,100.00\n"},
+	}
+	for _, tc := range cases {
+		if got := CSV(tc.counts); got != tc.want {
+			t.Errorf("%s: CSV = %q, esperava %q", tc.name, got, tc.want)
+		}
+	}
+}
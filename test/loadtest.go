@@ -1,233 +1,84 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
-	"net"
-	"sync"
+	"io"
+	"log"
+	"os"
 	"time"
-)
-
-// =============== Estruturas comuns ao cliente/servidor ================
-
-type Message struct {
-	Type       string         `json:"type"`
-	ClientID   string         `json:"client_id"`
-	VoteOption string         `json:"vote,omitempty"`
-	Message    string         `json:"message,omitempty"`
-	VoteCounts map[string]int `json:"vote_counts,omitempty"`
-	SeqNum     int            `json:"seq_num,omitempty"`
-}
-
-// ============================ Estatísticas ============================
-
-type Stats struct {
-	m             sync.Mutex
-	sent          int
-	confirmed     int
-	broadcastRecv int
-	lost          int
-	lastSeq       int
-	broadcastFail int
-}
 
-func (s *Stats) AddSent()          { s.m.Lock(); s.sent++; s.m.Unlock() }
-func (s *Stats) AddConfirm()       { s.m.Lock(); s.confirmed++; s.m.Unlock() }
-func (s *Stats) AddBroadcast()     { s.m.Lock(); s.broadcastRecv++; s.m.Unlock() }
-func (s *Stats) AddBroadcastFail() { s.m.Lock(); s.broadcastFail++; s.m.Unlock() }
-
-func (s *Stats) SeqCheck(n int) {
-	s.m.Lock()
-	if s.lastSeq > 0 && n > s.lastSeq+1 {
-		s.lost += (n - s.lastSeq - 1)
-	}
-	s.lastSeq = n
-	s.m.Unlock()
-}
-
-func (s *Stats) Print() {
-	s.m.Lock()
-	defer s.m.Unlock()
-
-	fmt.Println("\n====== RESULTADOS UDP ======")
-	fmt.Printf("Votos enviados:      %d\n", s.sent)
-	fmt.Printf("Confirmados (ACK):   %d\n", s.confirmed)
-	fmt.Printf("Votos fantasma:      %d\n", s.sent-s.confirmed)
-	fmt.Printf("Broadcasts recebidos:%d\n", s.broadcastRecv)
-	fmt.Printf("Broadcasts perdidos: %d\n", s.broadcastFail)
-	fmt.Printf("Pacotes perdidos:    %d\n", s.lost)
-
-	total := s.broadcastRecv + s.lost
-	if total > 0 {
-		fmt.Printf("Perda estimada:      %.2f%%\n", float64(s.lost)/float64(total)*100)
-	}
-
-	fmt.Println("==============================\n")
-}
-
-// ========================= Clientes de Teste ==========================
-
-func activeClient(id int, stats *Stats, wg *sync.WaitGroup) {
-	defer wg.Done()
-	clientID := fmt.Sprintf("ACTIVE_%d", id)
-
-	conn, err := net.Dial("udp", "localhost:9000")
-	if err != nil {
-		fmt.Printf("[ACTIVE_%d] erro ao conectar\n", id)
-		return
-	}
-	defer conn.Close()
-
-	ackCh := make(chan struct{})
-	go func() {
-		buf := make([]byte, 2048)
-		for {
-			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
-			n, err := conn.Read(buf)
-			if err != nil {
-				return
-			}
-			var msg Message
-			if json.Unmarshal(buf[:n], &msg) != nil {
-				continue
-			}
-			if msg.Type == "ACK" {
-				ackCh <- struct{}{}
-				return
-			}
-		}
-	}()
+	"github.com/juander/udp-vote/internal/server"
+)
 
-	send(conn, "REGISTER", clientID, "")
-	select {
-	case <-ackCh:
-	case <-time.After(2 * time.Second):
-		fmt.Printf("[ACTIVE_%d] não recebeu ACK de registro\n", id)
-		return
+// Linha de comando do teste de carga (go run ./test). O cenário está em
+// scenario.go; o go test ./test roda o mesmo cenário com verificações
+// (loadtest_test.go).
+func main() {
+	cfg := loadConfig{Addr: "localhost:9000", Listen: 10 * time.Second, Out: os.Stdout}
+	flag.IntVar(&cfg.Active, "active", 25, "clientes ativos (recebem broadcast)")
+	flag.IntVar(&cfg.Inactive, "inactive", 5, "clientes inativos (param de ler após votar)")
+	flag.StringVar(&cfg.Addr, "addr", cfg.Addr, "endereço do servidor")
+	flag.DurationVar(&cfg.Listen, "listen", cfg.Listen, "quanto tempo cada cliente fica no ar depois de votar")
+	embedded := flag.Bool("embedded", false, "sobe um servidor no próprio processo em -addr")
+	reusePort := flag.Int("reuseport", 0, "com -embedded: sockets do servidor com SO_REUSEPORT")
+	flood := flag.Duration("flood", 0, "com -embedded: mede a vazão de leitura saturando o servidor por esse tempo e sai")
+	minConfirmed := flag.Float64("min-confirmed", 0, "fração mínima de votos confirmados para passar (0 = não verifica)")
+	flag.Float64Var(&cfg.Loss, "loss", 0, "probabilidade de descartar cada datagrama enviado pelos clientes (0 a 1)")
+	flag.Parse()
+
+	if cfg.Loss < 0 || cfg.Loss >= 1 {
+		fmt.Println("-loss deve estar entre 0 e 1")
+		os.Exit(2)
 	}
 
-	time.Sleep(80 * time.Millisecond)
-	stats.AddSent()
-	send(conn, "VOTE", clientID, "A")
-
-	broadcasts := make(map[int]bool)
-	end := time.Now().Add(10 * time.Second)
-	buf := make([]byte, 4096)
-
-	for time.Now().Before(end) {
-		conn.SetReadDeadline(time.Now().Add(1 * time.Second))
-		n, err := conn.Read(buf)
+	var srv *server.UDPServer
+	if *embedded {
+		// Servidor real no mesmo processo, com a votação já aberta
+		log.SetOutput(io.Discard)
+		var err error
+		srv, err = startEmbedded(cfg.Addr, *reusePort)
 		if err != nil {
-			continue
-		}
-		var msg Message
-		if json.Unmarshal(buf[:n], &msg) != nil {
-			continue
+			fmt.Println("Erro ao abrir servidor:", err)
+			os.Exit(2)
 		}
-		switch msg.Type {
-		case "ACK":
-			if msg.Message == "Voto registrado" {
-				stats.AddConfirm()
-			}
-		case "BROADCAST":
-			stats.AddBroadcast()
-			stats.SeqCheck(msg.SeqNum)
-			broadcasts[msg.SeqNum] = true
-		}
-	}
+		cfg.Addr = srv.Addr().String()
 
-	if len(broadcasts) == 0 {
-		stats.AddBroadcastFail()
-		fmt.Printf("[ACTIVE_%d] não recebeu nenhum broadcast!\n", id)
-	}
-}
-
-func inactiveClient(id int, stats *Stats, wg *sync.WaitGroup) {
-	defer wg.Done()
-	clientID := fmt.Sprintf("INACTIVE_%d", id)
-
-	conn, err := net.Dial("udp", "localhost:9000")
-	if err != nil {
-		fmt.Printf("[INACTIVE_%d] erro ao conectar\n", id)
-		return
-	}
-	defer conn.Close()
-
-	ackCh := make(chan struct{})
-	go func() {
-		buf := make([]byte, 2048)
-		for {
-			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
-			n, err := conn.Read(buf)
-			if err != nil {
-				return
-			}
-			var msg Message
-			if json.Unmarshal(buf[:n], &msg) != nil {
-				continue
-			}
-			if msg.Type == "ACK" {
-				ackCh <- struct{}{}
-				return
-			}
+		if *flood > 0 {
+			floodTest(srv, cfg.Addr, *flood)
+			return
 		}
-	}()
-
-	send(conn, "REGISTER", clientID, "")
-	select {
-	case <-ackCh:
-	case <-time.After(2 * time.Second):
-		fmt.Printf("[INACTIVE_%d] não recebeu ACK de registro\n", id)
-		return
-	}
-
-	time.Sleep(120 * time.Millisecond)
-	stats.AddSent()
-	send(conn, "VOTE", clientID, "B")
-
-	buffer := make([]byte, 2048)
-	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-	n, _ := conn.Read(buffer)
-
-	var msg Message
-	if json.Unmarshal(buffer[:n], &msg) == nil && msg.Type == "ACK" && msg.Message == "Voto registrado" {
-		stats.AddConfirm()
 	}
 
-	fmt.Printf("[INACTIVE_%d] cliente ficou inativo (não recebe mais mensagens)\n", id)
-	time.Sleep(10 * time.Second)
-}
-
-func send(conn net.Conn, t, id, vote string) {
-	data, _ := json.Marshal(Message{Type: t, ClientID: id, VoteOption: vote})
-	conn.Write(data)
-}
-
-// =========================== MAIN TEST ================================
-
-func main() {
-	stats := &Stats{}
-	var wg sync.WaitGroup
-
 	fmt.Println("==== TESTE UDP ====")
 	fmt.Println("ACTIVE → recebe broadcast")
 	fmt.Println("INACTIVE → desconecta e não recebe broadcast")
 
 	time.Sleep(1 * time.Second)
-
-	for i := 0; i < 5; i++ {
-		wg.Add(1)
-		go inactiveClient(i, stats, &wg)
+	stats := runLoad(cfg)
+	stats.Print(os.Stdout)
+
+	passed := true
+	// Com perda simulada e servidor embutido, os votos contados precisam
+	// condizer com a taxa de descarte
+	if cfg.Loss > 0 && srv != nil {
+		passed = verdict(checkLoss(stats, srv, cfg.Loss)) && passed
 	}
-
-	time.Sleep(500 * time.Millisecond)
-
-	for i := 0; i < 25; i++ {
-		wg.Add(1)
-		go activeClient(i, stats, &wg)
-		time.Sleep(20 * time.Millisecond)
+	// Critério de aprovação: limita a proporção de votos fantasma
+	if *minConfirmed > 0 {
+		passed = verdict(checkConfirmed(stats, *minConfirmed)) && passed
 	}
+	if !passed {
+		os.Exit(1)
+	}
+}
 
-	wg.Wait()
-	stats.Print()
+// verdict imprime o resultado de uma verificação
+func verdict(detail string, ok bool) bool {
+	if ok {
+		fmt.Println("PASSOU:", detail)
+	} else {
+		fmt.Println("FALHOU:", detail)
+	}
+	return ok
 }
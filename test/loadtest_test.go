@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Ajustes do cenário no go test: flags depois de -args ou variáveis de
+// ambiente, ex.:
+//
+//	go test ./test -run TestLoad -args -load.active 100 -load.min-confirmed 0.9
+//	UDPVOTE_LOAD_LOSS=0.2 go test ./test -run TestLoad
+var (
+	loadActive       = flag.Int("load.active", envInt("UDPVOTE_LOAD_ACTIVE", 10), "clientes ativos [$UDPVOTE_LOAD_ACTIVE]")
+	loadInactive     = flag.Int("load.inactive", envInt("UDPVOTE_LOAD_INACTIVE", 2), "clientes inativos [$UDPVOTE_LOAD_INACTIVE]")
+	loadLoss         = flag.Float64("load.loss", envFloat("UDPVOTE_LOAD_LOSS", 0), "perda simulada na ida [$UDPVOTE_LOAD_LOSS]")
+	loadListen       = flag.Duration("load.listen", envDuration("UDPVOTE_LOAD_LISTEN", 2*time.Second), "tempo no ar depois de votar [$UDPVOTE_LOAD_LISTEN]")
+	loadMinConfirmed = flag.Float64("load.min-confirmed", envFloat("UDPVOTE_LOAD_MIN_CONFIRMED", 0.75), "fração mínima de votos confirmados [$UDPVOTE_LOAD_MIN_CONFIRMED]")
+	loadMaxLoss      = flag.Float64("load.max-loss", envFloat("UDPVOTE_LOAD_MAX_LOSS", 0.05), "fração máxima de broadcasts perdidos pelos ativos [$UDPVOTE_LOAD_MAX_LOSS]")
+)
+
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return v
+	}
+	return def
+}
+
+func envFloat(name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil {
+		return v
+	}
+	return def
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(name)); err == nil {
+		return v
+	}
+	return def
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard) // o servidor embutido loga cada pacote
+	}
+	os.Exit(m.Run())
+}
+
+// TestLoad roda o cenário da linha de comando contra o servidor real numa
+// porta livre e falha se os votos fantasma, a perda de broadcasts ou a
+// contagem do servidor saírem dos limites
+func TestLoad(t *testing.T) {
+	if testing.Short() {
+		t.Skip("teste de carga (leva alguns segundos)")
+	}
+	if *loadLoss < 0 || *loadLoss >= 1 {
+		t.Fatalf("-load.loss deve estar entre 0 e 1, veio %g", *loadLoss)
+	}
+	srv, err := startEmbedded("127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	out := io.Discard
+	if testing.Verbose() {
+		out = os.Stdout
+	}
+	cfg := loadConfig{
+		Addr:     srv.Addr().String(),
+		Active:   *loadActive,
+		Inactive: *loadInactive,
+		Loss:     *loadLoss,
+		Listen:   *loadListen,
+		Out:      out,
+	}
+	stats := runLoad(cfg)
+	stats.Print(out)
+
+	if detail, ok := checkConfirmed(stats, *loadMinConfirmed); !ok {
+		t.Fatalf("votos fantasma acima do limite: %s", detail)
+	}
+	if r := stats.LossRatio(); *loadLoss == 0 && r > *loadMaxLoss {
+		t.Fatalf("perda de broadcasts %.2f%%, máximo %.2f%%", r*100, *loadMaxLoss*100)
+	}
+	if detail, ok := checkLoss(stats, srv, cfg.Loss); !ok {
+		t.Fatalf("contagem do servidor fora do esperado: %s", detail)
+	}
+
+	// Todo voto confirmado foi contado
+	stats.m.Lock()
+	confirmed, broadcastFail := stats.confirmed, stats.broadcastFail
+	stats.m.Unlock()
+	if got := int(srv.Results()["A"] + srv.Results()["B"]); got < confirmed {
+		t.Fatalf("%d votos contados, mas %d confirmados", got, confirmed)
+	}
+	if *loadLoss == 0 && broadcastFail > 0 {
+		t.Fatalf("%d clientes ativos sem nenhum broadcast", broadcastFail)
+	}
+}
+
+// BenchmarkLoad mede o ciclo REGISTER → ACK → VOTE → ACK de um cliente
+// contra o servidor real, um ClientID por iteração
+func BenchmarkLoad(b *testing.B) {
+	srv, err := startEmbedded("127.0.0.1:0", 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer srv.Stop()
+	addr := srv.Addr().String()
+
+	confirmed := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if voteOnce(addr, fmt.Sprintf("BENCH_%d", i)) {
+			confirmed++
+		}
+	}
+	b.StopTimer()
+
+	ratio := float64(confirmed) / float64(b.N)
+	b.ReportMetric(ratio, "confirmed/op")
+	if ratio < *loadMinConfirmed {
+		b.Fatalf("%.2f%% confirmados (mínimo %.2f%%)", ratio*100, *loadMinConfirmed*100)
+	}
+}
+
+// voteOnce registra id, vota em A e diz se o voto foi confirmado
+func voteOnce(addr, id string) bool {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	buf := make([]byte, maxMessageSize)
+	// Espera um ACK com a mensagem dada ("" = qualquer uma), ignorando broadcasts
+	await := func(want string) bool {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return false
+			}
+			var msg Message
+			if json.Unmarshal(buf[:n], &msg) == nil && msg.Type == "ACK" && (want == "" || msg.Message == want) {
+				return true
+			}
+		}
+	}
+
+	send(conn, "REGISTER", id, "")
+	if !await("") {
+		return false
+	}
+	send(conn, "VOTE", id, "A")
+	return await("Voto registrado")
+}
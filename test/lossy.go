@@ -0,0 +1,45 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"net"
+	"sync/atomic"
+)
+
+// ========================== Perda simulada ============================
+
+// LossyConn descarta cada datagrama enviado com probabilidade `rate`,
+// simulando perda na ida ao servidor. O Write descartado não falha: como no
+// UDP real, quem envia não fica sabendo.
+type LossyConn struct {
+	net.Conn
+	rate    float64
+	dropped *atomic.Int64 // descartes de todos os LossyConn da mesma rodada
+}
+
+// newLossyConn envolve a conexão; com rate <= 0 ela é devolvida intacta
+func newLossyConn(conn net.Conn, rate float64, dropped *atomic.Int64) net.Conn {
+	if rate <= 0 {
+		return conn
+	}
+	return &LossyConn{Conn: conn, rate: rate, dropped: dropped}
+}
+
+func (c *LossyConn) Write(b []byte) (int, error) {
+	if rand.Float64() < c.rate {
+		c.dropped.Add(1)
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
+
+// lossConsistent diz se `counted` votos contados pelo servidor, de `sent`
+// enviados, condizem com a perda `rate`: o esperado é sent·(1−rate), com
+// tolerância de 4 desvios-padrão da binomial (mais 1 voto para amostras
+// pequenas)
+func lossConsistent(sent, counted int, rate float64) (expected, tolerance float64, ok bool) {
+	expected = float64(sent) * (1 - rate)
+	tolerance = 4*math.Sqrt(float64(sent)*rate*(1-rate)) + 1
+	return expected, tolerance, math.Abs(float64(counted)-expected) <= tolerance
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingConn conta os datagramas que chegam de fato à rede
+type countingConn struct {
+	net.Conn
+	writes int
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	c.writes++
+	return len(b), nil
+}
+
+// O LossyConn descarta na proporção pedida, sem erro para quem envia, e
+// cada datagrama ou sai ou entra na conta dos descartes
+func TestLossyConnDropRate(t *testing.T) {
+	const writes = 10000
+	for _, rate := range []float64{0.1, 0.3, 0.5} {
+		inner := &countingConn{}
+		var dropped atomic.Int64
+		conn := newLossyConn(inner, rate, &dropped)
+		for i := 0; i < writes; i++ {
+			if n, err := conn.Write([]byte("x")); n != 1 || err != nil {
+				t.Fatalf("Write = %d, %v", n, err)
+			}
+		}
+		if inner.writes+int(dropped.Load()) != writes {
+			t.Fatalf("perda %.1f: %d enviados + %d descartados != %d", rate, inner.writes, dropped.Load(), writes)
+		}
+		if expected, tolerance, ok := lossConsistent(writes, inner.writes, rate); !ok {
+			t.Fatalf("perda %.1f: %d enviados, esperava %.0f ± %.0f", rate, inner.writes, expected, tolerance)
+		}
+	}
+
+	inner := &countingConn{}
+	if conn := newLossyConn(inner, 0, new(atomic.Int64)); conn != net.Conn(inner) {
+		t.Fatal("sem perda, a conexão deveria ser devolvida intacta")
+	}
+}
+
+func TestLossConsistent(t *testing.T) {
+	cases := []struct {
+		sent, counted int
+		rate          float64
+		ok            bool
+	}{
+		{1000, 700, 0.3, true},
+		{1000, 750, 0.3, true}, // dentro de 4 desvios mais 1 (±59)
+		{1000, 760, 0.3, false},
+		{1000, 1000, 0.3, false},
+		{1000, 500, 0.3, false},
+		{10, 10, 0, true},
+		{10, 9, 0, true}, // 1 voto de folga em amostras pequenas
+		{10, 8, 0, false},
+	}
+	for _, tc := range cases {
+		if _, _, ok := lossConsistent(tc.sent, tc.counted, tc.rate); ok != tc.ok {
+			t.Errorf("lossConsistent(%d, %d, %.1f) = %v", tc.sent, tc.counted, tc.rate, ok)
+		}
+	}
+}
+
+// Cenário com 30% de perda na ida: a contagem do servidor condiz com a
+// perda injetada
+func TestLoadWithLoss(t *testing.T) {
+	if testing.Short() {
+		t.Skip("teste de carga (leva alguns segundos)")
+	}
+	srv, err := startEmbedded("127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	cfg := loadConfig{Addr: srv.Addr().String(), Active: 30, Loss: 0.3, Listen: 500 * time.Millisecond, Out: io.Discard}
+	stats := runLoad(cfg)
+	if stats.dropped.Load() == 0 {
+		t.Fatal("nenhum datagrama descartado com -loss 0.3")
+	}
+	if detail, ok := checkLoss(stats, srv, cfg.Loss); !ok {
+		t.Fatalf("contagem do servidor fora do esperado: %s", detail)
+	}
+}
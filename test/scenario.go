@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/juander/udp-vote/internal/server"
+)
+
+// ======================== Cenário de carga ============================
+//
+// O mesmo cenário roda pela linha de comando (go run ./test) e pelo go test
+// (TestLoad/BenchmarkLoad): clientes inativos votam e param de ler, clientes
+// ativos votam e acompanham os broadcasts. Os votos sem ACK são os votos
+// fantasma; os buracos de SeqNum, a perda de broadcasts.
+
+// Configuração de uma rodada do cenário
+type loadConfig struct {
+	Addr     string        // endereço do servidor
+	Active   int           // clientes ativos (recebem broadcast)
+	Inactive int           // clientes que param de ler após votar
+	Loss     float64       // probabilidade de descartar cada datagrama enviado
+	Listen   time.Duration // quanto tempo cada cliente fica no ar depois de votar
+	Out      io.Writer     // avisos de cada cliente (io.Discard = silencioso)
+}
+
+// Maior payload possível em um datagrama UDP sobre IPv4; buffers menores
+// truncam broadcasts grandes e inflam a perda medida
+const maxMessageSize = 65507
+
+type Message struct {
+	Type       string           `json:"type"`
+	ClientID   string           `json:"client_id"`
+	VoteOption string           `json:"vote,omitempty"`
+	Message    string           `json:"message,omitempty"`
+	VoteCounts map[string]int64 `json:"vote_counts,omitempty"`
+	SeqNum     int              `json:"seq_num,omitempty"`
+}
+
+// ============================ Estatísticas ============================
+
+type Stats struct {
+	m             sync.Mutex
+	sent          int
+	confirmed     int
+	broadcastRecv int
+	lost          int
+	lastSeq       int
+	broadcastFail int
+
+	dropped atomic.Int64 // datagramas descartados pela perda simulada
+}
+
+func (s *Stats) AddSent()          { s.m.Lock(); s.sent++; s.m.Unlock() }
+func (s *Stats) AddConfirm()       { s.m.Lock(); s.confirmed++; s.m.Unlock() }
+func (s *Stats) AddBroadcast()     { s.m.Lock(); s.broadcastRecv++; s.m.Unlock() }
+func (s *Stats) AddBroadcastFail() { s.m.Lock(); s.broadcastFail++; s.m.Unlock() }
+
+func (s *Stats) SeqCheck(n int) {
+	s.m.Lock()
+	if s.lastSeq > 0 && n > s.lastSeq+1 {
+		s.lost += (n - s.lastSeq - 1)
+	}
+	s.lastSeq = n
+	s.m.Unlock()
+}
+
+func (s *Stats) Print(w io.Writer) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	fmt.Fprintln(w, "\n====== RESULTADOS UDP ======")
+	fmt.Fprintf(w, "Votos enviados:      %d\n", s.sent)
+	fmt.Fprintf(w, "Confirmados (ACK):   %d\n", s.confirmed)
+	fmt.Fprintf(w, "Votos fantasma:      %d\n", s.sent-s.confirmed)
+	fmt.Fprintf(w, "Broadcasts recebidos:%d\n", s.broadcastRecv)
+	fmt.Fprintf(w, "Broadcasts perdidos: %d\n", s.broadcastFail)
+	fmt.Fprintf(w, "Pacotes perdidos:    %d\n", s.lost)
+	if n := s.dropped.Load(); n > 0 {
+		fmt.Fprintf(w, "Descartados (-loss): %d\n", n)
+	}
+	if r := s.lossRatioLocked(); r > 0 {
+		fmt.Fprintf(w, "Perda estimada:      %.2f%%\n", r*100)
+	}
+	fmt.Fprint(w, "==============================\n\n")
+}
+
+// ConfirmedRatio devolve a fração de votos enviados que recebeu ACK
+func (s *Stats) ConfirmedRatio() float64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.sent == 0 {
+		return 0
+	}
+	return float64(s.confirmed) / float64(s.sent)
+}
+
+// LossRatio devolve a fração estimada de broadcasts perdidos pelos ativos
+func (s *Stats) LossRatio() float64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.lossRatioLocked()
+}
+
+func (s *Stats) lossRatioLocked() float64 {
+	total := s.broadcastRecv + s.lost
+	if total == 0 {
+		return 0
+	}
+	return float64(s.lost) / float64(total)
+}
+
+// =========================== Verificações =============================
+
+// checkConfirmed compara a fração de votos confirmados com o mínimo
+func checkConfirmed(stats *Stats, min float64) (string, bool) {
+	ratio := stats.ConfirmedRatio()
+	return fmt.Sprintf("%.2f%% confirmados (mínimo %.2f%%)", ratio*100, min*100), ratio >= min
+}
+
+// checkLoss confere se os votos contados pelo servidor condizem com a perda
+// simulada (com loss 0, todos os enviados precisam ter sido contados)
+func checkLoss(stats *Stats, srv *server.UDPServer, loss float64) (string, bool) {
+	counted := 0
+	for _, n := range srv.Results() {
+		counted += int(n)
+	}
+	stats.m.Lock()
+	sent := stats.sent
+	stats.m.Unlock()
+	expected, tolerance, ok := lossConsistent(sent, counted, loss)
+	return fmt.Sprintf("%d votos contados de %d enviados (esperado %.0f ± %.0f com -loss %.2f)",
+		counted, sent, expected, tolerance, loss), ok
+}
+
+// ========================= Clientes de Teste ==========================
+
+// runLoad executa o cenário contra cfg.Addr e devolve as estatísticas
+func runLoad(cfg loadConfig) *Stats {
+	stats := &Stats{}
+	var wg sync.WaitGroup
+
+	for i := 0; i < cfg.Inactive; i++ {
+		wg.Add(1)
+		go inactiveClient(cfg, i, stats, &wg)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	for i := 0; i < cfg.Active; i++ {
+		wg.Add(1)
+		go activeClient(cfg, i, stats, &wg)
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	wg.Wait()
+	return stats
+}
+
+// dialRegistered abre o socket do cliente e espera o ACK do REGISTER
+func dialRegistered(cfg loadConfig, clientID string, stats *Stats) (net.Conn, bool) {
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		fmt.Fprintf(cfg.Out, "[%s] erro ao conectar\n", clientID)
+		return nil, false
+	}
+	conn = newLossyConn(conn, cfg.Loss, &stats.dropped)
+
+	ackCh := make(chan struct{})
+	go func() {
+		buf := make([]byte, maxMessageSize)
+		for {
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			var msg Message
+			if json.Unmarshal(buf[:n], &msg) != nil {
+				continue
+			}
+			if msg.Type == "ACK" {
+				ackCh <- struct{}{}
+				return
+			}
+		}
+	}()
+
+	send(conn, "REGISTER", clientID, "")
+	select {
+	case <-ackCh:
+		return conn, true
+	case <-time.After(2 * time.Second):
+		fmt.Fprintf(cfg.Out, "[%s] não recebeu ACK de registro\n", clientID)
+		conn.Close()
+		return nil, false
+	}
+}
+
+func activeClient(cfg loadConfig, id int, stats *Stats, wg *sync.WaitGroup) {
+	defer wg.Done()
+	clientID := fmt.Sprintf("ACTIVE_%d", id)
+
+	conn, ok := dialRegistered(cfg, clientID, stats)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	time.Sleep(80 * time.Millisecond)
+	stats.AddSent()
+	send(conn, "VOTE", clientID, "A")
+
+	broadcasts := make(map[int]bool)
+	end := time.Now().Add(cfg.Listen)
+	buf := make([]byte, maxMessageSize)
+
+	for time.Now().Before(end) {
+		conn.SetReadDeadline(time.Now().Add(1 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			continue
+		}
+		var msg Message
+		if json.Unmarshal(buf[:n], &msg) != nil {
+			continue
+		}
+		switch msg.Type {
+		case "ACK":
+			if msg.Message == "Voto registrado" {
+				stats.AddConfirm()
+			}
+		case "BROADCAST":
+			stats.AddBroadcast()
+			stats.SeqCheck(msg.SeqNum)
+			broadcasts[msg.SeqNum] = true
+		}
+	}
+
+	if len(broadcasts) == 0 {
+		stats.AddBroadcastFail()
+		fmt.Fprintf(cfg.Out, "[%s] não recebeu nenhum broadcast!\n", clientID)
+	}
+}
+
+func inactiveClient(cfg loadConfig, id int, stats *Stats, wg *sync.WaitGroup) {
+	defer wg.Done()
+	clientID := fmt.Sprintf("INACTIVE_%d", id)
+
+	conn, ok := dialRegistered(cfg, clientID, stats)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	time.Sleep(120 * time.Millisecond)
+	stats.AddSent()
+	send(conn, "VOTE", clientID, "B")
+
+	buffer := make([]byte, maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	n, _ := conn.Read(buffer)
+
+	var msg Message
+	if json.Unmarshal(buffer[:n], &msg) == nil && msg.Type == "ACK" && msg.Message == "Voto registrado" {
+		stats.AddConfirm()
+	}
+
+	fmt.Fprintf(cfg.Out, "[%s] cliente ficou inativo (não recebe mais mensagens)\n", clientID)
+	time.Sleep(cfg.Listen)
+}
+
+func send(conn net.Conn, t, id, vote string) {
+	data, _ := json.Marshal(Message{Type: t, ClientID: id, VoteOption: vote})
+	conn.Write(data)
+}
+
+// ========================== Servidor embutido =========================
+
+// startEmbedded sobe um servidor real no próprio processo em addr, com a
+// votação já aberta (addr com porta 0 escolhe uma livre; veja srv.Addr)
+func startEmbedded(addr string, reusePort int) (*server.UDPServer, error) {
+	srv, err := server.NewUDPServer([]string{"A", "B", "C"})
+	if err != nil {
+		return nil, err
+	}
+	srv.SetReusePort(reusePort)
+	started := make(chan error, 1)
+	go func() { started <- srv.Start(addr) }()
+	select {
+	case <-srv.Ready():
+	case err := <-started:
+		return nil, err
+	}
+	srv.StartVoting(60)
+	return srv, nil
+}
+
+// ========================== Vazão de leitura ==========================
+
+// Sockets de origem do flood; portas diferentes fazem o kernel espalhar os
+// datagramas entre os sockets do servidor com SO_REUSEPORT
+const floodSenders = 16
+
+// floodTest satura o servidor embutido com PINGs durante d e mede quantos
+// datagramas ele leu por segundo. Comparar -reuseport 1 com -reuseport 4
+// mostra o ganho de vários sockets; a distribuição por socket confirma que
+// todos recebem tráfego.
+func floodTest(srv *server.UDPServer, addr string, d time.Duration) {
+	ping, _ := json.Marshal(Message{Type: "PING", ClientID: "FLOOD"})
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < floodSenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := net.Dial("udp", addr)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					conn.Write(ping)
+				}
+			}
+		}()
+	}
+
+	// Conta só o que foi lido dentro da janela medida
+	before := srv.SocketPackets()
+	time.Sleep(d)
+	after := srv.SocketPackets()
+	close(stop)
+	wg.Wait()
+
+	var read int64
+	for i := range after {
+		after[i] -= before[i]
+		read += after[i]
+	}
+	fmt.Println("\n====== VAZÃO DE LEITURA ======")
+	fmt.Printf("Sockets do servidor: %d\n", len(after))
+	fmt.Printf("Duração:             %v\n", d)
+	fmt.Printf("Datagramas lidos:    %d\n", read)
+	fmt.Printf("Vazão:               %.0f datagramas/s\n", float64(read)/d.Seconds())
+	for i, c := range after {
+		fmt.Printf("Socket %d:            %d\n", i, c)
+	}
+	fmt.Print("==============================\n\n")
+}